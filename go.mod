@@ -1,9 +1,9 @@
 module github.com/spotlightpa/linkrot
 
-// +heroku goVersion go1.16
+// +heroku goVersion go1.21
 // +heroku install ./...
 
-go 1.16
+go 1.21
 
 require (
 	github.com/carlmjohnson/errutil v0.20.1
@@ -12,5 +12,7 @@ require (
 	github.com/carlmjohnson/requests v0.21.8
 	github.com/getsentry/sentry-go v0.11.0
 	golang.org/x/net v0.0.0-20210525063256-abc453219eb5
+	golang.org/x/sync v0.11.0
+	golang.org/x/text v0.3.6
 	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba
 )
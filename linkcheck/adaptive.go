@@ -0,0 +1,78 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// adaptiveLatencyTarget is the time to first byte below which
+// the adaptive controller keeps adding crawlers.
+const adaptiveLatencyTarget = time.Second
+
+// aimd limits concurrent fetches with additive increase, multiplicative decrease:
+// it adds a crawler after a full round of fast responses
+// and halves the crawlers when the origin starts returning 429 or 5xx
+// or timing out.
+// Only responses from hosts in scope count, so third-party hosts
+// and results served from a cache don't steer the crawl of the origin.
+type aimd struct {
+	sc         scope
+	limit, max int
+	// fast counts fast responses since the last increase
+	fast int
+	// cooldown is how many more responses to see before cutting again.
+	// Requests already in flight when the limit was cut
+	// shouldn't cut it again.
+	cooldown int
+}
+
+func newAIMD(sc scope, max int) *aimd {
+	return &aimd{sc: sc, limit: 1, max: max}
+}
+
+// overloaded reports whether a response suggests the server is struggling.
+func overloaded(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// timedOut reports whether a request failed because the server was too slow.
+func timedOut(err error) bool {
+	var ne net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &ne) && ne.Timeout())
+}
+
+// observe adjusts the limit for a fetch result
+// and reports whether the limit changed.
+func (a *aimd) observe(fr fetchResult) bool {
+	// Cached results and requests that failed without timing out
+	// say nothing about the origin's speed
+	if (fr.status == 0 && !timedOut(fr.err)) || fr.status == http.StatusNotModified || !a.sc.contains(fr.url) {
+		return false
+	}
+	if a.cooldown > 0 {
+		a.cooldown--
+	}
+	switch {
+	case overloaded(fr.status), fr.status == 0:
+		a.fast = 0
+		if a.cooldown > 0 || a.limit == 1 {
+			return false
+		}
+		a.cooldown = a.limit
+		a.limit /= 2
+		return true
+	case fr.timings.ttfb < adaptiveLatencyTarget:
+		a.fast++
+		if a.fast < a.limit || a.limit == a.max {
+			return false
+		}
+		a.limit++
+		a.fast = 0
+		return true
+	}
+	return false
+}
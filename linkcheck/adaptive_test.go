@@ -0,0 +1,72 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAIMD(t *testing.T) {
+	a := newAIMD(scope{base: "http://example.com/"}, 8)
+	fast := fetchResult{url: "http://example.com/a", status: http.StatusOK, timings: fetchTimings{ttfb: 10 * time.Millisecond}}
+	slow := fetchResult{url: "http://example.com/b", status: http.StatusOK, timings: fetchTimings{ttfb: 2 * adaptiveLatencyTarget}}
+	busy := fetchResult{url: "http://example.com/c", status: http.StatusTooManyRequests}
+
+	// Only fetched responses from the origin count
+	for _, fr := range []fetchResult{
+		{url: "http://other.example/", status: http.StatusOK},
+		{url: "http://example.com/cached", status: http.StatusNotModified},
+		{url: "http://example.com/cached-external"},
+	} {
+		if a.observe(fr) {
+			t.Fatalf("%s changed the limit", fr.url)
+		}
+	}
+
+	// 1 fast response to reach 2, 2 more to reach 3, 3 more to reach 4
+	for i := 0; i < 6; i++ {
+		a.observe(fast)
+	}
+	if a.limit != 4 {
+		t.Fatalf("limit after ramp up = %d; want 4", a.limit)
+	}
+	for i := 0; i < 10; i++ {
+		a.observe(fetchResult{url: "http://other.example/", status: http.StatusServiceUnavailable})
+	}
+	if a.limit != 4 {
+		t.Fatalf("a third-party host changed the limit to %d", a.limit)
+	}
+	for i := 0; i < 10; i++ {
+		a.observe(slow)
+	}
+	if a.limit != 4 {
+		t.Fatalf("slow responses changed limit to %d", a.limit)
+	}
+	// a burst of errors only halves once per round
+	for i := 0; i < 4; i++ {
+		a.observe(busy)
+	}
+	if a.limit != 2 {
+		t.Fatalf("limit after burst of 429s = %d; want 2", a.limit)
+	}
+	// timeouts count as congestion too
+	for i := 0; i < 4; i++ {
+		a.observe(fetchResult{url: "http://example.com/d", err: context.DeadlineExceeded})
+	}
+	if a.limit != 1 {
+		t.Fatalf("limit after timeouts = %d; want 1", a.limit)
+	}
+	for i := 0; i < 100; i++ {
+		a.observe(busy)
+	}
+	if a.limit != 1 {
+		t.Fatalf("limit under sustained errors = %d; want 1", a.limit)
+	}
+	for i := 0; i < 100; i++ {
+		a.observe(fast)
+	}
+	if a.limit != 8 {
+		t.Fatalf("limit did not recover to max: %d", a.limit)
+	}
+}
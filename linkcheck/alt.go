@@ -0,0 +1,32 @@
+package linkcheck
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// missingAlt returns a finding listing the images in doc without an alt
+// attribute. An empty alt marks an image as decorative, so only a missing
+// attribute is a problem, unless the image is hidden from screen readers.
+func missingAlt(doc *html.Node) []finding {
+	var selectors []string
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Img || hasAttr(n, "alt") {
+			return
+		}
+		switch strings.ToLower(strings.TrimSpace(attr(n, "role"))) {
+		case "presentation", "none":
+			return
+		}
+		if strings.EqualFold(attr(n, "aria-hidden"), "true") {
+			return
+		}
+		selectors = append(selectors, nodeSelector(n))
+	})
+	if len(selectors) == 0 {
+		return nil
+	}
+	return []finding{{categoryAccessibility, listSelectors("image has", "images have", "no alt attribute", selectors)}}
+}
@@ -0,0 +1,32 @@
+package linkcheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestMissingAlt(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body><main>
+<img src="/photo.jpg" alt="A photo">
+<img src="/spacer.gif" alt="">
+<img src="/chart.png">
+<img src="/divider.png" role="presentation">
+<img src="/flourish.png" aria-hidden="true">
+<picture><source srcset="/wide.webp"><img src="/wide.jpg"></picture>
+</main></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := missingAlt(doc)
+	want := []finding{{categoryAccessibility,
+		"2 images have no alt attribute: main > img:nth-child(3), main > picture > img"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if severities(nil).of(categoryAccessibility) != severityInfo {
+		t.Errorf("accessibility findings should be info by default")
+	}
+}
@@ -0,0 +1,103 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/url"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ampLinks pair a page with its AMP version.
+type ampLinks struct {
+	// isAMP is set for pages marked <html amp> or <html ⚡>
+	isAMP bool
+	// canonical is the <link rel=canonical> of the page
+	canonical string
+	// amphtml is the <link rel=amphtml> of the page
+	amphtml string
+}
+
+// pageAMPLinks returns the AMP pairing links in doc.
+func pageAMPLinks(pageurl *url.URL, doc *html.Node) ampLinks {
+	pageurl = documentBase(pageurl, doc)
+	var al ampLinks
+	visitAll(doc, func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			al.visit(pageurl, n)
+		}
+	})
+	return al
+}
+
+// visit records the AMP pairing link or marker in element n, if any.
+func (al *ampLinks) visit(pageurl *url.URL, n *html.Node) {
+	switch {
+	case n.DataAtom == atom.Html:
+		al.isAMP = al.isAMP || hasAttr(n, "amp") || hasAttr(n, "⚡")
+	case n.DataAtom == atom.Link && al.canonical == "" && hasRel(n, "canonical"):
+		al.canonical = resolveRef(pageurl, href(n))
+	case n.DataAtom == atom.Link && al.amphtml == "" && hasRel(n, "amphtml"):
+		al.amphtml = resolveRef(pageurl, href(n))
+	}
+}
+
+// ampPair returns the AMP links of a page with the same canonical URLs
+// as the links that are queued, so they can be matched against crawled pages.
+func (c *crawler) ampPair(al ampLinks) ampLinks {
+	for _, link := range []*string{&al.canonical, &al.amphtml} {
+		if *link == "" {
+			continue
+		}
+		if norm, err := Normalize(*link); err == nil {
+			*link = norm
+		}
+		*link = c.canonicalLink(*link)
+	}
+	return al
+}
+
+// markAMPMismatches adds findings to pages whose AMP version
+// doesn't name them as canonical, and to AMP pages whose canonical
+// page doesn't name them as its AMP version. Missing pages
+// are already reported as broken links, and pages that
+// weren't parsed, such as external or redirected pages, can't be checked.
+func (cp crawledPages) markAMPMismatches() {
+	parsed := func(link string) (pageInfo, bool) {
+		pi, ok := cp[link]
+		return pi, ok && pi.err == nil && !pi.unchecked && pi.redirect == "" && pi.contentHash != ""
+	}
+	for page, pi := range cp {
+		if amphtml := pi.amp.amphtml; amphtml != "" && amphtml != page {
+			if ai, ok := parsed(amphtml); ok {
+				switch {
+				case !ai.amp.isAMP:
+					pi.findings = append(pi.findings, finding{
+						categoryAMPMismatch,
+						fmt.Sprintf("amphtml link %s isn't an AMP page", amphtml),
+					})
+				case ai.amp.canonical == "":
+					pi.findings = append(pi.findings, finding{
+						categoryAMPMismatch,
+						fmt.Sprintf("AMP page %s has no canonical link", amphtml),
+					})
+				case ai.amp.canonical != page:
+					pi.findings = append(pi.findings, finding{
+						categoryAMPMismatch,
+						fmt.Sprintf("AMP page %s has canonical %s instead of this page", amphtml, ai.amp.canonical),
+					})
+				}
+			}
+		}
+		if canonical := pi.amp.canonical; pi.amp.isAMP && canonical != "" && canonical != page {
+			if ci, ok := parsed(canonical); ok && ci.amp.amphtml != page {
+				detail := fmt.Sprintf("canonical page %s has no amphtml link", canonical)
+				if ci.amp.amphtml != "" {
+					detail = fmt.Sprintf("canonical page %s has amphtml %s instead of this AMP page", canonical, ci.amp.amphtml)
+				}
+				pi.findings = append(pi.findings, finding{categoryAMPMismatch, detail})
+			}
+		}
+		cp[page] = pi
+	}
+}
@@ -0,0 +1,76 @@
+package linkcheck
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestPageAMPLinks(t *testing.T) {
+	pageurl, _ := url.Parse("https://example.com/story/")
+	for page, want := range map[string]ampLinks{
+		`<body>plain`: {},
+		`<head><link rel="canonical" href="/story"><link rel="amphtml" href="amp"><link rel="canonical" href="/other">`: {
+			canonical: "https://example.com/story",
+			amphtml:   "https://example.com/story/amp",
+		},
+		`<html amp><head><link rel="canonical" href="/story">`:                  {isAMP: true, canonical: "https://example.com/story"},
+		`<html ⚡><head><base href="/base/"><link rel="canonical" href="story">`: {isAMP: true, canonical: "https://example.com/base/story"},
+	} {
+		doc, err := html.Parse(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pageAMPLinks(pageurl, doc); got != want {
+			t.Errorf("%s: got %+v; want %+v", page, got, want)
+		}
+	}
+	c := crawler{base: "https://example.com/"}
+	got := c.ampPair(ampLinks{isAMP: true, canonical: "https://EXAMPLE.com:443/story#top"})
+	if want := (ampLinks{isAMP: true, canonical: "https://example.com/story"}); got != want {
+		t.Errorf("ampPair: got %+v; want %+v", got, want)
+	}
+}
+
+func TestAMPMismatches(t *testing.T) {
+	parsed := func(al ampLinks) pageInfo { return pageInfo{amp: al, contentHash: "x"} }
+	cp := crawledPages{
+		"/":          parsed(ampLinks{}),
+		"/story":     parsed(ampLinks{canonical: "/story", amphtml: "/story/amp"}),
+		"/story/amp": parsed(ampLinks{isAMP: true, canonical: "/story"}),
+		"/old":       parsed(ampLinks{amphtml: "/old/amp"}),
+		"/old/amp":   parsed(ampLinks{isAMP: true, canonical: "/other"}),
+		"/other":     parsed(ampLinks{}),
+		"/bare":      parsed(ampLinks{amphtml: "/bare/amp"}),
+		"/bare/amp":  parsed(ampLinks{isAMP: true}),
+		"/gone":      parsed(ampLinks{amphtml: "/gone/amp"}),
+		"/gone/amp":  {err: errTest},
+		"/moved":     parsed(ampLinks{amphtml: "/moved/amp"}),
+		"/moved/amp": {redirect: "/moved/amp/"},
+		"/notamp":    parsed(ampLinks{amphtml: "/plain"}),
+		"/plain":     parsed(ampLinks{}),
+		"/swapped":   parsed(ampLinks{isAMP: true, canonical: "/story"}),
+	}
+	cp.markAMPMismatches()
+	want := map[string][]string{
+		"/old":     {"AMP page /old/amp has canonical /other instead of this page"},
+		"/old/amp": {"canonical page /other has no amphtml link"},
+		"/bare":    {"AMP page /bare/amp has no canonical link"},
+		"/notamp":  {"amphtml link /plain isn't an AMP page"},
+		"/swapped": {"canonical page /story has amphtml /story/amp instead of this AMP page"},
+	}
+	for page, pi := range cp {
+		var got []string
+		for _, f := range pi.findings {
+			if f.category == categoryAMPMismatch {
+				got = append(got, f.detail)
+			}
+		}
+		if !slices.Equal(got, want[page]) {
+			t.Errorf("%s: got %q; want %q", page, got, want[page])
+		}
+	}
+}
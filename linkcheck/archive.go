@@ -2,8 +2,12 @@ package linkcheck
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/carlmjohnson/errutil"
@@ -11,11 +15,96 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// Archiver saves a copy of a page with an archiving service.
+type Archiver interface {
+	Archive(ctx context.Context, page string) error
+}
+
+// newArchiver returns an Archiver for a comma separated list of service names.
+// If more than one name is given, later services are used as fallbacks
+// when the earlier ones fail.
+func newArchiver(names string, cl *http.Client) (Archiver, error) {
+	var chain fallbackArchiver
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "wayback", "archive.org":
+			chain = append(chain, &waybackArchiver{
+				cl: cl,
+				// See https://archive.org/details/toomanyrequests_20191110
+				l: rate.NewLimiter(15.0/60, 15),
+			})
+		case "archive.today", "archive.ph":
+			chain = append(chain, &archiveTodayArchiver{
+				cl: cl,
+				// archive.today does not publish limits; be conservative
+				l: rate.NewLimiter(6.0/60, 3),
+			})
+		default:
+			return nil, fmt.Errorf("unknown archiver: %q", name)
+		}
+	}
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+	return chain, nil
+}
+
+type waybackArchiver struct {
+	cl *http.Client
+	l  *rate.Limiter
+}
+
+func (wa *waybackArchiver) Archive(ctx context.Context, page string) error {
+	if err := wa.l.Wait(ctx); err != nil {
+		return err
+	}
+	return requests.
+		URL("https://web.archive.org").
+		Pathf("/save/%s", page).
+		Head().
+		Client(wa.cl).
+		Fetch(ctx)
+}
+
+type archiveTodayArchiver struct {
+	cl *http.Client
+	l  *rate.Limiter
+}
+
+func (aa *archiveTodayArchiver) Archive(ctx context.Context, page string) error {
+	if err := aa.l.Wait(ctx); err != nil {
+		return err
+	}
+	return requests.
+		URL("https://archive.today/submit/").
+		BodyForm(url.Values{"url": {page}}).
+		Client(aa.cl).
+		Fetch(ctx)
+}
+
+// fallbackArchiver tries each Archiver in turn until one succeeds.
+type fallbackArchiver []Archiver
+
+func (fa fallbackArchiver) Archive(ctx context.Context, page string) error {
+	var errs errutil.Slice
+	for _, a := range fa {
+		err := a.Archive(ctx, page)
+		if err == nil {
+			return nil
+		}
+		errs.Push(err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errs.Merge()
+}
+
 func (c *crawler) archiveAll(pages crawledPages) error {
 	// queue good URLs
 	queue := make([]string, 0, len(pages))
 	for u, pi := range pages {
-		if pi.err == nil {
+		if pi.err == nil && !pi.unchecked {
 			queue = append(queue, u)
 		}
 	}
@@ -35,17 +124,10 @@ func (c *crawler) archiveAll(pages crawledPages) error {
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
 	defer stop()
 
-	// See https://archive.org/details/toomanyrequests_20191110
-	l := rate.NewLimiter(15.0/60, 15)
-
 	for i := 0; i < c.workers; i++ {
 		go func() {
 			for page := range pagesCh {
-				err := l.Wait(ctx)
-				if err == nil {
-					err = c.archive(ctx, page)
-				}
-				errCh <- err
+				errCh <- c.archiver.Archive(ctx, page)
 			}
 		}()
 	}
@@ -66,18 +148,9 @@ func (c *crawler) archiveAll(pages crawledPages) error {
 		case err := <-errCh:
 			inflightRequests--
 			errors.Push(err)
-			c.Printf("%d pages remaining to archive", len(queue)+inflightRequests)
+			c.Info("archiving links", "remaining", len(queue)+inflightRequests)
 		}
 	}
 
 	return errors.Merge()
 }
-
-func (c *crawler) archive(ctx context.Context, page string) error {
-	return requests.
-		URL("https://web.archive.org").
-		Pathf("/save/%s", page).
-		Head().
-		Client(c.Client).
-		Fetch(ctx)
-}
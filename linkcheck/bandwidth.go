@@ -0,0 +1,174 @@
+package linkcheck
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// usageTracker is a transport that counts requests and downloaded bytes
+// per host and, with -max-bandwidth, throttles downloads to an average rate.
+// Bytes are counted as they come off the wire: the tracker asks for gzip
+// and decompresses responses itself, the way http.Transport would,
+// so compressed bytes are counted before they're decoded.
+type usageTracker struct {
+	rt http.RoundTripper
+	// limit is the maximum average bytes per second, or 0 for no limit
+	limit int64
+	start time.Time
+
+	mu    sync.Mutex
+	bytes int64
+	hosts map[string]*hostUsage
+}
+
+// hostUsage is what was requested from a host.
+type hostUsage struct {
+	host     string
+	requests int
+	bytes    int64
+}
+
+func newUsageTracker(rt http.RoundTripper, limit int64) *usageTracker {
+	return &usageTracker{
+		rt:    rt,
+		limit: limit,
+		start: time.Now(),
+		hosts: make(map[string]*hostUsage),
+	}
+}
+
+func (ut *usageTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Host)
+	ut.mu.Lock()
+	hu := ut.hosts[host]
+	if hu == nil {
+		hu = &hostUsage{host: host}
+		ut.hosts[host] = hu
+	}
+	hu.requests++
+	ut.mu.Unlock()
+
+	// Same conditions as http.Transport's transparent gzip
+	gunzip := req.Header.Get("Accept-Encoding") == "" &&
+		req.Header.Get("Range") == "" && req.Method != http.MethodHead
+	if gunzip {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	res, err := ut.rt.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	res.Body = &countedBody{res.Body, ut, hu, req.Context()}
+	if gunzip && strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		res.Body = &gunzipBody{body: res.Body}
+		res.Header.Del("Content-Encoding")
+		res.Header.Del("Content-Length")
+		res.ContentLength = -1
+		res.Uncompressed = true
+	}
+	return res, nil
+}
+
+// add counts n bytes read from hu and, if that puts the run over its limit,
+// waits until the average rate is back under it.
+func (ut *usageTracker) add(ctx context.Context, hu *hostUsage, n int) {
+	ut.mu.Lock()
+	hu.bytes += int64(n)
+	ut.bytes += int64(n)
+	var wait time.Duration
+	if ut.limit > 0 {
+		due := time.Duration(float64(ut.bytes) / float64(ut.limit) * float64(time.Second))
+		wait = due - time.Since(ut.start)
+	}
+	ut.mu.Unlock()
+	if wait <= 0 {
+		return
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// totals returns the number of requests and bytes downloaded so far.
+// It is safe to call on a nil tracker.
+func (ut *usageTracker) totals() (requests int, bytes int64) {
+	if ut == nil {
+		return 0, 0
+	}
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+	for _, hu := range ut.hosts {
+		requests += hu.requests
+	}
+	return requests, ut.bytes
+}
+
+// byHost returns the usage of each host, most bytes first.
+func (ut *usageTracker) byHost() []hostUsage {
+	if ut == nil {
+		return nil
+	}
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+	usage := make([]hostUsage, 0, len(ut.hosts))
+	for _, hu := range ut.hosts {
+		usage = append(usage, *hu)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].bytes != usage[j].bytes {
+			return usage[i].bytes > usage[j].bytes
+		}
+		return usage[i].host < usage[j].host
+	})
+	return usage
+}
+
+// countedBody counts the bytes read from a response body.
+type countedBody struct {
+	io.ReadCloser
+	ut  *usageTracker
+	hu  *hostUsage
+	ctx context.Context
+}
+
+func (cb *countedBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	if n > 0 {
+		cb.ut.add(cb.ctx, cb.hu, n)
+	}
+	return n, err
+}
+
+// gunzipBody decompresses a gzipped response body,
+// reading the gzip header on the first Read.
+type gunzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (gb *gunzipBody) Read(p []byte) (int, error) {
+	if gb.err != nil {
+		return 0, gb.err
+	}
+	if gb.zr == nil {
+		if gb.zr, gb.err = gzip.NewReader(gb.body); gb.err != nil {
+			return 0, gb.err
+		}
+	}
+	return gb.zr.Read(p)
+}
+
+func (gb *gunzipBody) Close() error {
+	return gb.body.Close()
+}
@@ -0,0 +1,92 @@
+package linkcheck
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsageTracker(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", 1000))
+	}))
+	defer ts.Close()
+
+	ut := newUsageTracker(http.DefaultTransport, 0)
+	cl := &http.Client{Transport: ut}
+	for i := 0; i < 3; i++ {
+		res, err := cl.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+	if requests, bytes := ut.totals(); requests != 3 || bytes != 3000 {
+		t.Errorf("got %d requests, %d bytes; want 3, 3000", requests, bytes)
+	}
+	usage := ut.byHost()
+	if len(usage) != 1 || usage[0].host != strings.TrimPrefix(ts.URL, "http://") ||
+		usage[0].requests != 3 || usage[0].bytes != 3000 {
+		t.Errorf("got %+v", usage)
+	}
+
+	// 3000 bytes at 20,000 bytes per second should take about 150ms
+	ut = newUsageTracker(http.DefaultTransport, 20_000)
+	cl = &http.Client{Transport: ut}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		res, err := cl.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("downloads weren't throttled: took %v", elapsed)
+	}
+
+	var nilTracker *usageTracker
+	if requests, bytes := nilTracker.totals(); requests != 0 || bytes != 0 || nilTracker.byHost() != nil {
+		t.Errorf("nil tracker should report nothing")
+	}
+}
+
+func TestUsageTrackerCompressed(t *testing.T) {
+	page := strings.Repeat("x", 10_000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			io.WriteString(w, page)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, page)
+		zw.Close()
+	}))
+	defer ts.Close()
+
+	ut := newUsageTracker(http.DefaultTransport, 0)
+	cl := &http.Client{Transport: ut}
+	res, err := cl.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != page || !res.Uncompressed {
+		t.Errorf("got %d bytes, uncompressed=%v; want the decompressed page", len(body), res.Uncompressed)
+	}
+	// Only the compressed bytes came over the wire
+	if _, bytes := ut.totals(); bytes == 0 || bytes >= int64(len(page)) {
+		t.Errorf("counted %d bytes; want the compressed size", bytes)
+	}
+}
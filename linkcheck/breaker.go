@@ -0,0 +1,58 @@
+package linkcheck
+
+import (
+	"net/url"
+)
+
+// hostBreaker stops fetching from an external host
+// once it has timed out or errored too many times in a row,
+// rather than waiting out a timeout for every remaining URL.
+type hostBreaker struct {
+	scope scope
+	limit int
+	// failures counts consecutive failures by host.
+	// A host is tripped once it reaches limit.
+	failures map[string]int
+}
+
+func newHostBreaker(sc scope, limit int) *hostBreaker {
+	return &hostBreaker{
+		scope:    sc,
+		limit:    limit,
+		failures: make(map[string]int),
+	}
+}
+
+// allow reports whether link should still be fetched.
+func (hb *hostBreaker) allow(link string) bool {
+	if hb.scope.contains(link) {
+		return true
+	}
+	return hb.failures[hostname(link)] < hb.limit
+}
+
+// observe records a fetch result
+// and reports whether it tripped the breaker for its host.
+func (hb *hostBreaker) observe(fr fetchResult) bool {
+	if hb.scope.contains(fr.url) {
+		return false
+	}
+	host := hostname(fr.url)
+	if host == "" || hb.failures[host] >= hb.limit {
+		return false
+	}
+	if !fr.hostFailed {
+		delete(hb.failures, host)
+		return false
+	}
+	hb.failures[host]++
+	return hb.failures[host] == hb.limit
+}
+
+func hostname(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
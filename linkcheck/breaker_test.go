@@ -0,0 +1,65 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHostBreaker(t *testing.T) {
+	var hits int32
+	ext := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer ext.Close()
+	_, c, pages := crawlSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		for i := 0; i < 6; i++ {
+			fmt.Fprintf(w, `<a href="%s/%d">%d</a>`, ext.URL, i, i)
+		}
+	}), func(c *crawler) {
+		c.hostFailureLimit = 2
+	})
+	if hits != 2 {
+		t.Errorf("external host got %d requests; want 2", hits)
+	}
+	errs := pages.toURLErrors(c.scope(), true)
+	if len(errs) != 4 {
+		t.Fatalf("got %d errors; want 4 skipped URLs: %v", len(errs), errs)
+	}
+	for url, pe := range errs {
+		if pe.category() != categorySkippedHost || pe.level() != severityWarning {
+			t.Errorf("%q: got %v; want skipped host warning", url, pe.err)
+		}
+	}
+	s := newRunSummary(pages, results{errs: errs}, nil, false, 0)
+	if s.status != "ok" || s.skipped != 4 {
+		t.Errorf("summary = %v", s)
+	}
+}
+
+func TestHostBreakerResets(t *testing.T) {
+	hb := newHostBreaker(scope{base: "http://example.com/"}, 2)
+	fail := fetchResult{url: "http://other.example/a", hostFailed: true}
+	ok := fetchResult{url: "http://other.example/b"}
+	for _, fr := range []fetchResult{fail, ok, fail} {
+		if hb.observe(fr) {
+			t.Fatalf("tripped after %v", fr)
+		}
+	}
+	if !hb.allow("http://other.example/c") {
+		t.Fatal("host blocked before reaching the limit")
+	}
+	if !hb.observe(fail) {
+		t.Fatal("expected breaker to trip")
+	}
+	if hb.allow("http://other.example/c") {
+		t.Error("tripped host allowed")
+	}
+	if !hb.allow("http://example.com/page") {
+		t.Error("site under base should never be blocked")
+	}
+}
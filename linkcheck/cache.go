@@ -0,0 +1,211 @@
+package linkcheck
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheVersion is bumped whenever cacheEntry changes incompatibly.
+const cacheVersion = 2
+
+// errNotModified stops a conditional request whose cached copy is still good.
+var errNotModified = errors.New("not modified")
+
+// cacheValidators are the response headers used for conditional requests.
+type cacheValidators struct {
+	etag, lastModified string
+}
+
+// pageCache stores what was extracted from each page on disk,
+// so that scheduled runs can make conditional requests
+// and skip downloading and parsing pages that haven't changed.
+type pageCache struct {
+	dir string
+	// options fingerprints the crawler settings that change what is extracted;
+	// entries saved with other settings are ignored.
+	options string
+}
+
+type cacheEntry struct {
+	Version      int               `json:"version"`
+	Options      string            `json:"options"`
+	URL          string            `json:"url"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"`
+	SavedAt      time.Time         `json:"saved_at"`
+	Links        []cachedLink      `json:"links,omitempty"`
+	IDs          []string          `json:"ids,omitempty"`
+	Findings     []cachedFinding   `json:"findings,omitempty"`
+	Modified     time.Time         `json:"modified,omitempty"`
+	Lang         string            `json:"lang,omitempty"`
+	Locales      map[string]string `json:"locales,omitempty"`
+	Redirect     string            `json:"redirect,omitempty"`
+	ContentHash  string            `json:"content_hash,omitempty"`
+	Text         string            `json:"text,omitempty"`
+	PostActions  []string          `json:"post_actions,omitempty"`
+	Nofollow     []string          `json:"nofollow,omitempty"`
+	Robots       []string          `json:"robots,omitempty"`
+	Hreflang     map[string]string `json:"hreflang,omitempty"`
+	IsAMP        bool              `json:"is_amp,omitempty"`
+	Canonical    string            `json:"canonical,omitempty"`
+	AMPHTML      string            `json:"amphtml,omitempty"`
+	OtherSchemes []string          `json:"other_schemes,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Description  string            `json:"description,omitempty"`
+}
+
+type cachedLink struct {
+	URL      string `json:"url"`
+	Text     string `json:"text,omitempty"`
+	Selector string `json:"selector,omitempty"`
+}
+
+type cachedFinding struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func (c *crawler) cacheOptions() string {
+	return fmt.Sprintf("fragments=%t iframes=%t assets=%t json-ld=%t feeds=%t pdfs=%t forms=%t locales=%t hreflang=%t amp=%t text=%t lint=%t comments=%t dead-anchors=%t link-text=%t metadata=%t content-rules=%q security-headers=%t tel=%t dev-hosts=%q other-schemes=%t mailto=%t exclude=%q nofollow=%s max-links=%d stream=%d stale=%t",
+		!c.skipFragments, c.checkIframes, c.checkAssets, c.checkJSONLD, c.checkFeeds, c.checkPDFs, c.checkForms,
+		c.checkLocales, c.checkHreflang, c.checkAMP, c.checkTextFragments, c.htmlLint, c.commentedLinks, c.deadAnchors, c.checkLinkText, c.checkMetadata, c.contentRules, c.checkSecurityHeaders, c.checkTel, c.devHosts, c.listOtherSchemes, c.checkMailto, c.excludePaths, c.respectNofollow, c.maxLinksPerPage, c.streamThreshold, c.staleContentAge > 0)
+}
+
+// externalCacheOptions fingerprints the crawler settings
+// that change the results of checking external URLs.
+func (c *crawler) externalCacheOptions() string {
+	return fmt.Sprintf("fragments=%t strict=%t parked=%t", !c.skipFragments, c.strict, c.detectParked)
+}
+
+func (pc *pageCache) path(pageurl string) string {
+	sum := sha256.Sum256([]byte(pageurl))
+	return filepath.Join(pc.dir, fmt.Sprintf("%x.json", sum[:16]))
+}
+
+// load returns the cache entry for pageurl, or nil if there isn't a usable one.
+func (pc *pageCache) load(pageurl string) *cacheEntry {
+	if pc == nil {
+		return nil
+	}
+	b, err := os.ReadFile(pc.path(pageurl))
+	if err != nil {
+		return nil
+	}
+	var ce cacheEntry
+	if err = json.Unmarshal(b, &ce); err != nil ||
+		ce.Version != cacheVersion || ce.Options != pc.options || ce.URL != pageurl {
+		return nil
+	}
+	return &ce
+}
+
+// save stores what was extracted from fr, if it can be revalidated later.
+func (pc *pageCache) save(fr *fetchResult) error {
+	if pc == nil || fr.validators == (cacheValidators{}) {
+		return nil
+	}
+	ce := newCacheEntry(fr)
+	ce.Version = cacheVersion
+	ce.Options = pc.options
+	ce.SavedAt = time.Now()
+	b, err := json.Marshal(ce)
+	if err != nil {
+		return err
+	}
+	return pc.write(fr.url, b)
+}
+
+// newCacheEntry returns what was extracted from fr.
+func newCacheEntry(fr *fetchResult) cacheEntry {
+	ce := cacheEntry{
+		URL:          fr.url,
+		ETag:         fr.validators.etag,
+		LastModified: fr.validators.lastModified,
+		IDs:          fr.ids,
+		Modified:     fr.modified,
+		Lang:         fr.lang,
+		Locales:      fr.locales,
+		Redirect:     fr.redirect,
+		ContentHash:  fr.contentHash,
+		Text:         fr.text,
+		PostActions:  fr.postActions,
+		Robots:       fr.robots,
+		Hreflang:     fr.hreflang,
+		IsAMP:        fr.amp.isAMP,
+		Canonical:    fr.amp.canonical,
+		AMPHTML:      fr.amp.amphtml,
+		OtherSchemes: fr.otherSchemes,
+		Title:        fr.title,
+		Description:  fr.description,
+	}
+	for _, link := range fr.links {
+		lc := fr.contexts[link]
+		ce.Links = append(ce.Links, cachedLink{link, lc.text, lc.selector})
+		if fr.nofollow[link] {
+			ce.Nofollow = append(ce.Nofollow, link)
+		}
+	}
+	for _, f := range fr.findings {
+		ce.Findings = append(ce.Findings, cachedFinding{f.category, f.detail})
+	}
+	return ce
+}
+
+func (pc *pageCache) write(pageurl string, b []byte) error {
+	if err := os.MkdirAll(pc.dir, 0o755); err != nil {
+		return err
+	}
+	// Write then rename so a cancelled run can't leave a partial entry
+	name := pc.path(pageurl)
+	tmp, err := os.CreateTemp(pc.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if err1 := tmp.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// restore fills in fr from a cache entry after a 304 Not Modified
+// and returns the cached links, which still need filtering.
+func (ce *cacheEntry) restore(fr *fetchResult) (links []string) {
+	fr.ids = ce.IDs
+	fr.modified = ce.Modified
+	fr.lang = ce.Lang
+	fr.locales = ce.Locales
+	fr.redirect = ce.Redirect
+	fr.contentHash = ce.ContentHash
+	fr.text = ce.Text
+	fr.postActions = ce.PostActions
+	fr.robots = ce.Robots
+	fr.hreflang = ce.Hreflang
+	fr.amp = ampLinks{ce.IsAMP, ce.Canonical, ce.AMPHTML}
+	fr.otherSchemes = ce.OtherSchemes
+	fr.title, fr.description = ce.Title, ce.Description
+	if len(ce.Nofollow) > 0 {
+		fr.nofollow = make(map[string]bool, len(ce.Nofollow))
+		for _, link := range ce.Nofollow {
+			fr.nofollow[link] = true
+		}
+	}
+	fr.contexts = make(map[string]linkContext, len(ce.Links))
+	for _, cl := range ce.Links {
+		links = append(links, cl.URL)
+		fr.contexts[cl.URL] = linkContext{cl.Text, cl.Selector}
+	}
+	for _, cf := range ce.Findings {
+		fr.findings = append(fr.findings, finding{cf.Type, cf.Detail})
+	}
+	return links
+}
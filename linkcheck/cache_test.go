@@ -0,0 +1,153 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPageCache(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		statuses = make(map[int]int)
+	)
+	fs := http.FileServer(http.Dir("test-fixtures/sample-site"))
+	_, c, pages := crawlSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		fs.ServeHTTP(rec, r)
+		mu.Lock()
+		defer mu.Unlock()
+		statuses[rec.status]++
+	}), func(c *crawler) {
+		c.base += "id-bad-a.html"
+		c.cache = &pageCache{dir: t.TempDir(), options: c.cacheOptions()}
+	})
+	first := pages.toURLErrors(c.scope(), true)
+	if statuses[http.StatusNotModified] != 0 {
+		t.Fatalf("unexpected 304s on first run: %v", statuses)
+	}
+
+	pages, _ = c.crawl()
+	second := pages.toURLErrors(c.scope(), true)
+	if statuses[http.StatusNotModified] != 2 {
+		t.Errorf("expected both pages to be revalidated: %v", statuses)
+	}
+	if len(first) != 1 || !reflect.DeepEqual(first, second) {
+		t.Errorf("results differ with cache:\n%v\n%v", first, second)
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func TestExternalCache(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		hits = make(map[string]int)
+	)
+	fs := http.FileServer(http.Dir("test-fixtures/sample-site"))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		fs.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	c := testCrawler(ts.URL + "/")
+	c.externalCache = &externalCache{pageCache{dir: t.TempDir()}, time.Hour}
+	for _, page := range []string{"/id-bad-a.html", "/refresh-bad.html"} {
+		c.base = ts.URL + page
+		pages, _ := c.crawl()
+		first := pages.toURLErrors(c.scope(), true)
+		pages, _ = c.crawl()
+		second := pages.toURLErrors(c.scope(), true)
+		if len(first) != 1 || len(second) != 1 {
+			t.Fatalf("%s: expected one error per run; got %v and %v", page, first, second)
+		}
+		for url, pe := range first {
+			pe2 := second[url]
+			if pe2 == nil || pe.category() != pe2.category() ||
+				pe.err.Error() != pe2.err.Error() || errorClass(pe) != errorClass(pe2) {
+				t.Errorf("%s: cached result differs:\n%v\n%v", page, first, second)
+			}
+		}
+	}
+	for path, n := range hits {
+		if n != 1 && path != "/id-bad-a.html" && path != "/refresh-bad.html" {
+			t.Errorf("%s was requested %d times", path, n)
+		}
+	}
+}
+
+func TestExternalCacheSkips(t *testing.T) {
+	dir := t.TempDir()
+	withFragments := (&crawler{}).externalCacheOptions()
+	withoutFragments := (&crawler{skipFragments: true}).externalCacheOptions()
+	ec := &externalCache{pageCache{dir, withoutFragments}, time.Hour}
+	if err := ec.save(&fetchResult{url: "http://example.com/a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ec.load("http://example.com/a"); !ok {
+		t.Fatal("result wasn't cached")
+	}
+	// Results saved without IDs aren't used when checking fragments
+	ec2 := &externalCache{pageCache{dir, withFragments}, time.Hour}
+	if _, ok := ec2.load("http://example.com/a"); ok {
+		t.Error("used a result cached with other options")
+	}
+
+	notFound := decodeError("404 Not Found", http.StatusNotFound, "")
+	serverError := decodeError("502 Bad Gateway", http.StatusBadGateway, "")
+	for _, tc := range []struct {
+		fr     fetchResult
+		cached bool
+	}{
+		{fetchResult{status: http.StatusOK}, true},
+		{fetchResult{status: http.StatusNotFound, err: notFound}, true},
+		{fetchResult{err: &net.DNSError{Err: "no such host", IsNotFound: true}}, true},
+		{fetchResult{status: http.StatusBadGateway, err: serverError, hostFailed: true}, false},
+		// ignored without -strict
+		{fetchResult{status: http.StatusServiceUnavailable, hostFailed: true}, false},
+		{fetchResult{status: http.StatusTooManyRequests, retryAfter: time.Minute}, false},
+		{fetchResult{err: context.DeadlineExceeded, hostFailed: true}, false},
+		{fetchResult{err: fmt.Errorf("%w: %w", ErrFlakyDNS, errors.New("timeout"))}, false},
+		{fetchResult{err: ErrHostUnhealthy}, false},
+	} {
+		tc.fr.url = fmt.Sprintf("http://example.com/%d/%v", tc.fr.status, tc.fr.err)
+		if err := ec2.save(&tc.fr); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := ec2.load(tc.fr.url); ok != tc.cached {
+			t.Errorf("status=%d err=%v: cached=%t; want %t", tc.fr.status, tc.fr.err, ok, tc.cached)
+		}
+	}
+}
+
+func TestCacheOptions(t *testing.T) {
+	var c crawler
+	before := c.cacheOptions()
+	c.checkMailto = true
+	if c.cacheOptions() == before {
+		t.Error("-check-mailto doesn't change the cache options")
+	}
+	before = c.cacheOptions()
+	c.excludePaths = []string{"https://example.com/private/"}
+	if c.cacheOptions() == before {
+		t.Error("-exclude doesn't change the cache options")
+	}
+}
@@ -0,0 +1,157 @@
+package linkcheck
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// checkCLI runs the linkrot check subcommand, which checks the links and
+// fragments on a single page without recursing into the pages it links to,
+// for use as a pre-publish hook.
+func checkCLI(args []string) error {
+	fl := flag.NewFlagSet("linkrot check", flag.ContinueOnError)
+	fl.Usage = func() {
+		const usage = `Usage of linkrot check %s:
+
+linkrot check [options] <url>
+linkrot check [options] -from-file <file>
+
+    linkrot check fetches a single page and checks every link on it,
+    including links to #fragments, without crawling any further.
+    With -from-file, it checks the URLs listed in file, one per line,
+    instead. It exits 0 if all the links are good.
+
+Options:
+
+`
+		fmt.Fprintf(os.Stderr, usage, getVersion())
+		fl.PrintDefaults()
+	}
+	verbose := fl.Bool("verbose", false, "verbose")
+	crawlers := fl.Int("crawlers", runtime.NumCPU(), "number of concurrent requests")
+	timeout := fl.Duration("timeout", 10*time.Second, "timeout for requesting a URL")
+	maxRedirects := fl.Int("max-redirects", 10, "report URLs that redirect more than `N` times")
+	userAgent := fl.String("user-agent", chromeUserAgent, "`agent` to send in the User-Agent header")
+	strict := fl.Bool("strict", false, "report all errors, not just 404s and missing fragments, as broken")
+	checkFragments := fl.Bool("check-fragments", true, "check that links to #fragments match an id on the target page")
+	format := fl.String("format", formatText, "report `format`: text, json, or html")
+	output := fl.String("o", "", "write the report to `file` instead of stdout")
+	fromFile := fl.String("from-file", "", "check the URLs listed in `file`, or - for stdin, instead of the links on a page")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	if *fromFile != "" && fl.NArg() != 0 {
+		fl.Usage()
+		return fmt.Errorf("check takes a URL or -from-file, not both")
+	}
+	if *fromFile == "" && fl.NArg() != 1 {
+		fl.Usage()
+		return fmt.Errorf("check needs exactly one URL; got %d", fl.NArg())
+	}
+	if *format != formatText && *format != formatJSON && *format != formatHTML {
+		return fmt.Errorf("bad format: %q", *format)
+	}
+	var (
+		base    string
+		urlList []string
+	)
+	if *fromFile != "" {
+		var err error
+		if base, urlList, err = loadURLList(*fromFile); err != nil {
+			return err
+		}
+	} else {
+		page, err := url.Parse(fl.Arg(0))
+		if err != nil {
+			return fmt.Errorf("parsing URL: %w", err)
+		}
+		if page.Path == "" {
+			page.Path = "/"
+		}
+		if err = canonicalize(page); err != nil {
+			return fmt.Errorf("parsing URL: %w", err)
+		}
+		base = page.String()
+	}
+
+	jar, _ := cookiejar.New(nil)
+	c := &crawler{
+		base:    base,
+		workers: *crawlers,
+		Logger:  newLogger(os.Stderr, logFormatText, *verbose),
+		Client: &http.Client{
+			Jar:           jar,
+			Timeout:       *timeout,
+			CheckRedirect: checkRedirect(*maxRedirects),
+		},
+		userAgent:     *userAgent,
+		strict:        *strict,
+		skipFragments: !*checkFragments,
+		format:        *format,
+		output:        *output,
+		failOn:        failOnError,
+		singlePage:    true,
+		urlList:       urlList,
+	}
+	return c.run()
+}
+
+// loadURLList reads the URLs to check from name, or stdin if name is "-".
+// It returns the name to report the list under.
+func loadURLList(name string) (base string, urls []string, err error) {
+	r := io.Reader(os.Stdin)
+	base = "stdin"
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+		r, base = f, name
+	}
+	urls, err = readURLList(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading %s: %w", base, err)
+	}
+	if len(urls) == 0 {
+		return "", nil, fmt.Errorf("reading %s: no URLs", base)
+	}
+	return base, urls, nil
+}
+
+// readURLList reads absolute http and https URLs, one per line.
+// Blank lines and lines starting with # are skipped.
+func readURLList(r io.Reader) ([]string, error) {
+	var urls []string
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		u, err := url.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("line %d: not an http or https URL: %q", n, line)
+		}
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		if err = canonicalize(u); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		urls = append(urls, u.String())
+	}
+	return urls, s.Err()
+}
@@ -0,0 +1,110 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSinglePage(t *testing.T) {
+	site, c, pages := crawlSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/post/":
+			io.WriteString(w, `<a href="/post/more">more</a><a href="/about#team">team</a><a href="/gone">gone</a>`)
+		case "/post/more":
+			io.WriteString(w, `<a href="/post/deeper">deeper</a>`)
+		case "/about":
+			io.WriteString(w, `<h2 id="staff">Staff</h2>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}), func(c *crawler) {
+		c.base += "post/"
+		c.singlePage = true
+	})
+	if _, ok := pages[site+"/post/deeper"]; ok {
+		t.Error("followed a link on a linked page")
+	}
+	if len(pages) != 4 {
+		t.Errorf("got %d pages; want 4", len(pages))
+	}
+	errs := pages.toURLErrors(c.scope(), true)
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors; want 2: %v", len(errs), errs)
+	}
+	if pe := errs[site+"/about"]; pe == nil || !pe.missingFragments["team"] {
+		t.Errorf("missing fragment not reported: %v", errs)
+	}
+	if errs[site+"/gone"] == nil {
+		t.Errorf("broken link not reported: %v", errs)
+	}
+}
+
+func TestURLList(t *testing.T) {
+	var fetched []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = append(fetched, r.URL.Path)
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/ok":
+			io.WriteString(w, `<a href="/elsewhere">elsewhere</a>`)
+		case "/about":
+			io.WriteString(w, `<h2 id="staff">Staff</h2>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	list, err := readURLList(strings.NewReader(
+		"# links to verify\n" + ts.URL + "/ok\n\n" + ts.URL + "/about#team\n" + ts.URL + "/gone\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCrawler("urls.txt")
+	c.urlList = list
+	pages, _ := c.crawl()
+	if len(fetched) != 3 {
+		t.Errorf("fetched %v; want only the listed URLs", fetched)
+	}
+	errs := pages.toURLErrors(c.scope(), true)
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors; want 2: %v", len(errs), errs)
+	}
+	if pe := errs[ts.URL+"/about"]; pe == nil || !pe.missingFragments["team"] {
+		t.Errorf("missing fragment not reported: %v", errs)
+	}
+	if pe := errs[ts.URL+"/gone"]; pe == nil || len(pe.refs) != 1 || pe.refs[0] != "urls.txt" {
+		t.Errorf("broken link not reported from the list: %v", errs)
+	}
+}
+
+func TestReadURLList(t *testing.T) {
+	got, err := readURLList(strings.NewReader("  HTTPS://Example.com  \n# comment\nhttp://example.com:80/a#b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://example.com/", "http://example.com/a#b"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if _, err = readURLList(strings.NewReader("https://example.com/\n/relative\n")); err == nil ||
+		!strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got error %v; want one for line 2", err)
+	}
+}
+
+func TestURLListScope(t *testing.T) {
+	// A list named like a URL prefix doesn't make its URLs internal
+	c := crawler{base: "http", urlList: []string{"http://example.com/"}}
+	sc := c.scope()
+	if sc.contains("http://example.com/") {
+		t.Error("listed URL treated as internal")
+	}
+	if link, ok := sc.canonicalHost("http://www.example.com/"); ok {
+		t.Errorf("listed URL rewritten to %q", link)
+	}
+}
@@ -0,0 +1,42 @@
+package linkcheck
+
+import (
+	"net/url"
+	"regexp"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	commentAttrRe = regexp.MustCompile(`(?i)\b(?:href|src)\s*=\s*["']([^"']+)["']`)
+	commentURLRe  = regexp.MustCompile(`https?://[^\s"'<>()]+`)
+)
+
+// linksInComments returns the URLs found inside HTML comments in doc,
+// such as commented-out embeds left behind by template edits.
+// They are reported as findings rather than checked.
+func linksInComments(pageurl *url.URL, doc *html.Node) []finding {
+	pageurl = documentBase(pageurl, doc)
+	var findings []finding
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		link := resolveRef(pageurl, ref)
+		if link == "" || seen[link] {
+			return
+		}
+		seen[link] = true
+		findings = append(findings, finding{categoryCommentedLink, "link in HTML comment: " + link})
+	}
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.CommentNode {
+			return
+		}
+		for _, m := range commentAttrRe.FindAllStringSubmatch(n.Data, -1) {
+			add(m[1])
+		}
+		for _, ref := range commentURLRe.FindAllString(n.Data, -1) {
+			add(ref)
+		}
+	})
+	return findings
+}
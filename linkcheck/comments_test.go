@@ -0,0 +1,38 @@
+package linkcheck
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestLinksInComments(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<!-- <iframe src="/embeds/old-map.html"></iframe> -->
+<a href="/live.html">live</a>
+<!-- TODO: restore link to https://example.com/story (see <a href='/archive/'>archive</a>) -->
+<!-- <a href="/embeds/old-map.html">dupe</a> -->
+</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/page/")
+	var got []string
+	for _, f := range linksInComments(base, doc) {
+		if f.category != categoryCommentedLink {
+			t.Errorf("unexpected category %q", f.category)
+		}
+		got = append(got, f.detail)
+	}
+	want := []string{
+		"link in HTML comment: https://example.com/embeds/old-map.html",
+		"link in HTML comment: https://example.com/archive/",
+		"link in HTML comment: https://example.com/story",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
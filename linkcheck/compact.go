@@ -0,0 +1,69 @@
+package linkcheck
+
+import (
+	"hash/maphash"
+	"slices"
+)
+
+// stringPool interns the link URLs and selectors kept for crawled pages.
+// Sites repeat the same links and selectors in their navigation
+// on every page, and without a pool each page would keep its own copies
+// until the end of the crawl. IDs and anchor text are mostly unique
+// to their page, so pooling them would only add the pool's overhead.
+type stringPool map[string]string
+
+// intern returns the pooled copy of s, adding s if there isn't one.
+// A nil pool returns s unchanged.
+func (sp stringPool) intern(s string) string {
+	if sp == nil || s == "" {
+		return s
+	}
+	if pooled, ok := sp[s]; ok {
+		return pooled
+	}
+	sp[s] = s
+	return s
+}
+
+// idSeed and normIDSeed hash the IDs in an idSet
+// and the normalized forms of IDs that change when normalized.
+var idSeed, normIDSeed = maphash.MakeSeed(), maphash.MakeSeed()
+
+// idSet is the sorted hashes of a page's IDs. Pages can have thousands
+// of IDs but only get a few lookups, and the report only names the
+// fragments that links ask for, so 8 byte hashes are kept instead of
+// the IDs themselves. A collision makes a missing fragment look found,
+// at odds of about one in 2^64 divided by the page's IDs.
+type idSet []uint64
+
+func newIDSet(ids []string) idSet {
+	if len(ids) == 0 {
+		return nil
+	}
+	set := make(idSet, 0, len(ids))
+	for _, id := range ids {
+		set = append(set, maphash.String(idSeed, id))
+		if norm := normalizeFragment(id); norm != id {
+			set = append(set, maphash.String(normIDSeed, norm))
+		}
+	}
+	slices.Sort(set)
+	return slices.Clip(slices.Compact(set))
+}
+
+// has reports whether id is in the set.
+func (set idSet) has(id string) bool {
+	_, ok := slices.BinarySearch(set, maphash.String(idSeed, id))
+	return ok
+}
+
+// hasNormalized reports whether frag matches one of the IDs in the set
+// after both are normalized. IDs that normalizing doesn't change
+// are only hashed as themselves.
+func (set idSet) hasNormalized(frag string) bool {
+	norm := normalizeFragment(frag)
+	if _, ok := slices.BinarySearch(set, maphash.String(normIDSeed, norm)); ok {
+		return true
+	}
+	return normalizeFragment(norm) == norm && set.has(norm)
+}
@@ -0,0 +1,119 @@
+package linkcheck
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestIDSet(t *testing.T) {
+	set := newIDSet([]string{"main", "top", "Main", "main", "", "caf%C3%A9"})
+	for _, id := range []string{"main", "top", "Main", ""} {
+		if !set.has(id) {
+			t.Errorf("missing %q", id)
+		}
+	}
+	if set.has("nav") || set.has("café") || len(set) != 6 {
+		t.Errorf("got %v", set)
+	}
+	for frag, want := range map[string]bool{"café": true, "caf%c3%a9": true, "main": true, "%6Dain": true, "nav": false} {
+		if got := set.hasNormalized(frag); got != want {
+			t.Errorf("hasNormalized(%q) = %v", frag, got)
+		}
+	}
+	if newIDSet(nil) != nil {
+		t.Errorf("empty set should be nil")
+	}
+}
+
+func TestStringPool(t *testing.T) {
+	sp := make(stringPool)
+	cp := newCrawledPages()
+	for _, page := range []string{"/a", "/b"} {
+		// Each page's copies are their own strings
+		link := string([]byte("https://example.com/about"))
+		selector := string([]byte("nav > a"))
+		cp.add(fetchResult{
+			url:      page,
+			links:    []string{link},
+			contexts: map[string]linkContext{link: {"About", selector}},
+		}, sp)
+	}
+	var data []*byte
+	for link, lc := range cp["/a"].links {
+		data = append(data, unsafe.StringData(link), unsafe.StringData(lc.selector))
+	}
+	for link, lc := range cp["/b"].links {
+		data = append(data, unsafe.StringData(link), unsafe.StringData(lc.selector))
+	}
+	if len(data) != 4 || data[0] != data[2] || data[1] != data[3] {
+		t.Errorf("strings weren't shared between pages")
+	}
+}
+
+// BenchmarkCrawledPagesAdd reports the heap kept per crawled page
+// for pages with site-wide navigation and many unique IDs.
+func BenchmarkCrawledPagesAdd(b *testing.B) {
+	const pages = 1000
+	results := make([]fetchResult, pages)
+	for i := range results {
+		fr := fetchResult{
+			url:      fmt.Sprintf("https://example.com/post-%d", i),
+			contexts: make(map[string]linkContext),
+		}
+		for j := 0; j < 100; j++ {
+			link := fmt.Sprintf("https://example.com/section-%d", j)
+			fr.links = append(fr.links, link)
+			fr.contexts[link] = linkContext{fmt.Sprintf("Section %d", j), "nav > ul > li > a"}
+		}
+		for j := 0; j < 20; j++ {
+			link := fmt.Sprintf("https://example.com/post-%d#para-%d", (i+j)%pages, j)
+			fr.links = append(fr.links, link)
+			fr.contexts[link] = linkContext{fmt.Sprintf("Related %d", j), "article > p > a"}
+		}
+		for j := 0; j < 200; j++ {
+			fr.ids = append(fr.ids, fmt.Sprintf("post-%d-paragraph-%d", i, j))
+		}
+		results[i] = fr
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	var kept uint64
+	for n := 0; n < b.N; n++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		// Each page gets its own copies, like pages parsed separately
+		cloned := make([]fetchResult, pages)
+		for i, fr := range results {
+			fr.links = slices.Clone(fr.links)
+			for j := range fr.links {
+				fr.links[j] = strings.Clone(fr.links[j])
+			}
+			contexts := make(map[string]linkContext, len(fr.contexts))
+			for link, lc := range fr.contexts {
+				contexts[strings.Clone(link)] = linkContext{strings.Clone(lc.text), strings.Clone(lc.selector)}
+			}
+			fr.contexts = contexts
+			fr.ids = slices.Clone(fr.ids)
+			for j := range fr.ids {
+				fr.ids[j] = strings.Clone(fr.ids[j])
+			}
+			cloned[i] = fr
+		}
+		cp := newCrawledPages()
+		sp := make(stringPool)
+		for _, fr := range cloned {
+			cp.add(fr, sp)
+		}
+		cloned = nil
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		kept += after.HeapAlloc - before.HeapAlloc
+		runtime.KeepAlive(cp)
+	}
+	b.ReportMetric(float64(kept)/float64(b.N*pages), "B/page")
+}
@@ -0,0 +1,117 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// consentRule is a strategy for getting past a GDPR cookie wall on a host.
+type consentRule struct {
+	host string
+	// kind is either "cookie" or "param"
+	kind        string
+	name, value string
+}
+
+// parseConsentRule parses rules like
+// "example.com=cookie:euconsent=1" or "example.com=param:gdpr=0".
+func parseConsentRule(s string) (consentRule, error) {
+	host, strategy, ok := strings.Cut(s, "=")
+	if !ok || host == "" {
+		return consentRule{}, fmt.Errorf("bad consent rule %q: want host=strategy", s)
+	}
+	kind, pair, ok := strings.Cut(strategy, ":")
+	if !ok || (kind != "cookie" && kind != "param") {
+		return consentRule{}, fmt.Errorf("bad consent rule %q: strategy must be cookie:name=value or param:name=value", s)
+	}
+	name, value, ok := strings.Cut(pair, "=")
+	if !ok || name == "" {
+		return consentRule{}, fmt.Errorf("bad consent rule %q: want name=value", s)
+	}
+	return consentRule{strings.ToLower(host), kind, name, value}, nil
+}
+
+func (cr consentRule) matches(host string) bool {
+	host = strings.ToLower(host)
+	return host == cr.host || strings.HasSuffix(host, "."+cr.host)
+}
+
+// setConsentCookies preloads the client's cookie jar with consent cookies.
+func (c *crawler) setConsentCookies() {
+	if c.Client.Jar == nil {
+		return
+	}
+	for _, cr := range c.consent {
+		if cr.kind != "cookie" {
+			continue
+		}
+		cookie := &http.Cookie{
+			Name:   cr.name,
+			Value:  cr.value,
+			Path:   "/",
+			Domain: cr.host,
+		}
+		for _, scheme := range []string{"http", "https"} {
+			u := &url.URL{Scheme: scheme, Host: cr.host, Path: "/"}
+			c.Client.Jar.SetCookies(u, []*http.Cookie{cookie})
+		}
+	}
+}
+
+// requestURL returns the URL to request for pageurl,
+// with any consent parameters for its host
+// and the query token for pages under the base URL added.
+// Reports continue to use pageurl.
+func (c *crawler) requestURL(pageurl string) string {
+	if len(c.consent) == 0 && c.token.name == "" {
+		return pageurl
+	}
+	u, err := url.Parse(pageurl)
+	if err != nil {
+		return pageurl
+	}
+	changed := false
+	q := u.Query()
+	for _, cr := range c.consent {
+		if cr.kind == "param" && cr.matches(u.Hostname()) {
+			q.Set(cr.name, cr.value)
+			changed = true
+		}
+	}
+	if c.token.name != "" && c.shouldGetLinks(pageurl) {
+		q.Set(c.token.name, c.token.value)
+		changed = true
+	}
+	if !changed {
+		return pageurl
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// stripRequestParams undoes requestURL for u.
+func (c *crawler) stripRequestParams(u *url.URL) string {
+	if len(c.consent) == 0 && c.token.name == "" {
+		return u.String()
+	}
+	u2 := *u
+	q := u2.Query()
+	changed := false
+	for _, cr := range c.consent {
+		if cr.kind == "param" && cr.matches(u2.Hostname()) && q.Has(cr.name) {
+			q.Del(cr.name)
+			changed = true
+		}
+	}
+	if c.token.name != "" && q.Has(c.token.name) && q.Get(c.token.name) == c.token.value {
+		q.Del(c.token.name)
+		changed = true
+	}
+	if !changed {
+		return u.String()
+	}
+	u2.RawQuery = q.Encode()
+	return u2.String()
+}
@@ -0,0 +1,104 @@
+package linkcheck
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+)
+
+func consentRules(t *testing.T, ss ...string) []consentRule {
+	t.Helper()
+	var rules []consentRule
+	for _, s := range ss {
+		cr, err := parseConsentRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, cr)
+	}
+	return rules
+}
+
+func TestParseConsentRule(t *testing.T) {
+	cases := map[string]consentRule{
+		"example.com=cookie:euconsent=1":  {"example.com", "cookie", "euconsent", "1"},
+		"News.Example.com=param:gdpr=0":   {"news.example.com", "param", "gdpr", "0"},
+		"example.com=cookie:accepted=":    {"example.com", "cookie", "accepted", ""},
+		"example.com=param:consent=a=b&c": {"example.com", "param", "consent", "a=b&c"},
+	}
+	for s, want := range cases {
+		got, err := parseConsentRule(s)
+		if err != nil || got != want {
+			t.Errorf("parseConsentRule(%q) = %+v, %v; want %+v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{
+		"example.com",
+		"=cookie:a=1",
+		"example.com=header:a=1",
+		"example.com=cookie:a",
+		"example.com=param:=1",
+	} {
+		if _, err := parseConsentRule(s); err == nil {
+			t.Errorf("parseConsentRule(%q): want error", s)
+		}
+	}
+}
+
+func TestConsentParams(t *testing.T) {
+	c := crawler{consent: consentRules(t,
+		"example.com=param:gdpr=0",
+		"cookies.example.net=cookie:euconsent=1",
+	)}
+	cases := map[string]string{
+		"https://example.com/":             "https://example.com/?gdpr=0",
+		"https://www.example.com/a?b=1":    "https://www.example.com/a?b=1&gdpr=0",
+		"https://notexample.com/":          "https://notexample.com/",
+		"https://cookies.example.net/page": "https://cookies.example.net/page",
+	}
+	for pageurl, want := range cases {
+		got := c.requestURL(pageurl)
+		if got != want {
+			t.Errorf("requestURL(%q) = %q; want %q", pageurl, got, want)
+		}
+		u, err := url.Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back := c.stripRequestParams(u); back != pageurl {
+			t.Errorf("stripRequestParams(%q) = %q; want %q", got, back, pageurl)
+		}
+	}
+}
+
+func TestConsentCookies(t *testing.T) {
+	jar, _ := cookiejar.New(nil)
+	c := crawler{
+		Client: &http.Client{Jar: jar},
+		consent: consentRules(t,
+			"example.com=cookie:euconsent=1",
+			"example.net=param:gdpr=0",
+		),
+	}
+	c.setConsentCookies()
+	cases := map[string]int{
+		"https://example.com/":         1,
+		"http://www.example.com/a/b":   1,
+		"https://notexample.com/":      0,
+		"https://example.net/":         0,
+		"https://other.example.org/":   0,
+		"https://deep.www.example.com": 1,
+	}
+	for rawURL, want := range cases {
+		u, _ := url.Parse(rawURL)
+		cookies := jar.Cookies(u)
+		if len(cookies) != want {
+			t.Errorf("cookies for %s = %v; want %d", rawURL, cookies, want)
+			continue
+		}
+		if want > 0 && (cookies[0].Name != "euconsent" || cookies[0].Value != "1") {
+			t.Errorf("cookies for %s = %v", rawURL, cookies)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package linkcheck
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// contentRule is text that internal pages matching a path pattern must contain,
+// such as a payment form's script on a donation page.
+type contentRule struct {
+	pattern string
+	// text is the required text, unless re is set
+	text string
+	re   *regexp.Regexp
+}
+
+// parseContentRule parses rules like "/donate=Stripe"
+// or "/donate/*=/Stripe|PayPal/", where text between slashes is a regexp.
+func parseContentRule(s string) (contentRule, error) {
+	pattern, text, ok := strings.Cut(s, "=")
+	if !ok || pattern == "" || text == "" {
+		return contentRule{}, fmt.Errorf("bad content rule %q: want pattern=text or pattern=/regexp/", s)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return contentRule{}, fmt.Errorf("bad content rule %q: %w", s, err)
+	}
+	cr := contentRule{pattern: pattern, text: text}
+	if expr, ok := strings.CutPrefix(text, "/"); ok && len(expr) > 1 && strings.HasSuffix(expr, "/") {
+		re, err := regexp.Compile(strings.TrimSuffix(expr, "/"))
+		if err != nil {
+			return contentRule{}, fmt.Errorf("bad content rule %q: %w", s, err)
+		}
+		cr.re = re
+	}
+	return cr, nil
+}
+
+func (cr contentRule) String() string {
+	return cr.pattern + "=" + cr.text
+}
+
+// matches reports whether the rule applies to the page at pageurl.
+// A pattern like /donate matches /donate/ too.
+func (cr contentRule) matches(pageurl string) bool {
+	u, err := url.Parse(pageurl)
+	if err != nil {
+		return false
+	}
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	if ok, _ := path.Match(cr.pattern, p); ok {
+		return true
+	}
+	ok, _ := path.Match(cr.pattern, strings.TrimSuffix(p, "/"))
+	return ok && p != "/"
+}
+
+// contentFindings returns findings for the -require-content rules
+// whose text is missing from the page's HTML source.
+func (c *crawler) contentFindings(pageurl string, body []byte) []finding {
+	var findings []finding
+	for _, cr := range c.contentRules {
+		if !cr.matches(pageurl) {
+			continue
+		}
+		if cr.re != nil {
+			if !cr.re.Match(body) {
+				findings = append(findings, finding{
+					categoryMissingContent,
+					fmt.Sprintf("page doesn't match %s, required for %s", cr.text, cr.pattern),
+				})
+			}
+		} else if !bytes.Contains(body, []byte(cr.text)) {
+			findings = append(findings, finding{
+				categoryMissingContent,
+				fmt.Sprintf("page doesn't contain %q, required for %s", cr.text, cr.pattern),
+			})
+		}
+	}
+	return findings
+}
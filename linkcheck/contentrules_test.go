@@ -0,0 +1,75 @@
+package linkcheck
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseContentRule(t *testing.T) {
+	for _, s := range []string{"", "/donate", "=Stripe", "/donate=", "[=x", "/donate=/(/"} {
+		if _, err := parseContentRule(s); err == nil {
+			t.Errorf("parseContentRule(%q): want error", s)
+		}
+	}
+	cr, err := parseContentRule("/donate/*=/Stripe|PayPal/")
+	if err != nil || cr.re == nil || cr.re.String() != "Stripe|PayPal" {
+		t.Errorf("got %#v, %v", cr, err)
+	}
+	cr, err = parseContentRule("/about=a=b")
+	if err != nil || cr.re != nil || cr.text != "a=b" {
+		t.Errorf("got %#v, %v", cr, err)
+	}
+	for _, tc := range []struct {
+		pattern, url string
+		want         bool
+	}{
+		{"/donate", "https://example.com/donate", true},
+		{"/donate", "https://example.com/donate/", true},
+		{"/donate", "https://example.com/donate/monthly", false},
+		{"/donate/*", "https://example.com/donate/monthly", true},
+		{"/", "https://example.com", true},
+		{"/donate/*", "https://example.com/donate", false},
+	} {
+		cr := contentRule{pattern: tc.pattern, text: "x"}
+		if got := cr.matches(tc.url); got != tc.want {
+			t.Errorf("%q matches %q = %t; want %t", tc.pattern, tc.url, got, tc.want)
+		}
+	}
+}
+
+func TestContentFindings(t *testing.T) {
+	var rules []contentRule
+	for _, s := range []string{"/donate=js.stripe.com", "/donate/*=/(?i)stripe/", "/donate/*=<form"} {
+		cr, err := parseContentRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, cr)
+	}
+	c := crawler{contentRules: rules}
+	for _, tc := range []struct {
+		url, body string
+		want      []string
+	}{
+		{"https://example.com/donate/", `<form><script src="https://js.stripe.com/v3/"></script>`, nil},
+		{"https://example.com/donate", `<p>Donate`, []string{
+			`page doesn't contain "js.stripe.com", required for /donate`,
+		}},
+		{"https://example.com/donate/monthly", `Our payment form is down`, []string{
+			"page doesn't match /(?i)stripe/, required for /donate/*",
+			`page doesn't contain "<form", required for /donate/*`,
+		}},
+		{"https://example.com/about", `About`, nil},
+	} {
+		var got []string
+		for _, f := range c.contentFindings(tc.url, []byte(tc.body)) {
+			if f.category != categoryMissingContent {
+				t.Errorf("%s: got category %q", tc.url, f.category)
+			}
+			got = append(got, f.detail)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got findings %q; want %q", tc.url, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package linkcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// parseCookie parses a Set-Cookie style string,
+// such as "preview=1; domain=example.com; path=/".
+func parseCookie(s string) (*http.Cookie, error) {
+	// Let net/http do the parsing
+	res := http.Response{Header: http.Header{"Set-Cookie": {s}}}
+	cookies := res.Cookies()
+	if len(cookies) != 1 {
+		return nil, fmt.Errorf("bad cookie %q: want name=value; attributes...", s)
+	}
+	cookie := cookies[0]
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	return cookie, nil
+}
+
+// setCookies preloads the client's cookie jar with cookies from -cookie.
+// Cookies without a domain are set for the base URL's host.
+func (c *crawler) setCookies(cookies []*http.Cookie) error {
+	if c.Client.Jar == nil || len(cookies) == 0 {
+		return nil
+	}
+	base, err := url.Parse(c.base)
+	if err != nil {
+		return err
+	}
+	for _, cookie := range cookies {
+		host := base.Hostname()
+		if cookie.Domain != "" {
+			host = cookie.Domain
+		}
+		for _, scheme := range []string{"http", "https"} {
+			u := &url.URL{Scheme: scheme, Host: host, Path: "/"}
+			c.Client.Jar.SetCookies(u, []*http.Cookie{cookie})
+		}
+	}
+	return nil
+}
+
+// persistentJar is a cookie jar that remembers every cookie it is given,
+// so they can be saved to a file and loaded again by the next run.
+// The standard library jar does the actual work.
+type persistentJar struct {
+	http.CookieJar
+	path string
+	mu   sync.Mutex
+	// saved are the cookies set, keyed by URL, name, domain, and path
+	saved map[savedCookieKey]savedCookie
+}
+
+type savedCookieKey struct {
+	URL, Name, Domain, Path string
+}
+
+type savedCookie struct {
+	URL      string    `json:"url"`
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires"`
+	Secure   bool      `json:"secure,omitempty"`
+	HTTPOnly bool      `json:"http_only,omitempty"`
+}
+
+// loadPersistentJar wraps jar and loads the cookies saved at path, if any.
+func loadPersistentJar(jar http.CookieJar, path string) (*persistentJar, error) {
+	pj := &persistentJar{
+		CookieJar: jar,
+		path:      path,
+		saved:     make(map[savedCookieKey]savedCookie),
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pj, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved []savedCookie
+	if err = json.Unmarshal(b, &saved); err != nil {
+		return nil, fmt.Errorf("reading cookie jar %s: %w", path, err)
+	}
+	for _, sc := range saved {
+		u, err := url.Parse(sc.URL)
+		if err != nil {
+			continue
+		}
+		pj.SetCookies(u, []*http.Cookie{{
+			Name:     sc.Name,
+			Value:    sc.Value,
+			Domain:   sc.Domain,
+			Path:     sc.Path,
+			Expires:  sc.Expires,
+			Secure:   sc.Secure,
+			HttpOnly: sc.HTTPOnly,
+		}})
+	}
+	return pj, nil
+}
+
+func (pj *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	pj.CookieJar.SetCookies(u, cookies)
+	pj.mu.Lock()
+	defer pj.mu.Unlock()
+	origin := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}).String()
+	for _, cookie := range cookies {
+		key := savedCookieKey{origin, cookie.Name, cookie.Domain, cookie.Path}
+		// Expired cookies are deleted by the jar, so forget them too
+		if cookie.MaxAge < 0 ||
+			(!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now())) {
+			delete(pj.saved, key)
+			continue
+		}
+		expires := cookie.Expires
+		if cookie.MaxAge > 0 {
+			expires = time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+		}
+		pj.saved[key] = savedCookie{
+			URL:      origin,
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  expires,
+			Secure:   cookie.Secure,
+			HTTPOnly: cookie.HttpOnly,
+		}
+	}
+}
+
+// save writes the cookies to the jar's file.
+func (pj *persistentJar) save() error {
+	pj.mu.Lock()
+	saved := make([]savedCookie, 0, len(pj.saved))
+	for _, sc := range pj.saved {
+		if sc.Expires.IsZero() || sc.Expires.After(time.Now()) {
+			saved = append(saved, sc)
+		}
+	}
+	pj.mu.Unlock()
+	sort.Slice(saved, func(i, j int) bool {
+		a, b := saved[i], saved[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Name < b.Name
+	})
+	b, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write then rename so a cancelled run can't leave a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(pj.path), "cookies-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if err1 := tmp.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), pj.path)
+}
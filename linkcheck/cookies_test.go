@@ -0,0 +1,78 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCookie(t *testing.T) {
+	cookie, err := parseCookie("preview=1; Domain=example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie.Name != "preview" || cookie.Value != "1" ||
+		cookie.Domain != "example.com" || cookie.Path != "/" {
+		t.Errorf("got %#v", cookie)
+	}
+	if _, err = parseCookie("no equals sign"); err == nil {
+		t.Error("expected error for bad cookie")
+	}
+}
+
+func TestCookies(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("preview"); err != nil || c.Value != "1" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600})
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="/b">b</a>`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	newCrawler := func() *crawler {
+		inner, _ := cookiejar.New(nil)
+		jar, err := loadPersistentJar(inner, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := testCrawler(ts.URL + "/")
+		c.Client = &http.Client{Jar: jar}
+		c.strict = true
+		c.cookieJar = jar
+		return c
+	}
+
+	c := newCrawler()
+	cookie, _ := parseCookie("preview=1")
+	if err := c.setCookies([]*http.Cookie{cookie}); err != nil {
+		t.Fatal(err)
+	}
+	pages, _ := c.crawl()
+	if errs := pages.toURLErrors(c.scope(), true); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if err := c.cookieJar.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next run gets both cookies back without -cookie
+	c = newCrawler()
+	u, _ := url.Parse(ts.URL)
+	got := make(map[string]string)
+	for _, cookie := range c.Client.Jar.Cookies(u) {
+		got[cookie.Name] = cookie.Value
+	}
+	if got["preview"] != "1" || got["session"] != "abc" {
+		t.Errorf("cookies not persisted: %v", got)
+	}
+}
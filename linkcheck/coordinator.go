@@ -0,0 +1,384 @@
+package linkcheck
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// leasePoll is how long a worker's request for a URL waits
+// before the coordinator tells it to ask again.
+const leasePoll = 20 * time.Second
+
+// coordinatorOnly are the options workers don't get from the coordinator.
+var coordinatorOnly = map[string]bool{
+	"coordinate":       true,
+	"coordinator-cert": true,
+	"coordinator-key":  true,
+	"lease-timeout":    true,
+	"findings-addr":    true,
+	"pprof-addr":       true,
+}
+
+// privateOptions may hold credentials, so they aren't sent to workers,
+// which set their own.
+var privateOptions = map[string]bool{
+	"coordinator-secret": true,
+	"findings-secret":    true,
+	"cookie":             true,
+	"token":              true,
+	"proxy":              true,
+	"internal-proxy":     true,
+	"sentry-dsn":         true,
+	"smtp-url":           true,
+	"webhook-url":        true,
+}
+
+// coordinator hands out URLs from the crawl's fetch pool to linkrot worker
+// processes over HTTP, so a big crawl can be spread across machines.
+// The coordinator keeps the queue and every result;
+// workers only fetch and parse pages, alongside its own crawlers.
+type coordinator struct {
+	*crawler
+	// args are the options workers set up their crawlers with
+	args []string
+	// leaseTimeout is how long a worker has to report a result
+	// before the coordinator fetches the URL itself
+	leaseTimeout time.Duration
+	// ready is closed once the crawl starts
+	ready chan struct{}
+	ctx   context.Context
+	pool  *fetchPool
+
+	mu     sync.Mutex
+	n      int
+	leases map[string]lease
+}
+
+type lease struct {
+	url    string
+	result chan fetchResult
+}
+
+// workerConfig is what a worker needs to set up its crawler.
+type workerConfig struct {
+	Args []string `json:"args"`
+}
+
+// workerLease is a URL for a worker to fetch.
+type workerLease struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Post is set for form actions to check with HEAD
+	Post bool `json:"post,omitempty"`
+}
+
+// serveCoordinator serves URLs to workers on addr until stop is called.
+// Requests must carry secret as a bearer token,
+// so unless addr is a loopback address, tlsConfig must be set
+// to serve HTTPS instead of HTTP.
+// args are the command line options to pass on to workers.
+// It returns the address it listens on.
+func (c *crawler) serveCoordinator(addr, secret string, tlsConfig *tls.Config, args []string, leaseTimeout time.Duration) (listening string, stop func(), err error) {
+	if tlsConfig == nil && !isLoopback(addr) {
+		return "", nil, fmt.Errorf("serving workers on %s needs -coordinator-cert and -coordinator-key", addr)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, err
+	}
+	if tlsConfig != nil {
+		l = tls.NewListener(l, tlsConfig)
+	}
+	co := &coordinator{
+		crawler:      c,
+		args:         args,
+		leaseTimeout: leaseTimeout,
+		ready:        make(chan struct{}),
+		leases:       make(map[string]lease),
+	}
+	c.coordinator = co
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", co.handleConfig)
+	mux.HandleFunc("/lease", co.handleLease)
+	mux.HandleFunc("/result", co.handleResult)
+	srv := &http.Server{Handler: requireBearer(secret, mux)}
+	go srv.Serve(l)
+	return l.Addr().String(), func() { srv.Close() }, nil
+}
+
+// start hands out URLs from fp until the crawl is cancelled or fp is closed.
+func (co *coordinator) start(fp *fetchPool) {
+	if co == nil {
+		return
+	}
+	co.ctx, co.pool = fp.ctx, fp
+	close(co.ready)
+}
+
+// isLoopback reports whether addr only listens on the local machine,
+// such as localhost:7071 or 127.0.0.1:0. An empty host listens everywhere.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requireBearer responds 401 Unauthorized to requests
+// without secret as their bearer token, unless secret is empty.
+func requireBearer(secret string, h http.Handler) http.Handler {
+	if secret == "" {
+		return h
+	}
+	want := []byte("Bearer " + secret)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing or wrong secret", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (co *coordinator) handleConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, workerConfig{co.args})
+}
+
+// handleLease waits up to leasePoll for a URL to fetch.
+// It responds 204 No Content if there wasn't one
+// and 410 Gone once the crawl is over.
+func (co *coordinator) handleLease(w http.ResponseWriter, r *http.Request) {
+	timer := time.NewTimer(leasePoll)
+	defer timer.Stop()
+	select {
+	case <-co.ready:
+	case <-timer.C:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case <-r.Context().Done():
+		return
+	}
+	select {
+	case url, ok := <-co.pool.jobs:
+		if !ok {
+			http.Error(w, "crawl is over", http.StatusGone)
+			return
+		}
+		writeJSON(w, co.grant(url))
+	case <-timer.C:
+		w.WriteHeader(http.StatusNoContent)
+	case <-co.ctx.Done():
+		http.Error(w, "crawl is over", http.StatusGone)
+	case <-r.Context().Done():
+	}
+}
+
+// grant leases url to a worker. Until the lease ends,
+// it counts as one of the pool's fetches.
+func (co *coordinator) grant(url string) workerLease {
+	co.mu.Lock()
+	co.n++
+	id := strconv.Itoa(co.n)
+	result := make(chan fetchResult, 1)
+	co.leases[id] = lease{url, result}
+	co.mu.Unlock()
+	co.Debug("leased URL to worker", "url", url, "lease", id)
+
+	co.pool.do(func() { co.await(id, url, result) })
+	return workerLease{id, url, co.postEndpoints.has(url)}
+}
+
+// await passes the result of lease id on to the crawl loop,
+// fetching the URL locally if the worker doesn't report back in time.
+func (co *coordinator) await(id, url string, result chan fetchResult) {
+	timer := time.NewTimer(co.leaseTimeout)
+	defer timer.Stop()
+	var fr fetchResult
+	select {
+	case fr = <-result:
+	case <-timer.C:
+		if _, ok := co.end(id, url); ok {
+			co.Warn("worker lease expired; fetching locally", "url", url, "lease", id)
+			fr = co.fetch(co.ctx, url)
+		} else {
+			// The result arrived just in time
+			fr = <-result
+		}
+	case <-co.ctx.Done():
+		co.end(id, url)
+		return
+	}
+	select {
+	case co.pool.results <- fr:
+	case <-co.ctx.Done():
+	}
+}
+
+// end removes lease id, if it is still open for url.
+func (co *coordinator) end(id, url string) (lease, bool) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	l, ok := co.leases[id]
+	if !ok || l.url != url {
+		return lease{}, false
+	}
+	delete(co.leases, id)
+	return l, true
+}
+
+// handleResult takes the result of a lease from a worker.
+// It responds 409 Conflict if the lease already expired.
+func (co *coordinator) handleResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var wr workerResult
+	if err := json.NewDecoder(r.Body).Decode(&wr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := r.URL.Query().Get("lease")
+	l, ok := co.end(id, wr.URL)
+	if !ok {
+		co.Info("dropping result for expired lease", "url", wr.URL, "lease", id)
+		http.Error(w, "lease expired", http.StatusConflict)
+		return
+	}
+	fr := wr.fetchResult()
+	co.postEndpoints.addResult(&fr)
+	l.result <- fr
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// workerResult is a fetchResult sent from a worker to the coordinator.
+// Extracted page data uses the cache's format.
+type workerResult struct {
+	cacheEntry
+	Status          int              `json:"status,omitempty"`
+	RetryAfter      time.Duration    `json:"retry_after,omitempty"`
+	HostFailed      bool             `json:"host_failed,omitempty"`
+	Unchecked       bool             `json:"unchecked,omitempty"`
+	Suggestion      string           `json:"suggestion,omitempty"`
+	ShortenerStatus int              `json:"shortener_status,omitempty"`
+	ShortTarget     string           `json:"short_target,omitempty"`
+	UserAgent       string           `json:"user_agent,omitempty"`
+	Timings         [6]time.Duration `json:"timings"`
+	Error           string           `json:"error,omitempty"`
+	ErrorStatus     int              `json:"error_status,omitempty"`
+	ErrorKind       string           `json:"error_kind,omitempty"`
+}
+
+func newWorkerResult(fr *fetchResult) workerResult {
+	ft := fr.timings
+	wr := workerResult{
+		cacheEntry:      newCacheEntry(fr),
+		Status:          fr.status,
+		RetryAfter:      fr.retryAfter,
+		HostFailed:      fr.hostFailed,
+		Unchecked:       fr.unchecked,
+		Suggestion:      fr.suggestion,
+		ShortenerStatus: fr.shortenerStatus,
+		ShortTarget:     fr.shortTarget,
+		UserAgent:       fr.userAgent,
+		Timings:         [6]time.Duration{ft.dns, ft.connect, ft.tls, ft.ttfb, ft.body, ft.total},
+	}
+	if fr.err != nil {
+		wr.Error = fr.err.Error()
+		wr.ErrorStatus, wr.ErrorKind = encodeError(fr.err)
+	}
+	return wr
+}
+
+func (wr *workerResult) fetchResult() fetchResult {
+	fr := fetchResult{
+		url:             wr.URL,
+		validators:      cacheValidators{wr.ETag, wr.LastModified},
+		status:          wr.Status,
+		retryAfter:      wr.RetryAfter,
+		hostFailed:      wr.HostFailed,
+		unchecked:       wr.Unchecked,
+		suggestion:      wr.Suggestion,
+		shortenerStatus: wr.ShortenerStatus,
+		shortTarget:     wr.ShortTarget,
+		userAgent:       wr.UserAgent,
+	}
+	t := wr.Timings
+	fr.timings = fetchTimings{t[0], t[1], t[2], t[3], t[4], t[5]}
+	fr.links = wr.restore(&fr)
+	if wr.Error != "" {
+		fr.err = decodeError(wr.Error, wr.ErrorStatus, wr.ErrorKind)
+	}
+	return fr
+}
+
+// workerArgs returns the options parsed from args and from LINKROT_
+// environment variables, looked up with lookupEnv, as arguments
+// for workers, without the options that only apply to the coordinator
+// or may hold credentials.
+func workerArgs(fl *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) []string {
+	var out, rest []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			rest = args[i:]
+			break
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		seen[name] = true
+		skip := coordinatorOnly[name] || privateOptions[name]
+		if !skip {
+			out = append(out, arg)
+		}
+		// Non-boolean options may take their value from the next argument
+		if f := fl.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			if !skip {
+				out = append(out, args[i])
+			}
+		}
+	}
+	// Like flagext.ParseEnv, which workers also run with their own environment
+	fl.VisitAll(func(f *flag.Flag) {
+		if seen[f.Name] || coordinatorOnly[f.Name] || privateOptions[f.Name] {
+			return
+		}
+		if val, ok := lookupEnv(envName(f.Name)); ok {
+			out = append(out, "-"+f.Name+"="+val)
+		}
+	})
+	return append(out, rest...)
+}
+
+// envName returns the environment variable for the option name.
+func envName(name string) string {
+	return "LINKROT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
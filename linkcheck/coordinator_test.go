@@ -0,0 +1,266 @@
+package linkcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+const testSecret = "s3cret"
+
+func coordinatedSite() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			io.WriteString(w, `<body><a href="/a#top">a</a><a href="/a#gone">gone</a><a href="/missing">missing</a>`)
+		case "/a":
+			io.WriteString(w, `<body><h1 id="top">A</h1><a href="/">home</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestCoordinatedCrawl(t *testing.T) {
+	ts := coordinatedSite()
+	defer ts.Close()
+
+	newCrawler := func(workers int) *crawler {
+		c := testCrawler(ts.URL + "/")
+		c.workers = workers
+		c.strict = true
+		return c
+	}
+	// Without local crawlers, the worker fetches everything
+	c := newCrawler(0)
+	addr, stop, err := c.serveCoordinator("127.0.0.1:0", testSecret, nil, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	w := newCrawler(1)
+	workErr := make(chan error, 1)
+	go func() { workErr <- w.work(context.Background(), http.DefaultClient, "http://"+addr+"/", testSecret) }()
+
+	pages, cancelled := c.crawlContext(context.Background())
+	if cancelled {
+		t.Fatal("crawl was cancelled")
+	}
+	if err = <-workErr; err != nil {
+		t.Fatalf("worker: %v", err)
+	}
+	if len(pages) != 3 {
+		t.Fatalf("got %d pages: %v", len(pages), pages)
+	}
+	if !pages[ts.URL+"/a"].ids.has("top") {
+		t.Errorf("IDs were not reported: %v", pages[ts.URL+"/a"])
+	}
+	if !requests.HasStatusErr(pages[ts.URL+"/missing"].err, http.StatusNotFound) {
+		t.Errorf("404 was not reported: %v", pages[ts.URL+"/missing"].err)
+	}
+	errs := pages.toURLErrors(c.scope(), true)
+	if pe := errs[ts.URL+"/a"]; pe == nil || !pe.missingFragments["gone"] {
+		t.Errorf("missing fragment was not reported: %v", errs)
+	}
+}
+
+func TestCoordinatorExpiredLease(t *testing.T) {
+	ts := coordinatedSite()
+	defer ts.Close()
+
+	c := testCrawler(ts.URL + "/")
+	c.workers = 0
+	c.strict = true
+	addr, stop, err := c.serveCoordinator("127.0.0.1:0", testSecret, nil, nil, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	// A worker that never reports back
+	leased := make(chan string, 10)
+	go func() {
+		for {
+			var wl workerLease
+			err := requests.
+				URL("http://" + addr + "/lease").
+				Bearer(testSecret).
+				ToJSON(&wl).
+				Fetch(context.Background())
+			if err != nil {
+				close(leased)
+				return
+			}
+			leased <- wl.URL
+		}
+	}()
+
+	pages, _ := c.crawlContext(context.Background())
+	if len(pages) != 3 {
+		t.Fatalf("expired leases weren't fetched locally: %v", pages)
+	}
+	n := 0
+	for range leased {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("leased %d URLs; want 3", n)
+	}
+	// Late results are refused
+	err = requests.
+		URL("http://"+addr+"/result").
+		Param("lease", "1").
+		Bearer(testSecret).
+		BodyJSON(newWorkerResult(&fetchResult{url: ts.URL + "/"})).
+		Fetch(context.Background())
+	if !requests.HasStatusErr(err, http.StatusConflict) {
+		t.Errorf("late result: %v", err)
+	}
+}
+
+func TestCoordinatorSecret(t *testing.T) {
+	c := &crawler{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	addr, stop, err := c.serveCoordinator("127.0.0.1:0", testSecret, nil, []string{"-verbose"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	for _, path := range []string{"/config", "/lease", "/result"} {
+		for _, token := range []string{"", "wrong"} {
+			err := requests.
+				URL("http://"+addr+path).
+				Header("Authorization", "Bearer "+token).
+				Fetch(context.Background())
+			if !requests.HasStatusErr(err, http.StatusUnauthorized) {
+				t.Errorf("%s with %q: %v", path, token, err)
+			}
+		}
+	}
+	var cfg workerConfig
+	err = requests.
+		URL("http://" + addr + "/config").
+		Bearer(testSecret).
+		ToJSON(&cfg).
+		Fetch(context.Background())
+	if err != nil || !slices.Equal(cfg.Args, []string{"-verbose"}) {
+		t.Errorf("got config %v: %v", cfg, err)
+	}
+}
+
+func TestCoordinatorTLS(t *testing.T) {
+	ts := coordinatedSite()
+	defer ts.Close()
+
+	c := testCrawler(ts.URL + "/")
+	if _, _, err := c.serveCoordinator(":0", testSecret, nil, nil, time.Minute); err == nil {
+		t.Fatal("served workers plain HTTP on every interface")
+	}
+	certFile, keyFile := writeTestCert(t)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, stop, err := c.serveCoordinator("127.0.0.1:0", testSecret,
+		&tls.Config{Certificates: []tls.Certificate{cert}}, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	if _, err = coordinatorClient("http://coordinator.example:7070/", ""); err == nil {
+		t.Error("allowed plain HTTP to a remote coordinator")
+	}
+	cl, err := coordinatorClient("https://"+addr+"/", certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg workerConfig
+	if err = requests.
+		URL("https://" + addr + "/").
+		Client(cl).
+		Path("config").
+		Bearer(testSecret).
+		ToJSON(&cfg).
+		Fetch(context.Background()); err != nil {
+		t.Errorf("getting config over TLS: %v", err)
+	}
+}
+
+func TestWorkerResultRoundTrip(t *testing.T) {
+	fr := fetchResult{
+		url:        "http://example.com/a",
+		links:      []string{"http://example.com/b"},
+		contexts:   map[string]linkContext{"http://example.com/b": {"B", "p > a"}},
+		ids:        []string{"top"},
+		findings:   []finding{{categoryAccessibility, "1 image"}},
+		status:     http.StatusTooManyRequests,
+		retryAfter: time.Second,
+		timings:    fetchTimings{total: time.Millisecond},
+		err:        ErrParkedDomain,
+	}
+	wr := newWorkerResult(&fr)
+	got := wr.fetchResult()
+	if got.url != fr.url || !slices.Equal(got.links, fr.links) || got.contexts["http://example.com/b"] != fr.contexts["http://example.com/b"] ||
+		!slices.Equal(got.ids, fr.ids) || !slices.Equal(got.findings, fr.findings) ||
+		got.status != fr.status || got.retryAfter != fr.retryAfter || got.timings != fr.timings {
+		t.Errorf("got %+v; want %+v", got, fr)
+	}
+	if pe := (&pageError{err: got.err}); pe.category() != categoryParkedDomain || got.err.Error() != fr.err.Error() {
+		t.Errorf("error not restored: %v", got.err)
+	}
+}
+
+func TestWorkerArgs(t *testing.T) {
+	fl := flag.NewFlagSet("test", flag.ContinueOnError)
+	fl.Bool("verbose", false, "")
+	fl.String("coordinate", "", "")
+	fl.Duration("lease-timeout", 0, "")
+	fl.Int("crawlers", 0, "")
+	fl.String("token", "", "")
+	fl.String("coordinator-secret", "", "")
+	fl.String("user-agent", "", "")
+	env := map[string]string{
+		"LINKROT_USER_AGENT":         "curl/8.0",
+		"LINKROT_TOKEN":              "preview=secret",
+		"LINKROT_COORDINATOR_SECRET": "secret",
+		"LINKROT_LEASE_TIMEOUT":      "1m",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+	for _, tc := range []struct {
+		args, want []string
+	}{
+		{nil, []string{"-user-agent=curl/8.0"}},
+		{[]string{"https://example.com"}, []string{"-user-agent=curl/8.0", "https://example.com"}},
+		{
+			[]string{"-coordinate", ":8080", "-verbose", "-crawlers", "4", "https://example.com"},
+			[]string{"-verbose", "-crawlers", "4", "-user-agent=curl/8.0", "https://example.com"},
+		},
+		{
+			[]string{"--coordinate=:8080", "-lease-timeout", "1m", "-crawlers=2", "https://example.com", "-coordinate"},
+			[]string{"-crawlers=2", "-user-agent=curl/8.0", "https://example.com", "-coordinate"},
+		},
+		{
+			[]string{"-token", "preview=secret", "-coordinator-secret=secret", "-user-agent", "bot", "--", "-x"},
+			[]string{"-user-agent", "bot", "--", "-x"},
+		},
+	} {
+		if got := workerArgs(fl, tc.args, lookupEnv); !slices.Equal(got, tc.want) {
+			t.Errorf("workerArgs(%q) = %q; want %q", tc.args, got, tc.want)
+		}
+	}
+}
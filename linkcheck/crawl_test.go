@@ -0,0 +1,49 @@
+package linkcheck
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// htmlSite serves pages, a map of paths to HTML. Other paths are 404s.
+func htmlSite(pages map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, page)
+	})
+}
+
+// testCrawler returns a crawler for base with one worker
+// that discards its logs.
+func testCrawler(base string) *crawler {
+	return &crawler{
+		base:      base,
+		workers:   1,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Client:    http.DefaultClient,
+		userAgent: chromeUserAgent,
+	}
+}
+
+// crawlSite serves h and crawls it from its root with a testCrawler
+// that configure, if it isn't nil, has changed.
+// It returns the server's URL, the crawler, and what was crawled.
+func crawlSite(t *testing.T, h http.Handler, configure func(c *crawler)) (site string, c *crawler, pages crawledPages) {
+	t.Helper()
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	c = testCrawler(ts.URL + "/")
+	if configure != nil {
+		configure(c)
+	}
+	pages, _ = c.crawl()
+	return ts.URL, c, pages
+}
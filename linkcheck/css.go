@@ -0,0 +1,116 @@
+package linkcheck
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// linksFromStyle returns the stylesheets linked by n
+// and the url() references in its inline CSS.
+func linksFromStyle(pageurl *url.URL, n *html.Node) (links []string) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	if style := attr(n, "style"); style != "" {
+		links = append(links, linksFromCSS(pageurl, style)...)
+	}
+	switch n.DataAtom {
+	case atom.Link:
+		if ref := href(n); ref != "" && hasRel(n, "stylesheet") {
+			if link := resolveRef(pageurl, ref); link != "" {
+				links = append(links, link)
+			}
+		}
+	case atom.Style:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				links = append(links, linksFromCSS(pageurl, c.Data)...)
+			}
+		}
+	}
+	return links
+}
+
+// linksFromCSS resolves the references in css against baseurl,
+// skipping data URIs and fragment-only references to SVG filters and such.
+func linksFromCSS(baseurl *url.URL, css string) (links []string) {
+	for _, ref := range cssURLs(css) {
+		if strings.HasPrefix(ref, "#") || strings.HasPrefix(strings.ToLower(ref), "data:") {
+			continue
+		}
+		if link := resolveRef(baseurl, ref); link != "" {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// cssURLs returns the url() and @import references in a stylesheet,
+// such as fonts and background images.
+// It is not a full CSS tokenizer, but it does skip comments and strings.
+func cssURLs(css string) (refs []string) {
+	const whitespace = " \t\n\r\f"
+	s := css
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, "/*"):
+			end := strings.Index(s[2:], "*/")
+			if end == -1 {
+				return refs
+			}
+			s = s[end+4:]
+		case s[0] == '"' || s[0] == '\'':
+			_, s = cssString(s)
+		case hasPrefixFold(s, "url("):
+			rest := strings.TrimLeft(s[4:], whitespace)
+			var ref string
+			if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+				ref, rest = cssString(rest)
+			} else {
+				end := strings.IndexByte(rest, ')')
+				if end == -1 {
+					end = len(rest)
+				}
+				ref, rest = rest[:end], rest[end:]
+			}
+			if ref = strings.Trim(ref, whitespace); ref != "" {
+				refs = append(refs, ref)
+			}
+			s = rest
+		case hasPrefixFold(s, "@import"):
+			rest := strings.TrimLeft(s[7:], whitespace)
+			if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+				var ref string
+				ref, rest = cssString(rest)
+				if ref != "" {
+					refs = append(refs, ref)
+				}
+			}
+			s = rest
+		default:
+			s = s[1:]
+		}
+	}
+	return refs
+}
+
+// cssString splits a quoted string off the front of s.
+func cssString(s string) (str, rest string) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return s[1:i], s[i+1:]
+		}
+	}
+	return s[1:], ""
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
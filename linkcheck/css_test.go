@@ -0,0 +1,62 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCSSURLs(t *testing.T) {
+	var testcases = []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"body { color: red }", nil},
+		{"a { background: url(bg.png) }", []string{"bg.png"}},
+		{`@font-face { src: url("a.woff2") format("woff2"), URL( 'b.woff' ) }`, []string{"a.woff2", "b.woff"}},
+		{`@import "reset.css"; @import url(print.css) print;`, []string{"reset.css", "print.css"}},
+		{"/* url(commented.png) */ a { content: 'url(quoted.png)' }", nil},
+		{`a { background: url("it\"s.png") }`, []string{`it\"s.png`}},
+		{"a { background: url(unterminated.png", []string{"unterminated.png"}},
+	}
+	for _, test := range testcases {
+		if got := cssURLs(test.in); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("cssURLs(%q) = %q; want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestFetchCSS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		io.WriteString(w, `@import "print.css"; .hero { background: url('/img/hero.jpg') } .x { background: url(data:image/png;base64,AAAA) }`)
+	}))
+	defer ts.Close()
+
+	c := testCrawler(ts.URL + "/")
+	c.checkAssets = true
+	fr := c.fetch(context.Background(), ts.URL+"/css/site.css")
+	want := []string{ts.URL + "/css/print.css", ts.URL + "/img/hero.jpg"}
+	if fr.err != nil || !slices.Equal(fr.links, want) {
+		t.Errorf("got links %q and error %v; want %q", fr.links, fr.err, want)
+	}
+
+	// Stylesheets off the site aren't read for links
+	c.base = "https://example.com/"
+	fr = c.fetch(context.Background(), ts.URL+"/css/site.css")
+	if fr.err != nil || len(fr.links) != 0 {
+		t.Errorf("external stylesheet got links %q and error %v", fr.links, fr.err)
+	}
+
+	// Without -check-assets, CSS isn't an accepted content type
+	c.base, c.checkAssets = ts.URL+"/", false
+	fr = c.fetch(context.Background(), ts.URL+"/css/site.css")
+	if len(fr.links) != 0 {
+		t.Errorf("got links %q without -check-assets", fr.links)
+	}
+}
@@ -1,61 +1,271 @@
 package linkcheck
 
 import (
+	"container/heap"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
+// queue is the frontier of URLs to crawl in memory. Internal pages are
+// crawled before external links are checked, and shallower pages before
+// deeper ones, so a crawl that stops early has covered the pages closest
+// to the base URL. The low priority lane is only drained once everything
+// else has been fetched.
 type queue struct {
-	q []string
-	m map[string]bool
+	h queueHeap
+	m map[string]queuedURL
+	// internal reports whether a link is crawled rather than just checked;
+	// if it's nil, every link is internal
+	internal func(link string) bool
+	// n is how many URLs are waiting
+	n   int
+	seq int
 }
 
-func newQueue(url string) *queue {
-	return &queue{
-		q: []string{url},
-		m: map[string]bool{url: true},
+type queueState int8
+
+const (
+	queuedNormal queueState = iota + 1
+	queuedLow
+	popped
+)
+
+type queuedURL struct {
+	state queueState
+	depth int
+}
+
+// queueTier orders the lanes of a queue.
+type queueTier int8
+
+const (
+	tierInternal queueTier = iota
+	tierExternal
+	tierLow
+)
+
+type queueEntry struct {
+	link  string
+	tier  queueTier
+	depth int
+	// seq keeps entries of the same tier and depth in FIFO order
+	seq int
+}
+
+type queueHeap []queueEntry
+
+func (h queueHeap) Len() int { return len(h) }
+
+func (h queueHeap) Less(i, j int) bool {
+	if h[i].tier != h[j].tier {
+		return h[i].tier < h[j].tier
+	}
+	if h[i].depth != h[j].depth {
+		return h[i].depth < h[j].depth
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h queueHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *queueHeap) Push(x any) { *h = append(*h, x.(queueEntry)) }
+
+func (h *queueHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+func newQueue(url string, internal func(link string) bool) *queue {
+	q := &queue{
+		m:        make(map[string]queuedURL),
+		internal: internal,
+	}
+	q.push(url, tierInternal, 0)
+	q.m[url] = queuedURL{queuedNormal, 0}
+	q.n++
+	return q
+}
+
+func (q *queue) push(link string, tier queueTier, depth int) {
+	q.seq++
+	heap.Push(&q.h, queueEntry{link, tier, depth, q.seq})
+}
+
+// skipPromoted drops entries that were moved to another lane
+// or to a shallower depth.
+func (q *queue) skipPromoted() {
+	for len(q.h) > 0 {
+		e := q.h[0]
+		qu := q.m[e.link]
+		if e.depth == qu.depth && (qu.state == queuedLow && e.tier == tierLow ||
+			qu.state == queuedNormal && e.tier != tierLow) {
+			return
+		}
+		heap.Pop(&q.h)
 	}
 }
 
 func (q *queue) empty() bool {
-	return len(q.q) == 0
+	q.skipPromoted()
+	return len(q.h) == 0
 }
 
 func (q *queue) head() string {
 	if q.empty() {
 		return ""
 	}
-	return q.q[0]
+	return q.h[0].link
 }
 
 func (q *queue) pophead() {
-	if !q.empty() {
-		q.q = q.q[1:]
+	if q.empty() {
+		return
 	}
+	e := heap.Pop(&q.h).(queueEntry)
+	q.m[e.link] = queuedURL{popped, q.m[e.link].depth}
+	q.n--
 }
 
-func (q *queue) add(link string) {
-	link = removeFragment(link)
-	// Only add if it's not queued before
+func (q *queue) add(link string, depth int) {
+	link, err := Normalize(link)
+	if err != nil {
+		return
+	}
+	// Only add if it's not queued before, unless it's waiting
+	// in the low priority lane or was found on a shorter path
+	state, seen := q.m[link]
+	switch {
+	case !seen, state.state == queuedLow:
+	case state.state == queuedNormal && depth < state.depth:
+	default:
+		return
+	}
+	tier := tierInternal
+	if q.internal != nil && !q.internal(link) {
+		tier = tierExternal
+	}
+	q.push(link, tier, depth)
+	q.m[link] = queuedURL{queuedNormal, depth}
+	if !seen {
+		q.n++
+	}
+}
+
+// addLow queues link in the low priority lane.
+func (q *queue) addLow(link string, depth int) {
+	link, err := Normalize(link)
+	if err != nil {
+		return
+	}
 	if _, seen := q.m[link]; seen {
 		return
 	}
-	q.q = append(q.q, link)
-	q.m[link] = true
+	q.push(link, tierLow, depth)
+	q.m[link] = queuedURL{queuedLow, depth}
+	q.n++
+}
+
+// depth returns how many links away from the base URL link was found.
+func (q *queue) depth(link string) int {
+	return q.m[link].depth
 }
 
 // fetchResult is a type so that we can send fetch's results on a channel
 type fetchResult struct {
-	url   string
-	links []string
-	ids   []string
-	err   error
+	url      string
+	links    []string
+	contexts map[string]linkContext
+	ids      []string
+	// hashedIDs replace ids for duplicates of a page already crawled
+	hashedIDs idSet
+	findings  []finding
+	modified  time.Time
+	timings   fetchTimings
+	lang      string
+	locales   localeVariants
+	// redirect is the final URL if the request was redirected
+	redirect string
+	// validators are set if the page can be cached
+	validators cacheValidators
+	// status is the HTTP status of the final response, if there was one
+	status int
+	// retryAfter is set when the server asked us to come back later
+	retryAfter time.Duration
+	// throttled counts how many times the URL was retried after throttling
+	throttled int
+	// hostFailed is set if the host timed out or errored,
+	// even when the error itself is ignored
+	hostFailed bool
+	// unchecked is set for external URLs skipped by -internal-only
+	// and internal URLs skipped as a crawler trap
+	unchecked bool
+	// suggestion is a working correction for a missing URL
+	suggestion string
+	// shortenerStatus is the first response status for URL shorteners,
+	// and shortTarget is where their interstitial page points
+	shortenerStatus int
+	shortTarget     string
+	// contentHash identifies the body of internal HTML pages
+	contentHash string
+	// userAgent overrides the crawler's user agent for the request
+	userAgent string
+	// text is the normalized page text, kept for -check-text-fragments
+	text string
+	// postActions are the links that are form POST endpoints
+	postActions []string
+	// nofollow are the links marked rel=nofollow, with -respect-nofollow=check
+	nofollow map[string]bool
+	// robots are the directives in robots meta tags and X-Robots-Tag headers
+	robots []string
+	// hreflang are the page's translations, with -check-hreflang
+	hreflang localeVariants
+	// amp pairs the page with its AMP version, with -check-amp
+	amp ampLinks
+	// otherSchemes are links that weren't checked because of their scheme,
+	// with -list-other-schemes
+	otherSchemes []string
+	// title and description are the page's metadata, with -check-metadata
+	title       string
+	description string
+	err         error
 }
 
 type pageInfo struct {
-	ids   map[string]bool
-	links map[string]bool
-	err   error
+	ids idSet
+	// links maps each link to where it first appears on the page
+	links    map[string]linkContext
+	findings []finding
+	modified time.Time
+	timings  fetchTimings
+	lang     string
+	locales  localeVariants
+	// redirect is the final URL if the request was redirected
+	redirect    string
+	contentHash string
+	suggestion  string
+	throttled   int
+	unchecked   bool
+	err         error
+	// text is the normalized page text, kept for -check-text-fragments
+	text string
+	// nofollow are the links marked rel=nofollow
+	nofollow map[string]bool
+	// robots are the page's robots directives
+	robots []string
+	// hreflang are the page's translations
+	hreflang localeVariants
+	// amp pairs the page with its AMP version
+	amp ampLinks
+	// otherSchemes are links that weren't checked because of their scheme
+	otherSchemes []string
+	// title and description are the page's metadata
+	title       string
+	description string
 }
 
 type crawledPages map[string]pageInfo
@@ -64,76 +274,279 @@ func newCrawledPages() crawledPages {
 	return make(crawledPages)
 }
 
-func (cp crawledPages) add(fr fetchResult) {
+// add stores what's needed from fr, interning its strings in sp.
+func (cp crawledPages) add(fr fetchResult, sp stringPool) {
+	if fr.unchecked {
+		cp[fr.url] = pageInfo{findings: fr.findings, unchecked: true}
+		return
+	}
 	if fr.err != nil {
-		cp[fr.url] = pageInfo{err: fr.err}
+		cp[fr.url] = pageInfo{
+			timings:    fr.timings,
+			throttled:  fr.throttled,
+			suggestion: fr.suggestion,
+			err:        fr.err,
+		}
 		return
 	}
 	cp[fr.url] = pageInfo{
-		ids:   sliceToSet(fr.ids),
-		links: sliceToSet(fr.links),
+		ids:          fr.idSet(),
+		links:        linksWithContext(fr.links, fr.contexts, sp),
+		findings:     fr.findings,
+		modified:     fr.modified,
+		timings:      fr.timings,
+		lang:         fr.lang,
+		locales:      fr.locales,
+		redirect:     fr.redirect,
+		throttled:    fr.throttled,
+		contentHash:  fr.contentHash,
+		text:         fr.text,
+		nofollow:     fr.nofollow,
+		robots:       fr.robots,
+		hreflang:     fr.hreflang,
+		amp:          fr.amp,
+		otherSchemes: fr.otherSchemes,
+		title:        fr.title,
+		description:  fr.description,
+	}
+}
+
+// idSet returns the hashed IDs of fr.
+func (fr *fetchResult) idSet() idSet {
+	if fr.hashedIDs != nil {
+		return fr.hashedIDs
 	}
+	return newIDSet(fr.ids)
 }
 
-func (cp crawledPages) addLinksToQueue(url string, q *queue) {
+func linksWithContext(links []string, contexts map[string]linkContext, sp stringPool) map[string]linkContext {
+	if len(links) == 0 {
+		return nil
+	}
+	m := make(map[string]linkContext, len(links))
+	for _, link := range links {
+		lc := contexts[link]
+		m[sp.intern(link)] = linkContext{lc.text, sp.intern(lc.selector)}
+	}
+	return m
+}
+
+// addLinksToQueue queues the links on url, putting links
+// for which demote returns true in the low priority lane.
+func (cp crawledPages) addLinksToQueue(url string, q frontier, demote func(link string) bool) {
 	pi := cp[url]
+	depth := q.depth(url) + 1
 	for link := range pi.links {
-		q.add(link)
+		if demote(link) {
+			q.addLow(link, depth)
+		} else {
+			q.add(link, depth)
+		}
 	}
 }
 
-func (cp crawledPages) toURLErrors(base string) urlErrors {
+func (cp crawledPages) toURLErrors(sc scope, checkFragments bool) urlErrors {
 	requestErrs := make(urlErrors)
 	// Put all errors into errs
 	for url, pi := range cp {
 		if pi.err != nil {
-			requestErrs[url] = &pageError{pi.err, nil, nil}
+			requestErrs[url] = &pageError{err: pi.err, suggestion: pi.suggestion}
 		}
 	}
 	// For each page, if one of its links is in errs,
 	// add that to the back refs and check for its
 	// link ids in frags
 	fragErrs := make(urlErrors)
+	fragErr := func(link string) *pageError {
+		pe := fragErrs[link]
+		if pe == nil {
+			pe = &pageError{
+				err:                 ErrNormalizedFragment,
+				missingFragments:    make(map[string]bool),
+				normalizedFragments: make(map[string]bool),
+			}
+			fragErrs[link] = pe
+		}
+		return pe
+	}
+	localeErrs := make(urlErrors)
 	for page, pi := range cp {
 		// ignore pages off site
-		if !strings.HasPrefix(page, base) {
+		if !sc.contains(page) {
 			continue
 		}
-		for link := range pi.links {
-			link, frag := splitFragment(link)
+		for rawLink, lc := range pi.links {
+			link, frag, err := splitFragment(rawLink)
+			if err != nil {
+				continue
+			}
 			if pe, ok := requestErrs[link]; ok {
-				pe.refs = append(pe.refs, page)
+				pe.addRef(page, lc)
 			}
-			// Ignore empty # and URLs that look like JS apps (#!, #/)
-			if frag == "" ||
-				strings.HasPrefix(frag, "!") ||
-				strings.HasPrefix(frag, "/") {
+			if !checkFragments {
 				continue
 			}
-			if target, ok := cp[link]; ok && target.ids[frag] {
+			target, ok := cp[link]
+			if ok && target.unchecked {
 				continue
 			}
-			// fragment was missing
-			pe := fragErrs[link]
-			if pe == nil {
-				pe = &pageError{ErrMissingFragment, nil, make(map[string]bool)}
-				fragErrs[link] = pe
+			id, missingText, status := target.checkFragment(rawLink, frag)
+			if missingText != "" {
+				pe := fragErr(link)
+				pe.addRef(page, lc)
+				pe.err = ErrMissingFragment
+				pe.missingFragments[missingText] = true
 			}
-			pe.refs = append(pe.refs, page)
-			pe.missingFragments[frag] = true
+			switch status {
+			case fragmentIgnored:
+				continue
+			case fragmentFound:
+				cp.checkLocaleVariant(localeErrs, page, lc, pi.lang, link, target, id)
+				continue
+			}
+			pe := fragErr(link)
+			if missingText == "" {
+				pe.addRef(page, lc)
+			}
+			if status == fragmentNormalized {
+				pe.normalizedFragments[id] = true
+				continue
+			}
+			pe.err = ErrMissingFragment
+			pe.missingFragments[id] = true
 		}
 	}
 	// Merge errors
 	for url, pe := range fragErrs {
 		requestErrs[url] = pe
 	}
+	for url, pe := range localeErrs {
+		requestErrs[url] = pe
+	}
 	return requestErrs
 }
 
+// How a link's fragment ID matches its target page
+type fragmentStatus int
+
+const (
+	fragmentIgnored fragmentStatus = iota
+	fragmentFound
+	fragmentNormalized
+	fragmentMissing
+)
+
+// checkFragment matches frag, the fragment of rawLink, against pi.
+// Text directives are checked against the page text if it was kept;
+// missingText is the ones that weren't found, with their delimiter.
+// id is the fragment's ID, without any directives,
+// and status is how it matches one of the page's IDs.
+func (pi pageInfo) checkFragment(rawLink, frag string) (id, missingText string, status fragmentStatus) {
+	id = frag
+	if before, directives, found := strings.Cut(frag, fragmentDirectiveDelimiter); found {
+		if pi.text != "" && !textFragmentFound(rawLink, pi.text) {
+			missingText = fragmentDirectiveDelimiter + directives
+		}
+		id = before
+	}
+	switch {
+	// Ignore empty # and URLs that look like JS apps (#!, #/)
+	case id == "" || strings.HasPrefix(id, "!") || strings.HasPrefix(id, "/"):
+		return id, missingText, fragmentIgnored
+	// Browsers scroll to the top for #top in any case
+	// if no element matches
+	case strings.EqualFold(id, "top"):
+		return id, missingText, fragmentIgnored
+	case pi.ids.has(id):
+		return id, missingText, fragmentFound
+	case pi.ids.hasNormalized(id):
+		return id, missingText, fragmentNormalized
+	}
+	return id, missingText, fragmentMissing
+}
+
+func (cp crawledPages) toFindings() pageFindings {
+	pf := make(pageFindings)
+	for page, pi := range cp {
+		if len(pi.findings) > 0 {
+			pf[page] = pi.findings
+		}
+	}
+	return pf
+}
+
 type pageError struct {
-	err              error
-	refs             []string
-	missingFragments map[string]bool
+	err                 error
+	refs                []string
+	missingFragments    map[string]bool
+	normalizedFragments map[string]bool
+	// refContexts are where the URL is linked on each ref
+	refContexts map[string]linkContext
+	// staleOnly is set when an external URL is only linked from stale pages
+	staleOnly bool
+	// severity is set by setSeverities; if empty, the category's default is used
+	severity severity
+	// suggestion is a working URL to use instead, if one was found
+	suggestion string
+}
+
+// addRef records that page links to pe's URL at lc.
+func (pe *pageError) addRef(page string, lc linkContext) {
+	pe.refs = append(pe.refs, page)
+	if lc == (linkContext{}) {
+		return
+	}
+	if pe.refContexts == nil {
+		pe.refContexts = make(map[string]linkContext)
+	}
+	if _, ok := pe.refContexts[page]; !ok {
+		pe.refContexts[page] = lc
+	}
+}
+
+// Categories of pageError
+const (
+	categoryRequestError       = "request-error"
+	categoryMissingFragment    = "missing-fragment"
+	categoryNormalizedFragment = "normalized-fragment"
+	categoryTooManyRedirects   = "too-many-redirects"
+	categoryLocaleFragment     = "locale-fragment"
+	categorySkippedHost        = "skipped-host"
+	categoryFlakyDNS           = "dns-flaky"
+	categoryParkedDomain       = "parked-domain"
+)
+
+func (pe *pageError) category() string {
+	switch {
+	case pe.err == ErrMissingFragment:
+		return categoryMissingFragment
+	case pe.err == ErrNormalizedFragment:
+		return categoryNormalizedFragment
+	case pe.err == ErrLocaleFragment:
+		return categoryLocaleFragment
+	case pe.err == ErrHostUnhealthy:
+		return categorySkippedHost
+	case errors.Is(pe.err, ErrFlakyDNS):
+		return categoryFlakyDNS
+	case errors.Is(pe.err, ErrParkedDomain):
+		return categoryParkedDomain
+	case errors.Is(pe.err, ErrTooManyRedirects):
+		return categoryTooManyRedirects
+	}
+	return categoryRequestError
+}
+
+// level is the severity of pe. Errors for URLs only linked from
+// stale pages are relaxed to warnings.
+func (pe *pageError) level() severity {
+	sev := pe.severity
+	if sev == "" {
+		sev = severities(nil).of(pe.category())
+	}
+	if pe.staleOnly && sev == severityError {
+		return severityWarning
+	}
+	return sev
 }
 
 type urlErrors map[string]*pageError
@@ -142,12 +555,151 @@ func (ue urlErrors) String() string {
 	var buf strings.Builder
 	for page, pe := range ue {
 		fmt.Fprintf(&buf, "%q: %v\n", page, pe.err)
-		if pe.err == ErrMissingFragment {
+		if len(pe.missingFragments) > 0 {
 			fmt.Fprintf(&buf, "- ids: %s\n",
 				strings.Join(setToSlice(pe.missingFragments), ", "),
 			)
 		}
+		if len(pe.normalizedFragments) > 0 {
+			fmt.Fprintf(&buf, "- ids matching only after normalization: %s\n",
+				strings.Join(setToSlice(pe.normalizedFragments), ", "),
+			)
+		}
+		if pe.suggestion != "" {
+			fmt.Fprintf(&buf, "- did you mean: %s\n", pe.suggestion)
+		}
+		if pe.staleOnly {
+			fmt.Fprintf(&buf, "- only linked from stale pages\n")
+		}
+		if sev := pe.level(); sev != severityError {
+			fmt.Fprintf(&buf, "- severity: %s\n", sev)
+		}
 		fmt.Fprintf(&buf, " - refs: %s\n", strings.Join(pe.refs, ", "))
+		for _, ref := range pe.refs {
+			if lc, ok := pe.refContexts[ref]; ok {
+				fmt.Fprintf(&buf, "   - on %s: %v\n", ref, lc)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// finding is a problem with a crawled page itself,
+// as opposed to a problem with a URL that it links to.
+// Findings are reported as warnings unless their severity is overridden.
+type finding struct {
+	category string
+	detail   string
+}
+
+// Categories of finding
+const (
+	categoryHTMLLint      = "html-lint"
+	categoryTooManyLinks  = "too-many-links"
+	categoryCommentedLink = "commented-link"
+	categorySlowResponse  = "slow-response"
+	categoryBlockedAgent  = "blocked-user-agent"
+	categoryCrawlerTrap   = "crawler-trap"
+	// categoryHighLatency is an internal page slower to respond than -warn-latency
+	categoryHighLatency = "high-latency"
+	// categoryDuplicateID is an id used by more than one element on a page
+	categoryDuplicateID = "duplicate-id"
+	// categoryDeadAnchor is a link with an empty, javascript:, or bare # href
+	categoryDeadAnchor = "dead-anchor"
+	// categoryDuplicateContent is a page identical to another internal page
+	categoryDuplicateContent = "duplicate-content"
+	// categoryShortenedLink is where a link through a URL shortener leads
+	categoryShortenedLink = "shortened-link"
+	// categoryNonCanonicalHost is a link to an http/https or www/apex
+	// variant of the base URL's host
+	categoryNonCanonicalHost = "non-canonical-host"
+	// categoryNoindexLinked is an internal page marked noindex
+	// that many other pages link to
+	categoryNoindexLinked = "noindex-linked"
+	// categoryOneWayHreflang is an hreflang alternate that doesn't link back
+	categoryOneWayHreflang = "one-way-hreflang"
+	// categoryHreflangRedirect is an hreflang alternate that redirects
+	categoryHreflangRedirect = "hreflang-redirect"
+	// categoryAMPMismatch is a page and its AMP version that don't point to each other
+	categoryAMPMismatch = "amp-mismatch"
+	// categoryBadTel is a tel: link with a number that can't be dialed
+	categoryBadTel = "bad-tel"
+	// categoryDevHostLink is a link to a development or staging host
+	categoryDevHostLink = "dev-host-link"
+	// categoryEmptyLinkText lists the links on a page with no text
+	categoryEmptyLinkText = "empty-link-text"
+	// categoryAccessibility is an accessibility problem on a page,
+	// such as images without alt text
+	categoryAccessibility = "accessibility"
+	// categoryMissingMetadata is a page without a title or meta description
+	categoryMissingMetadata = "missing-metadata"
+	// categoryDuplicateMetadata is a page with the same title
+	// or meta description as another internal page
+	categoryDuplicateMetadata = "duplicate-metadata"
+	// categoryMissingContent is a page without the text
+	// a -require-content rule says it must have
+	categoryMissingContent = "missing-content"
+	// categorySecurityHeader is an internal page missing security headers
+	categorySecurityHeader = "security-header"
+	// categoryTokenizedPage is a page over -stream-threshold
+	// that skipped the page audits that need a DOM
+	categoryTokenizedPage = "tokenized-page"
+)
+
+type pageFindings map[string][]finding
+
+func (pf pageFindings) String() string {
+	var buf strings.Builder
+	for _, page := range pf.pages() {
+		fmt.Fprintf(&buf, "%q:\n", page)
+		for _, f := range pf[page] {
+			fmt.Fprintf(&buf, " - %s: %s\n", f.category, f.detail)
+		}
 	}
 	return buf.String()
 }
+
+// pages returns the URLs of pages with findings, sorted.
+func (pf pageFindings) pages() []string {
+	pages := make([]string, 0, len(pf))
+	for page := range pf {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+	return pages
+}
+
+// results are the problems found by a crawl.
+type results struct {
+	errs     urlErrors
+	findings pageFindings
+	// timings are only reported in verbose mode
+	timings pageTimings
+	// recs is nil unless recommendations were requested
+	recs *recommendations
+	// stats is nil unless link stats were requested
+	stats *linkStats
+	// schemes is nil unless links with other schemes were requested
+	schemes otherSchemes
+	// hosts are the requests and bytes for each host, most bytes first
+	hosts []hostUsage
+	// severities overrides the severities of findings
+	severities severities
+}
+
+func (res results) String() string {
+	s := res.errs.String()
+	if len(res.findings) > 0 {
+		s += "\nPage findings:\n" + res.findings.String()
+	}
+	if res.recs != nil && !res.recs.empty() {
+		s += "\nRecommendations:\n" + res.recs.String()
+	}
+	if res.stats != nil {
+		s += "\nInternal linking:\n" + res.stats.String()
+	}
+	if len(res.schemes) > 0 {
+		s += "\nLinks with unchecked schemes:\n" + res.schemes.String()
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package linkcheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueueLowPriority(t *testing.T) {
+	q := newQueue("http://example.com/", nil)
+	q.addLow("http://other.example/stale", 1)
+	q.addLow("http://other.example/promoted", 1)
+	q.add("http://example.com/a", 1)
+	q.add("http://other.example/promoted", 1)
+	q.add("http://example.com/a#dupe", 1)
+
+	var got []string
+	for !q.empty() {
+		got = append(got, q.head())
+		q.pophead()
+		// re-adding a popped URL is a no-op
+		q.add("http://example.com/", 1)
+	}
+	want := []string{
+		"http://example.com/",
+		"http://example.com/a",
+		"http://other.example/promoted",
+		"http://other.example/stale",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	internal := func(link string) bool { return strings.HasPrefix(link, "http://example.com/") }
+	q := newQueue("http://example.com/", internal)
+	q.add("http://other.example/", q.depth("http://example.com/")+1)
+	q.add("http://example.com/a", 1)
+	q.addLow("http://stale.example/", 1)
+	q.add("http://example.com/b", 1)
+	if q.len() != 5 {
+		t.Errorf("got len %d; want 5", q.len())
+	}
+
+	var got []string
+	for !q.empty() {
+		head := q.head()
+		got = append(got, head)
+		q.pophead()
+		if head == "http://example.com/a" {
+			q.add("http://example.com/a/deep", q.depth(head)+1)
+			q.add("http://other.example/deep", q.depth(head)+1)
+		}
+		if head == "http://example.com/b" {
+			q.add("http://example.com/b/deep", q.depth(head)+1)
+		}
+	}
+	want := []string{
+		"http://example.com/",
+		"http://example.com/a",
+		"http://example.com/b",
+		"http://example.com/a/deep",
+		"http://example.com/b/deep",
+		"http://other.example/",
+		"http://other.example/deep",
+		"http://stale.example/",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if q.len() != 0 {
+		t.Errorf("got len %d; want 0", q.len())
+	}
+}
+
+func TestQueueShallowerPath(t *testing.T) {
+	q := newQueue("http://example.com/", nil)
+	q.pophead()
+	q.add("http://example.com/far", 3)
+	q.add("http://example.com/b", 2)
+	// found again closer to the base URL
+	q.add("http://example.com/far", 1)
+	q.add("http://example.com/far", 2)
+	if q.len() != 2 {
+		t.Errorf("got len %d; want 2", q.len())
+	}
+	var got []string
+	for !q.empty() {
+		got = append(got, q.head())
+		q.pophead()
+	}
+	want := []string{"http://example.com/far", "http://example.com/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if d := q.depth("http://example.com/far"); d != 1 {
+		t.Errorf("depth = %d; want 1", d)
+	}
+}
@@ -0,0 +1,48 @@
+package linkcheck
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// handlerAttrs suggest that script handles clicks on a link to bare #.
+var handlerAttrs = []string{"onclick", "role", "aria-controls", "data-toggle", "data-bs-toggle"}
+
+// deadAnchors returns findings for links in doc that go nowhere:
+// an empty href, a javascript: URL, or a bare # with nothing
+// to suggest that script handles it. These aren't broken,
+// but they can't be followed by keyboard users, crawlers,
+// or readers opening them in a new tab.
+func deadAnchors(doc *html.Node) []finding {
+	var findings []finding
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom != atom.A || !hasAttr(n, "href") {
+			return
+		}
+		href := strings.TrimSpace(attr(n, "href"))
+		var problem string
+		switch {
+		case href == "":
+			problem = "link with empty href"
+		case strings.HasPrefix(strings.ToLower(href), "javascript:"):
+			problem = "link to " + href
+		case href == "#" && !hasHandler(n):
+			problem = "link to bare #"
+		default:
+			return
+		}
+		findings = append(findings, finding{categoryDeadAnchor, problem + ": " + describeLink(n).String()})
+	})
+	return findings
+}
+
+func hasHandler(n *html.Node) bool {
+	for _, key := range handlerAttrs {
+		if hasAttr(n, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package linkcheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDeadAnchors(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body><nav id="menu">
+<a href="">Home</a>
+<a href="JavaScript:void(0)">Share</a>
+<a href="#">Top</a>
+<a href="#" onclick="openMenu()">Menu</a>
+<a href="#" data-bs-toggle="collapse">More</a>
+<a href="#main">Skip</a>
+<a name="anchor">placeholder</a>
+<a href="/news/">News</a>
+</nav></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range deadAnchors(doc) {
+		if f.category != categoryDeadAnchor {
+			t.Errorf("unexpected category %q", f.category)
+		}
+		got = append(got, f.detail)
+	}
+	want := []string{
+		`link with empty href: "Home" at #menu > a:nth-child(1)`,
+		`link to JavaScript:void(0): "Share" at #menu > a:nth-child(2)`,
+		`link to bare #: "Top" at #menu > a:nth-child(3)`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+package linkcheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDebugBody is how much of each response body is kept in a debug bundle.
+const maxDebugBody = 64 << 10
+
+type exchangeLogKey struct{}
+
+// exchangeLog records the HTTP exchanges made while fetching one URL.
+type exchangeLog struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (el *exchangeLog) printf(format string, args ...interface{}) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	fmt.Fprintf(&el.buf, format, args...)
+}
+
+func (el *exchangeLog) write(b []byte) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	el.buf.Write(b)
+}
+
+// redactedHeaders are left out of debug bundles.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+const redacted = "REDACTED"
+
+// debugTransport records requests and responses into the exchangeLog
+// in the request context, if there is one.
+// Credentials, including the -token parameter, are redacted.
+type debugTransport struct {
+	rt    http.RoundTripper
+	token queryToken
+}
+
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range redactedHeaders {
+		if len(h.Values(name)) > 0 {
+			h.Set(name, redacted)
+		}
+	}
+	return h
+}
+
+// redactRequest returns a copy of req without credentials.
+func (dt debugTransport) redactRequest(req *http.Request) *http.Request {
+	r2 := req.Clone(req.Context())
+	r2.Header = redactHeader(req.Header)
+	if dt.token.name != "" {
+		if q := r2.URL.Query(); q.Has(dt.token.name) {
+			q.Set(dt.token.name, redacted)
+			r2.URL.RawQuery = q.Encode()
+		}
+	}
+	return r2
+}
+
+func (dt debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	el, _ := req.Context().Value(exchangeLogKey{}).(*exchangeLog)
+	if el == nil {
+		return dt.rt.RoundTrip(req)
+	}
+	el.printf("=== request at %s\n", time.Now().Format(time.RFC3339Nano))
+	if dump, err := httputil.DumpRequestOut(dt.redactRequest(req), false); err == nil {
+		el.write(dump)
+	}
+	res, err := dt.rt.RoundTrip(req)
+	if err != nil {
+		el.printf("=== error\n%v\n\n", err)
+		return nil, err
+	}
+	el.printf("=== response\n")
+	res2 := *res
+	res2.Header = redactHeader(res.Header)
+	if dump, err := httputil.DumpResponse(&res2, false); err == nil {
+		el.write(dump)
+	}
+	// Keep the start of the body, then hand it back unchanged
+	head, err := io.ReadAll(io.LimitReader(res.Body, maxDebugBody))
+	el.write(head)
+	if err != nil {
+		el.printf("\n=== error reading body\n%v", err)
+	}
+	el.printf("\n\n")
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), res.Body), res.Body}
+	return res, nil
+}
+
+// withExchangeLog returns a context whose requests are recorded
+// if debug bundles are enabled.
+func (c *crawler) withExchangeLog(ctx context.Context) (context.Context, *exchangeLog) {
+	if c.debugBundle == "" {
+		return ctx, nil
+	}
+	el := new(exchangeLog)
+	return context.WithValue(ctx, exchangeLogKey{}, el), el
+}
+
+// saveDebugBundle writes the recorded exchanges for a failed fetch
+// into the debug bundle directory.
+func (c *crawler) saveDebugBundle(pageurl string, fetchErr error, el *exchangeLog) {
+	if el == nil {
+		return
+	}
+	if err := os.MkdirAll(c.debugBundle, 0o700); err != nil {
+		c.Warn("could not create debug bundle directory", "error", err)
+		return
+	}
+	name := filepath.Join(c.debugBundle, debugBundleName(pageurl))
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "URL: %s\nError: %v\n\n", pageurl, fetchErr)
+	buf.Write(el.buf.Bytes())
+	if err := os.WriteFile(name, buf.Bytes(), 0o600); err != nil {
+		c.Warn("could not write debug bundle", "url", pageurl, "error", err)
+		return
+	}
+	c.Info("wrote debug bundle", "url", pageurl, "path", name)
+}
+
+// debugBundleName makes a readable, unique file name for pageurl.
+func debugBundleName(pageurl string) string {
+	readable := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.TrimPrefix(strings.TrimPrefix(pageurl, "https://"), "http://"))
+	if len(readable) > 80 {
+		readable = readable[:80]
+	}
+	sum := sha256.Sum256([]byte(pageurl))
+	return fmt.Sprintf("%s-%x.txt", readable, sum[:4])
+}
@@ -0,0 +1,68 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDebugBundleRedacts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "set-secret"})
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	c := crawler{
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		debugBundle: dir,
+	}
+	cl := &http.Client{Transport: debugTransport{http.DefaultTransport, queryToken{"preview", "token-secret"}}}
+	ctx, el := c.withExchangeLog(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/page?preview=token-secret&a=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer auth-secret")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "cookie-secret"})
+	res, err := cl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	c.saveDebugBundle(ts.URL+"/page", err, el)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got files %v: %v", files, err)
+	}
+	info, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("bundle mode is %v", perm)
+	}
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := string(b)
+	for _, secret := range []string{"token-secret", "auth-secret", "cookie-secret", "set-secret"} {
+		if strings.Contains(bundle, secret) {
+			t.Errorf("bundle contains %q:\n%s", secret, bundle)
+		}
+	}
+	for _, want := range []string{"Authorization: REDACTED", "Set-Cookie: REDACTED", "preview=REDACTED", "a=1", "500 Internal Server Error"} {
+		if !strings.Contains(bundle, want) {
+			t.Errorf("bundle is missing %q:\n%s", want, bundle)
+		}
+	}
+}
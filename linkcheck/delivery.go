@@ -0,0 +1,102 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Delivery retry settings
+const (
+	deliveryAttempts = 4
+	deliveryTimeout  = 30 * time.Second
+	deliveryBackoff  = 2 * time.Second
+)
+
+// sink is somewhere a report gets delivered after a run.
+type sink struct {
+	name    string
+	deliver func(ctx context.Context, res results) error
+}
+
+// deliveryStatus records the outcome of delivering to a sink.
+type deliveryStatus struct {
+	sink     string
+	attempts int
+	err      error
+}
+
+func (ds deliveryStatus) String() string {
+	if ds.err != nil {
+		return ds.sink + ":failed"
+	}
+	return ds.sink + ":ok"
+}
+
+// permanentError marks a delivery error that retrying won't fix.
+type permanentError struct{ error }
+
+func (pe permanentError) Unwrap() error { return pe.error }
+
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+func (c *crawler) sinks() []sink {
+	var sinks []sink
+	if c.mailer != nil {
+		sinks = append(sinks, sink{"email", c.emailReport})
+	}
+	if c.webhookURL != "" {
+		sinks = append(sinks, sink{"webhook", c.postWebhook})
+	}
+	return sinks
+}
+
+// deliverAll sends the results to every configured sink,
+// retrying failures with exponential backoff until ctx is done.
+func (c *crawler) deliverAll(ctx context.Context, res results) []deliveryStatus {
+	var statuses []deliveryStatus
+	for _, s := range c.sinks() {
+		ds := c.deliver(ctx, s, res, deliveryBackoff)
+		if ds.err != nil {
+			c.Warn("delivering report failed",
+				"to", s.name, "attempts", ds.attempts, "error", ds.err)
+		} else {
+			c.Info("delivered report", "to", s.name)
+		}
+		statuses = append(statuses, ds)
+	}
+	return statuses
+}
+
+// deliver sends the results to s, waiting backoff before the first retry
+// and twice as long before each one after that.
+func (c *crawler) deliver(ctx context.Context, s sink, res results, backoff time.Duration) deliveryStatus {
+	ds := deliveryStatus{sink: s.name}
+	for ds.attempts < deliveryAttempts {
+		ds.attempts++
+		attemptCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+		ds.err = s.deliver(attemptCtx, res)
+		cancel()
+		if ds.err == nil || errors.As(ds.err, new(permanentError)) || ctx.Err() != nil {
+			break
+		}
+		if ds.attempts < deliveryAttempts {
+			c.Warn("delivering report failed; retrying", "to", s.name, "error", ds.err)
+			// add up to 50% jitter
+			wait := backoff + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			select {
+			case <-ctx.Done():
+				return ds
+			case <-time.After(wait):
+			}
+			backoff *= 2
+		}
+	}
+	return ds
+}
@@ -0,0 +1,64 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestDeliver(t *testing.T) {
+	c := crawler{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	errDown := errors.New("server down")
+	failing := func(failures int, err error) (sink, *int) {
+		calls := new(int)
+		return sink{"test", func(ctx context.Context, res results) error {
+			*calls++
+			if *calls <= failures {
+				return err
+			}
+			return nil
+		}}, calls
+	}
+
+	s, calls := failing(2, errDown)
+	ds := c.deliver(context.Background(), s, results{}, time.Millisecond)
+	if ds.err != nil || ds.attempts != 3 || *calls != 3 {
+		t.Errorf("transient failures: got attempts=%d err=%v; want 3 attempts and success", ds.attempts, ds.err)
+	}
+	if ds.String() != "test:ok" {
+		t.Errorf("status = %q", ds)
+	}
+
+	s, calls = failing(deliveryAttempts, errDown)
+	ds = c.deliver(context.Background(), s, results{}, time.Millisecond)
+	if !errors.Is(ds.err, errDown) || ds.attempts != deliveryAttempts || *calls != deliveryAttempts {
+		t.Errorf("persistent failures: got attempts=%d err=%v; want %d attempts", ds.attempts, ds.err, deliveryAttempts)
+	}
+	if ds.String() != "test:failed" {
+		t.Errorf("status = %q", ds)
+	}
+
+	s, calls = failing(deliveryAttempts, permanent(errDown))
+	ds = c.deliver(context.Background(), s, results{}, time.Millisecond)
+	if !errors.Is(ds.err, errDown) || ds.attempts != 1 || *calls != 1 {
+		t.Errorf("permanent failure: got attempts=%d err=%v; want 1 attempt", ds.attempts, ds.err)
+	}
+
+	// Cancelling stops the retries without waiting out the backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	s, calls = failing(deliveryAttempts, errDown)
+	s.deliver = func(context.Context, results) error {
+		*calls++
+		cancel()
+		return errDown
+	}
+	start := time.Now()
+	ds = c.deliver(ctx, s, results{}, time.Hour)
+	if ds.attempts != 1 || *calls != 1 || time.Since(start) > time.Minute {
+		t.Errorf("cancelled: got attempts=%d after %v; want 1 attempt", ds.attempts, time.Since(start))
+	}
+	cancel()
+}
@@ -0,0 +1,63 @@
+package linkcheck
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// defaultDevHosts are development hosts that shouldn't be linked
+// from published pages unless -dev-hosts says otherwise.
+const defaultDevHosts = "localhost,127.0.0.1,0.0.0.0,::1,*.localhost,*.test,*.local"
+
+// parseDevHosts parses a comma separated list of host patterns.
+func parseDevHosts(s string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(s, ",") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad host pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// isDevHost reports whether host matches one of the -dev-hosts patterns.
+func (c *crawler) isDevHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range c.devHosts {
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// devHostLinks returns findings for links on a page to development
+// or staging hosts, which usually slip in when copy is drafted
+// against a preview. The crawled site's own host isn't reported,
+// so a local preview can still be crawled.
+func (c *crawler) devHostLinks(links []string) []finding {
+	if len(c.devHosts) == 0 {
+		return nil
+	}
+	base := hostname(c.base)
+	var findings []finding
+	seen := make(map[string]bool)
+	for _, link := range links {
+		host := hostname(link)
+		if host == "" || strings.EqualFold(host, base) || seen[link] || !c.isDevHost(host) {
+			continue
+		}
+		seen[link] = true
+		findings = append(findings, finding{
+			categoryDevHostLink,
+			fmt.Sprintf("links to %s on development host %s", link, host),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,52 @@
+package linkcheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDevHostLinks(t *testing.T) {
+	patterns, err := parseDevHosts(defaultDevHosts + ", *.Staging.Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := crawler{base: "https://example.com/", devHosts: patterns}
+	var got []string
+	for _, f := range c.devHostLinks([]string{
+		"https://example.com/news/",
+		"http://localhost:1313/news/",
+		"http://localhost:1313/news/",
+		"http://127.0.0.1/admin",
+		"http://[::1]:8080/",
+		"https://preview.staging.example.com/story",
+		"https://staging.example.com/story",
+		"https://site.test/",
+		"https://example.org/",
+		"mailto:tips@example.com",
+	}) {
+		if f.category != categoryDevHostLink {
+			t.Errorf("unexpected category %q", f.category)
+		}
+		got = append(got, f.detail)
+	}
+	want := []string{
+		"links to http://localhost:1313/news/ on development host localhost",
+		"links to http://127.0.0.1/admin on development host 127.0.0.1",
+		"links to http://[::1]:8080/ on development host ::1",
+		"links to https://preview.staging.example.com/story on development host preview.staging.example.com",
+		"links to https://site.test/ on development host site.test",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	// A local preview can link to itself
+	c.base = "http://localhost:1313/"
+	if got := c.devHostLinks([]string{"http://localhost:1313/news/"}); got != nil {
+		t.Errorf("got %v for the site's own host; want none", got)
+	}
+
+	if _, err := parseDevHosts("[bad"); err == nil {
+		t.Error("want error for bad pattern")
+	}
+}
@@ -0,0 +1,136 @@
+package linkcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// dnsRetries is how many more times a URL is fetched
+	// after a temporary DNS failure.
+	dnsRetries = 2
+	// dnsRetryDelay is the wait before the first retry;
+	// later retries wait longer.
+	dnsRetryDelay = time.Second
+)
+
+// newResolver returns a resolver for a -dns setting: either a comma separated
+// list of DNS servers, as host or host:port, or a DNS-over-HTTPS endpoint URL,
+// which is requested with cl.
+func newResolver(s string, cl *http.Client) (*net.Resolver, error) {
+	if strings.HasPrefix(s, "https://") {
+		return &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return &dohConn{ctx: ctx, endpoint: s, client: cl}, nil
+			},
+		}, nil
+	}
+	var servers []string
+	for _, server := range strings.Split(s, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		servers = append(servers, server)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("bad DNS setting %q: want servers or an https:// URL", s)
+	}
+	var (
+		mu   sync.Mutex
+		next int
+	)
+	return &net.Resolver{
+		PreferGo: true,
+		// Ignore the system's servers and rotate through ours
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			mu.Lock()
+			server := servers[next%len(servers)]
+			next++
+			mu.Unlock()
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}, nil
+}
+
+// dohConn is a fake connection that sends the DNS queries the Go resolver
+// writes to it to a DNS-over-HTTPS endpoint (RFC 8484).
+// Because it isn't a net.PacketConn, the resolver uses TCP framing:
+// each message is preceded by its length as two bytes.
+type dohConn struct {
+	ctx      context.Context
+	endpoint string
+	client   *http.Client
+	deadline time.Time
+	resp     bytes.Buffer
+}
+
+func (dc *dohConn) Write(b []byte) (int, error) {
+	if len(b) < 2 || int(binary.BigEndian.Uint16(b)) != len(b)-2 {
+		return 0, errors.New("DNS-over-HTTPS: bad query framing")
+	}
+	ctx := dc.ctx
+	if !dc.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, dc.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dc.endpoint, bytes.NewReader(b[2:]))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	res, err := dc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("DNS-over-HTTPS: unexpected status %d", res.StatusCode)
+	}
+	msg, err := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+	if err != nil {
+		return 0, err
+	}
+	dc.resp.Reset()
+	dc.resp.Write([]byte{byte(len(msg) >> 8), byte(len(msg))})
+	dc.resp.Write(msg)
+	return len(b), nil
+}
+
+func (dc *dohConn) Read(b []byte) (int, error) { return dc.resp.Read(b) }
+
+func (dc *dohConn) Close() error { return nil }
+
+func (dc *dohConn) LocalAddr() net.Addr { return dohAddr{} }
+
+func (dc *dohConn) RemoteAddr() net.Addr { return dohAddr{} }
+
+func (dc *dohConn) SetDeadline(t time.Time) error {
+	dc.deadline = t
+	return nil
+}
+
+func (dc *dohConn) SetReadDeadline(t time.Time) error { return nil }
+
+func (dc *dohConn) SetWriteDeadline(t time.Time) error { return dc.SetDeadline(t) }
+
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "https" }
+func (dohAddr) String() string  { return "doh" }
@@ -0,0 +1,167 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// answerDNS answers A queries for linkrot.test with 127.0.0.1,
+// queries for flaky.test with SERVFAIL, and everything else with NXDOMAIN.
+func answerDNS(query []byte) []byte {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+	rh := dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true}
+	switch q.Name.String() {
+	case "linkrot.test.":
+	case "flaky.test.":
+		rh.RCode = dnsmessage.RCodeServerFailure
+	default:
+		rh.RCode = dnsmessage.RCodeNameError
+	}
+	b := dnsmessage.NewBuilder(nil, rh)
+	b.EnableCompression()
+	b.StartQuestions()
+	b.Question(q)
+	b.StartAnswers()
+	if rh.RCode == dnsmessage.RCodeSuccess && q.Type == dnsmessage.TypeA {
+		b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60},
+			dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}})
+	}
+	msg, _ := b.Finish()
+	return msg
+}
+
+// startDNSServer serves answerDNS over UDP and returns its address.
+func startDNSServer(t *testing.T) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(answerDNS(buf[:n]), addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func checkResolver(t *testing.T, r *net.Resolver) {
+	t.Helper()
+	addrs, err := r.LookupHost(context.Background(), "linkrot.test")
+	if err != nil || len(addrs) != 1 || addrs[0] != "127.0.0.1" {
+		t.Errorf("LookupHost = %v, %v; want 127.0.0.1", addrs, err)
+	}
+	_, err = r.LookupHost(context.Background(), "missing.test")
+	if d := new(net.DNSError); !errors.As(err, &d) || !d.IsNotFound {
+		t.Errorf("LookupHost(missing.test) = %v; want not found", err)
+	}
+}
+
+func TestResolverServers(t *testing.T) {
+	server := startDNSServer(t)
+	r, err := newResolver(server, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResolver(t, r)
+
+	if _, err = newResolver(" , ", nil); err == nil {
+		t.Error("expected error for empty server list")
+	}
+}
+
+func TestResolverDoH(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		query, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(answerDNS(query))
+	}))
+	defer ts.Close()
+	r, err := newResolver(ts.URL+"/dns-query", ts.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResolver(t, r)
+}
+
+func TestDNSErrorCategories(t *testing.T) {
+	server := startDNSServer(t)
+	base, _ := url.Parse("http://example.com/")
+	tr, _, err := newTransport(base, transportOptions{dns: server})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCrawler(base.String())
+	c.Client = &http.Client{Transport: tr}
+	for _, test := range []struct{ url, category string }{
+		{"http://missing.test/", categoryRequestError},
+		{"http://flaky.test/", categoryFlakyDNS},
+	} {
+		fr := fetchResult{url: test.url}
+		err := c.doFetch(context.Background(), &fr)
+		if err == nil {
+			t.Fatalf("%s: expected an error", test.url)
+		}
+		pe := &pageError{err: err}
+		if got := pe.category(); got != test.category {
+			t.Errorf("%s: category = %q; want %q", test.url, got, test.category)
+		}
+	}
+}
+
+func TestResolverDoHProxy(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		tunnels []string
+	)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tunnels = append(tunnels, r.Method+" "+r.Host)
+		mu.Unlock()
+		http.Error(w, "no tunnels", http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+	base, _ := url.Parse("http://example.com/")
+	_, r, err := newTransport(base, transportOptions{
+		proxy: proxy.URL,
+		dns:   "https://doh.test/dns-query",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.LookupHost(context.Background(), "example.test"); err == nil {
+		t.Fatal("expected the lookup to fail")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(tunnels) == 0 || tunnels[0] != "CONNECT doh.test:443" {
+		t.Errorf("proxy saw %q; want DNS-over-HTTPS requests tunneled through it", tunnels)
+	}
+}
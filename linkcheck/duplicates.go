@@ -0,0 +1,102 @@
+package linkcheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// contentIndex remembers the first internal page crawled with each body,
+// so pages with identical content aren't parsed again.
+// Pages are indexed by body and by the URL relative links on them resolve
+// against, so a duplicate is only skipped when its links are the same.
+type contentIndex struct {
+	mu    sync.Mutex
+	pages map[string]indexedPage
+}
+
+type indexedPage struct {
+	url     string
+	ids     idSet
+	lang    string
+	locales localeVariants
+	text    string
+	// hreflang and amp are kept so duplicates can be checked for reciprocity
+	hreflang localeVariants
+	amp      ampLinks
+	// title and description are kept so duplicates group deterministically
+	title, description string
+}
+
+func newContentIndex() *contentIndex {
+	return &contentIndex{pages: make(map[string]indexedPage)}
+}
+
+// contentHash returns the hash used to detect duplicate page bodies.
+func contentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// indexKey combines hash with pageurl minus its query and fragment,
+// since relative links on a page resolve against its path.
+func indexKey(hash, pageurl string) string {
+	u, err := url.Parse(pageurl)
+	if err != nil {
+		return hash + " " + pageurl
+	}
+	u.RawQuery, u.Fragment, u.RawFragment = "", "", ""
+	return hash + " " + u.String()
+}
+
+// load returns the page first stored with hash at pageurl's path, if it isn't pageurl.
+func (ci *contentIndex) load(hash, pageurl string) (indexedPage, bool) {
+	if ci == nil {
+		return indexedPage{}, false
+	}
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	ip, ok := ci.pages[indexKey(hash, pageurl)]
+	return ip, ok && ip.url != pageurl
+}
+
+func (ci *contentIndex) store(hash, pageurl string, fr *fetchResult) {
+	if ci == nil {
+		return
+	}
+	key := indexKey(hash, pageurl)
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	if _, ok := ci.pages[key]; !ok {
+		ci.pages[key] = indexedPage{fr.url, fr.idSet(), fr.lang, fr.locales, fr.text, fr.hreflang, fr.amp, fr.title, fr.description}
+	}
+}
+
+// markDuplicates adds a finding to each internal page
+// whose content is identical to another page's.
+// The first URL of each cluster, in sorted order, is treated as the original.
+func (cp crawledPages) markDuplicates() {
+	clusters := make(map[string][]string)
+	for page, pi := range cp {
+		if pi.contentHash != "" && pi.err == nil {
+			clusters[pi.contentHash] = append(clusters[pi.contentHash], page)
+		}
+	}
+	for _, pages := range clusters {
+		if len(pages) < 2 {
+			continue
+		}
+		sort.Strings(pages)
+		for _, page := range pages[1:] {
+			pi := cp[page]
+			pi.findings = append(pi.findings, finding{
+				categoryDuplicateContent,
+				fmt.Sprintf("same content as %s; %d pages are identical", pages[0], len(pages)),
+			})
+			cp[page] = pi
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	var parsedLinks int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="/a">a</a><a href="/a?x=1">a again</a><a href="/b#top">b</a>`)
+	})
+	page := `<h1 id="top">same</h1><a href="/c">c</a>`
+	for _, path := range []string{"/a", "/b"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, page)
+		})
+	}
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		parsedLinks++
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<p>c</p>`)
+	})
+	site, c, pages := crawlSite(t, mux, nil)
+	if parsedLinks != 1 {
+		t.Errorf("/c fetched %d times; want 1", parsedLinks)
+	}
+	findings := pages.toFindings()
+	if len(findings) != 2 {
+		t.Fatalf("got findings %v; want two duplicates", findings)
+	}
+	for _, page := range []string{"/a?x=1", "/b"} {
+		fs := findings[site+page]
+		if len(fs) != 1 || fs[0].category != categoryDuplicateContent ||
+			!strings.Contains(fs[0].detail, site+"/a;") {
+			t.Errorf("%s: got findings %v; want duplicate of /a", page, fs)
+		}
+	}
+	// IDs carry over to skipped duplicates
+	if errs := pages.toURLErrors(c.scope(), true); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestDuplicatesRelativeLinks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="/docs">docs</a><a href="/docs/">docs again</a>`)
+	})
+	// The same body served at both paths links to different pages
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="guide">guide</a>`)
+	})
+	mux.HandleFunc("/docs/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="guide">guide</a>`)
+	})
+	site, _, pages := crawlSite(t, mux, nil)
+	for _, page := range []string{"/guide", "/docs/guide"} {
+		if _, ok := pages[site+page]; !ok {
+			t.Errorf("%s wasn't crawled", page)
+		}
+	}
+	findings := pages.toFindings()
+	if fs := findings[site+"/docs/"]; len(fs) != 1 || fs[0].category != categoryDuplicateContent {
+		t.Errorf("got findings %v; want /docs/ to duplicate /docs", findings)
+	}
+}
@@ -0,0 +1,149 @@
+package linkcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// mailer delivers reports by email.
+type mailer struct {
+	server *url.URL
+	from   string
+	to     []string
+}
+
+func newMailer(smtpURL, from string, to []string) (*mailer, error) {
+	u, err := url.Parse(smtpURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing SMTP URL: %w", err)
+	}
+	if u.Scheme != "smtp" && u.Scheme != "smtps" {
+		return nil, fmt.Errorf("SMTP URL scheme must be smtp or smtps: %q", u.Redacted())
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("SMTP URL missing host: %q", u.Redacted())
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("no email recipients")
+	}
+	if from == "" && u.User != nil {
+		from = u.User.Username()
+	}
+	if !strings.Contains(from, "@") {
+		return nil, fmt.Errorf("bad email sender: %q", from)
+	}
+	return &mailer{u, from, to}, nil
+}
+
+func (m *mailer) addr() string {
+	port := m.server.Port()
+	if port == "" {
+		port = "25"
+		if m.server.Scheme == "smtps" {
+			port = "465"
+		}
+	}
+	return net.JoinHostPort(m.server.Hostname(), port)
+}
+
+func (m *mailer) auth() smtp.Auth {
+	if m.server.User == nil {
+		return nil
+	}
+	pass, _ := m.server.User.Password()
+	return smtp.PlainAuth("", m.server.User.Username(), pass, m.server.Hostname())
+}
+
+func (m *mailer) message(subject, contentType, body string) []byte {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	fmt.Fprintf(&buf, "\r\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(buf.String())
+}
+
+func (m *mailer) dial(ctx context.Context) (net.Conn, error) {
+	if m.server.Scheme == "smtps" {
+		d := tls.Dialer{Config: &tls.Config{ServerName: m.server.Hostname()}}
+		return d.DialContext(ctx, "tcp", m.addr())
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", m.addr())
+}
+
+func (m *mailer) send(ctx context.Context, subject, contentType, body string) error {
+	msg := m.message(subject, contentType, body)
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	cl, err := smtp.NewClient(conn, m.server.Hostname())
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer cl.Close()
+	if ok, _ := cl.Extension("STARTTLS"); ok && m.server.Scheme == "smtp" {
+		if err = cl.StartTLS(&tls.Config{ServerName: m.server.Hostname()}); err != nil {
+			return err
+		}
+	}
+	if a := m.auth(); a != nil {
+		if err = cl.Auth(a); err != nil {
+			return err
+		}
+	}
+	if err = cl.Mail(m.from); err != nil {
+		return err
+	}
+	for _, addr := range m.to {
+		if err = cl.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := cl.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return cl.Quit()
+}
+
+func (c *crawler) emailReport(ctx context.Context, res results) error {
+	subject := fmt.Sprintf("linkrot: no broken links found on %s", c.base)
+	if len(res.errs) > 0 {
+		subject = fmt.Sprintf("linkrot: %d broken links found on %s", len(res.errs), c.base)
+	}
+	var buf strings.Builder
+	if err := c.writeReport(&buf, res); err != nil {
+		return permanent(fmt.Errorf("rendering report: %w", err))
+	}
+	body := buf.String()
+	if strings.TrimSpace(body) == "" {
+		body = "No broken links found.\n"
+	}
+	contentType := "text/plain"
+	if c.format == formatHTML {
+		contentType = "text/html"
+	}
+	return c.mailer.send(ctx, subject, contentType, body)
+}
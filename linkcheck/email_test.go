@@ -0,0 +1,140 @@
+package linkcheck
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewMailer(t *testing.T) {
+	for _, test := range []struct {
+		smtpURL, from string
+		to            []string
+		addr, sender  string
+	}{
+		{"smtp://mail.example.com", "bot@example.com", []string{"a@example.com"}, "mail.example.com:25", "bot@example.com"},
+		{"smtps://mail.example.com", "bot@example.com", []string{"a@example.com"}, "mail.example.com:465", "bot@example.com"},
+		{"smtp://bot%40example.com:pw@mail.example.com:587", "", []string{"a@example.com"}, "mail.example.com:587", "bot@example.com"},
+		{"http://mail.example.com", "bot@example.com", []string{"a@example.com"}, "", ""},
+		{"smtp:///", "bot@example.com", []string{"a@example.com"}, "", ""},
+		{"smtp://mail.example.com", "bot@example.com", nil, "", ""},
+		{"smtp://mail.example.com", "bot", []string{"a@example.com"}, "", ""},
+	} {
+		m, err := newMailer(test.smtpURL, test.from, test.to)
+		if test.addr == "" {
+			if err == nil {
+				t.Errorf("%q from %q to %q: expected an error", test.smtpURL, test.from, test.to)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.smtpURL, err)
+			continue
+		}
+		if m.addr() != test.addr || m.from != test.sender {
+			t.Errorf("%q: got addr %q and sender %q", test.smtpURL, m.addr(), m.from)
+		}
+		if (m.auth() != nil) != (m.server.User != nil) {
+			t.Errorf("%q: auth is %v", test.smtpURL, m.auth())
+		}
+	}
+}
+
+// fakeSMTP accepts one message on l and sends its DATA on the channel.
+func fakeSMTP(t *testing.T, l net.Listener) <-chan string {
+	t.Helper()
+	data := make(chan string, 1)
+	go func() {
+		defer close(data)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				var msg strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					msg.WriteString(line)
+				}
+				data <- msg.String()
+				reply("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return data
+}
+
+func TestEmailReport(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	data := fakeSMTP(t, l)
+
+	m, err := newMailer("smtp://"+l.Addr().String(), "bot@example.com", []string{"a@example.com", "b@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := crawler{base: "https://example.com/", mailer: m, format: formatHTML}
+	res := results{errs: urlErrors{"https://example.com/gone": {err: errTest}}}
+	if err = c.emailReport(context.Background(), res); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-data
+	for _, want := range []string{
+		"From: bot@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: linkrot: 1 broken links found on https://example.com/\r\n",
+		"Content-Type: text/html; charset=utf-8\r\n",
+		`<a href="https://example.com/gone">`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(msg, "\r\n", ""), "\n") {
+		t.Errorf("message has bare newlines")
+	}
+}
+
+func TestMailerRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	m := &mailer{&url.URL{Scheme: "smtp", Host: addr}, "bot@example.com", []string{"a@example.com"}}
+	if err := m.send(context.Background(), "subject", "text/plain", "body"); err == nil {
+		t.Error("expected an error sending to a closed port")
+	}
+}
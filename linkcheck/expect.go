@@ -0,0 +1,277 @@
+package linkcheck
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// expectCLI runs the linkrot expect subcommand, which checks that URLs
+// respond as a manifest says they should, such as old paths redirecting
+// to new ones after a migration.
+func expectCLI(args []string) error {
+	fl := flag.NewFlagSet("linkrot expect", flag.ContinueOnError)
+	fl.Usage = func() {
+		const usage = `Usage of linkrot expect %s:
+
+linkrot expect [options] <manifest>
+linkrot expect [options] -redirects <file> -base <url>
+
+    linkrot expect requests each URL in a manifest without following
+    redirects and checks that it responds with the expected status and,
+    for redirects, the expected location. The manifest is CSV with rows
+    of url,status[,location] or, if its name ends in .json, a JSON array
+    of {"url", "status", "location"} objects.
+
+    With -redirects, the expectations come from a Netlify _redirects
+    or vercel.json file instead, checked against the site at base.
+    Rules with placeholders, splats, or conditions are skipped.
+
+    It exits 0 if every URL responds as expected.
+
+Options:
+
+`
+		fmt.Fprintf(os.Stderr, usage, getVersion())
+		fl.PrintDefaults()
+	}
+	verbose := fl.Bool("verbose", false, "verbose")
+	crawlers := fl.Int("crawlers", runtime.NumCPU(), "number of concurrent requests")
+	timeout := fl.Duration("timeout", 10*time.Second, "timeout for requesting a URL")
+	userAgent := fl.String("user-agent", chromeUserAgent, "`agent` to send in the User-Agent header")
+	redirects := fl.String("redirects", "", "check the rules in a Netlify _redirects or vercel.json `file`")
+	base := fl.String("base", "", "`URL` of the deployed site to check -redirects against")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	c := &crawler{
+		workers:   *crawlers,
+		Logger:    newLogger(os.Stderr, logFormatText, *verbose),
+		Client:    &http.Client{Timeout: *timeout},
+		userAgent: *userAgent,
+	}
+	var (
+		exps []expectation
+		err  error
+	)
+	switch {
+	case *redirects != "":
+		if *base == "" || fl.NArg() != 0 {
+			fl.Usage()
+			return fmt.Errorf("expect -redirects needs -base and no manifest")
+		}
+		var skipped int
+		if exps, skipped, err = loadRedirectRules(*redirects, *base); err != nil {
+			return err
+		}
+		if skipped > 0 {
+			c.Warn("skipped redirect rules that can't be checked as written", "rules", skipped)
+		}
+		if len(exps) == 0 {
+			return fmt.Errorf("no redirect rules to check in %s", *redirects)
+		}
+	case fl.NArg() != 1:
+		fl.Usage()
+		return fmt.Errorf("expect needs exactly one manifest; got %d", fl.NArg())
+	default:
+		if exps, err = loadExpectations(fl.Arg(0)); err != nil {
+			return err
+		}
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	results := c.expect(ctx, exps)
+	return printExpect(os.Stdout, results)
+}
+
+// expectation is how a URL should respond.
+type expectation struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	// Location is where a redirect should point, if set
+	Location string `json:"location,omitempty"`
+}
+
+func loadExpectations(name string) ([]expectation, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var exps []expectation
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		err = json.NewDecoder(f).Decode(&exps)
+	} else {
+		exps, err = readExpectationsCSV(f)
+	}
+	if err == nil && len(exps) == 0 {
+		err = errors.New("no URLs")
+	}
+	if err == nil {
+		err = checkExpectations(exps)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest %s: %w", name, err)
+	}
+	return exps, nil
+}
+
+// checkExpectations validates exps and makes their locations absolute.
+func checkExpectations(exps []expectation) error {
+	for i, exp := range exps {
+		u, err := url.Parse(exp.URL)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("not an http or https URL: %q", exp.URL)
+		}
+		if exp.Status < 100 || exp.Status > 599 {
+			return fmt.Errorf("%s: bad status %d", exp.URL, exp.Status)
+		}
+		if exp.Location == "" {
+			continue
+		}
+		loc, err := url.Parse(exp.Location)
+		if err != nil {
+			return fmt.Errorf("%s: %w", exp.URL, err)
+		}
+		// Relative and absolute Location headers should compare equal
+		exps[i].Location = u.ResolveReference(loc).String()
+	}
+	return nil
+}
+
+// readExpectationsCSV reads rows of url,status[,location].
+// A header row starting with "url" is skipped.
+func readExpectationsCSV(r io.Reader) ([]expectation, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	var exps []expectation
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return exps, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(exps) == 0 && strings.EqualFold(row[0], "url") {
+			continue
+		}
+		if len(row) < 2 || len(row) > 3 {
+			return nil, fmt.Errorf("line %d: want url,status[,location]; got %d fields", line, len(row))
+		}
+		status, err := strconv.Atoi(strings.TrimSpace(row[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad status %q", line, row[1])
+		}
+		exp := expectation{URL: strings.TrimSpace(row[0]), Status: status}
+		if len(row) == 3 {
+			exp.Location = strings.TrimSpace(row[2])
+		}
+		exps = append(exps, exp)
+	}
+}
+
+// expectResult is how a URL responded compared to its expectation.
+type expectResult struct {
+	expectation
+	// err is nil if the URL responded as expected
+	err error
+}
+
+// expect requests each URL without following redirects
+// and compares the response to its expectation.
+func (c *crawler) expect(ctx context.Context, exps []expectation) []expectResult {
+	cl := *c.Client
+	cl.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	results := make([]expectResult, len(exps))
+	sem := make(chan struct{}, max(c.workers, 1))
+	var wg sync.WaitGroup
+	for i, exp := range exps {
+		wg.Add(1)
+		go func(i int, exp expectation) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = expectResult{exp, c.expectOne(ctx, &cl, exp)}
+		}(i, exp)
+	}
+	wg.Wait()
+	return results
+}
+
+func (c *crawler) expectOne(ctx context.Context, cl *http.Client, exp expectation) error {
+	var (
+		status   int
+		location string
+	)
+	err := requests.
+		URL(exp.URL).
+		UserAgent(c.userAgentFor(exp.URL)).
+		Client(cl).
+		AddValidator(func(res *http.Response) error {
+			status = res.StatusCode
+			if loc, err := res.Location(); err == nil {
+				location = loc.String()
+			}
+			return nil
+		}).
+		Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	c.Debug("checked expectation", "url", exp.URL, "status", status, "location", location)
+	if status != exp.Status {
+		if location != "" {
+			return fmt.Errorf("%w: got %d to %s; want %d", ErrUnexpectedResponse, status, location, exp.Status)
+		}
+		return fmt.Errorf("%w: got %d; want %d", ErrUnexpectedResponse, status, exp.Status)
+	}
+	if exp.Location != "" && location != exp.Location {
+		return fmt.Errorf("%w: redirects to %q; want %s", ErrUnexpectedResponse, location, exp.Location)
+	}
+	return nil
+}
+
+// printExpect writes a line for each result and a summary,
+// and returns an error if any URL did not respond as expected.
+func printExpect(w io.Writer, results []expectResult) error {
+	var ok, mismatched int
+	for _, er := range results {
+		if er.err == nil {
+			ok++
+			fmt.Fprintf(w, "ok: %s\n", er.URL)
+			continue
+		}
+		mismatched++
+		fmt.Fprintf(w, "mismatch: %s: %v\n", er.URL, er.err)
+	}
+	fmt.Fprintf(w, "linkrot: expect ok=%d mismatched=%d\n", ok, mismatched)
+	if mismatched > 0 {
+		return ErrBadLinks
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExpect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/temp":
+			http.Redirect(w, r, "/new", http.StatusFound)
+		case "/wrong":
+			http.Redirect(w, r, "/elsewhere", http.StatusMovedPermanently)
+		case "/new":
+			io.WriteString(w, "new")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	exps, err := readExpectationsCSV(strings.NewReader(`url,status,location
+# migrated paths
+` + ts.URL + `/old,301,/new
+` + ts.URL + `/temp, 301
+` + ts.URL + `/wrong,301,` + ts.URL + `/new
+` + ts.URL + `/new,200
+` + ts.URL + `/gone,410
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = checkExpectations(exps); err != nil {
+		t.Fatal(err)
+	}
+	c := testCrawler("")
+	c.workers = 2
+	results := c.expect(context.Background(), exps)
+	for i, want := range []string{"", "got 302", "redirects to", "", "got 404"} {
+		err := results[i].err
+		if want == "" && err != nil || want != "" &&
+			(!errors.Is(err, ErrUnexpectedResponse) || !strings.Contains(err.Error(), want)) {
+			t.Errorf("%s: got %v; want %q", results[i].URL, err, want)
+		}
+	}
+
+	var buf strings.Builder
+	if err := printExpect(&buf, results); !errors.Is(err, ErrBadLinks) {
+		t.Errorf("printExpect err = %v; want ErrBadLinks", err)
+	}
+	if !strings.HasSuffix(buf.String(), "linkrot: expect ok=2 mismatched=3\n") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestReadExpectationsCSV(t *testing.T) {
+	for _, bad := range []string{
+		"https://example.com/\n",
+		"https://example.com/,moved\n",
+		"https://example.com/,301,/a,extra\n",
+	} {
+		if _, err := readExpectationsCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: want error", bad)
+		}
+	}
+	exps := []expectation{{URL: "/relative", Status: 200}}
+	if err := checkExpectations(exps); err == nil {
+		t.Error("relative URL: want error")
+	}
+}
@@ -0,0 +1,152 @@
+package linkcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// externalCache remembers the results of checking external URLs for ttl,
+// so that repeated runs, including runs against other sites sharing the cache,
+// don't request the same third-party URLs every time.
+type externalCache struct {
+	pageCache
+	ttl time.Duration
+}
+
+type externalEntry struct {
+	Version   int       `json:"version"`
+	Options   string    `json:"options"`
+	URL       string    `json:"url"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+	// Status is the HTTP status for status errors
+	Status int `json:"status,omitempty"`
+	// Kind is "dns", "redirects", or "parked" for errors of those types
+	Kind     string   `json:"kind,omitempty"`
+	IDs      []string `json:"ids,omitempty"`
+	Redirect string   `json:"redirect,omitempty"`
+}
+
+// cachedError is an error loaded from the cache.
+// It unwraps to an error of the original type,
+// so that it is reported and categorized like the original.
+type cachedError struct {
+	msg string
+	err error
+}
+
+func (ce *cachedError) Error() string { return ce.msg }
+func (ce *cachedError) Unwrap() error { return ce.err }
+
+// load returns the cached result for pageurl if it is still fresh.
+func (ec *externalCache) load(pageurl string) (fr fetchResult, ok bool) {
+	if ec == nil {
+		return fr, false
+	}
+	b, err := os.ReadFile(ec.path(pageurl))
+	if err != nil {
+		return fr, false
+	}
+	var ee externalEntry
+	if err = json.Unmarshal(b, &ee); err != nil ||
+		ee.Version != cacheVersion || ee.Options != ec.options || ee.URL != pageurl ||
+		time.Since(ee.CheckedAt) > ec.ttl {
+		return fr, false
+	}
+	fr = fetchResult{url: pageurl, ids: ee.IDs, redirect: ee.Redirect}
+	if ee.Error != "" {
+		fr.err = decodeError(ee.Error, ee.Status, ee.Kind)
+	}
+	return fr, true
+}
+
+// isTemporary reports whether checking fr's URL again might turn out differently,
+// as for timeouts, server errors, throttling, and hosts skipped as unhealthy.
+func isTemporary(fr *fetchResult) bool {
+	if fr.hostFailed || fr.retryAfter > 0 ||
+		fr.status == http.StatusRequestTimeout || fr.status == http.StatusTooManyRequests ||
+		fr.status >= 500 {
+		return true
+	}
+	if fr.err == nil {
+		return false
+	}
+	if errors.Is(fr.err, ErrFlakyDNS) || errors.Is(fr.err, ErrHostUnhealthy) {
+		return true
+	}
+	// Errors that can't be told apart later, like timeouts, aren't cached
+	status, kind := encodeError(fr.err)
+	return status == 0 && kind == ""
+}
+
+// encodeError returns the HTTP status or kind of err
+// that decodeError needs to categorize it like the original.
+func encodeError(err error) (status int, kind string) {
+	if se := new(requests.StatusError); errors.As(err, &se) {
+		return se.StatusCode, ""
+	}
+	switch {
+	case errors.Is(err, ErrFlakyDNS):
+		return 0, "flaky-dns"
+	case errors.As(err, new(*net.DNSError)):
+		return 0, "dns"
+	case errors.Is(err, ErrTooManyRedirects):
+		return 0, "redirects"
+	case errors.Is(err, ErrParkedDomain):
+		return 0, "parked"
+	}
+	return 0, ""
+}
+
+// decodeError returns an error with message msg
+// that unwraps to an error like the one encodeError was given.
+func decodeError(msg string, status int, kind string) error {
+	ce := &cachedError{msg: msg}
+	switch {
+	case status != 0:
+		ce.err = (*requests.StatusError)(&http.Response{StatusCode: status})
+	case kind == "flaky-dns":
+		ce.err = ErrFlakyDNS
+	case kind == "dns":
+		// Only missing hosts are cached
+		ce.err = &net.DNSError{Err: msg, IsNotFound: true}
+	case kind == "redirects":
+		ce.err = ErrTooManyRedirects
+	case kind == "parked":
+		ce.err = ErrParkedDomain
+	}
+	return ce
+}
+
+func (ec *externalCache) save(fr *fetchResult) error {
+	if ec == nil {
+		return nil
+	}
+	// Temporary failures are worth checking again next time
+	if isTemporary(fr) {
+		return nil
+	}
+	ee := externalEntry{
+		Version:   cacheVersion,
+		Options:   ec.options,
+		URL:       fr.url,
+		CheckedAt: time.Now(),
+		IDs:       fr.ids,
+		Redirect:  fr.redirect,
+	}
+	if fr.err != nil {
+		ee.Error = fr.err.Error()
+		ee.Status, ee.Kind = encodeError(fr.err)
+	}
+	b, err := json.Marshal(ee)
+	if err != nil {
+		return err
+	}
+	return ec.write(fr.url, b)
+}
@@ -0,0 +1,89 @@
+package linkcheck
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// feedTypes are the media types of RSS and Atom feeds.
+// Feeds served as generic XML are recognized by looksLikeFeed.
+var feedTypes = []string{
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/xml",
+	"text/xml",
+}
+
+// looksLikeFeed reports whether a response with media type mt
+// and a body starting with head is an RSS or Atom feed.
+func looksLikeFeed(mt string, head []byte) bool {
+	switch mt {
+	case "application/rss+xml", "application/atom+xml":
+		return true
+	case "application/xml", "text/xml":
+		return bytes.Contains(head, []byte("<rss")) ||
+			bytes.Contains(head, []byte("<feed"))
+	}
+	return false
+}
+
+// linksFromFeed returns the <link> and <enclosure> URLs in an RSS or Atom feed,
+// resolved against baseurl.
+func linksFromFeed(baseurl *url.URL, body []byte) (links []string, err error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	// Feeds in the wild are often not well-formed
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	add := func(ref string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return
+		}
+		if link := resolveRef(baseurl, ref); link != "" {
+			links = append(links, link)
+		}
+	}
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return links, nil
+		}
+		if err != nil {
+			return links, err
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch el.Name.Local {
+		case "link":
+			// Atom links use href; RSS links are text
+			if ref, ok := xmlAttr(el, "href"); ok {
+				add(ref)
+				continue
+			}
+			var text string
+			if err = d.DecodeElement(&text, &el); err != nil {
+				return links, err
+			}
+			add(text)
+		case "enclosure":
+			if ref, ok := xmlAttr(el, "url"); ok {
+				add(ref)
+			}
+		}
+	}
+}
+
+func xmlAttr(el xml.StartElement, key string) (string, bool) {
+	for _, a := range el.Attr {
+		if a.Name.Local == key {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,49 @@
+package linkcheck
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestLinksFromFeed(t *testing.T) {
+	base, _ := url.Parse("https://example.com/feed.xml")
+	var testcases = []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"rss", `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+<link>https://example.com/</link>
+<atom:link href="https://example.com/feed.xml" rel="self" xmlns:atom="http://www.w3.org/2005/Atom"/>
+<item>
+	<title>A &amp; B</title>
+	<link> /a.html </link>
+	<description><![CDATA[<a href="/not-a-link">x</a>]]></description>
+	<enclosure url="/episode.mp3" length="1" type="audio/mpeg"/>
+</item>
+</channel></rss>`, []string{
+			"https://example.com/",
+			"https://example.com/feed.xml",
+			"https://example.com/a.html",
+			"https://example.com/episode.mp3",
+		}},
+		{"atom", `<feed xmlns="http://www.w3.org/2005/Atom">
+<link href="/" />
+<entry><title>&nbsp;entry</title><link rel="alternate" href="entry.html"/></entry>
+</feed>`, []string{
+			"https://example.com/",
+			"https://example.com/entry.html",
+		}},
+	}
+	for _, test := range testcases {
+		got, err := linksFromFeed(base, []byte(test.in))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q; want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/carlmjohnson/requests"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// formAction is where a <form> on a page submits to.
+type formAction struct {
+	url     string
+	post    bool
+	context linkContext
+}
+
+// formActions returns the actions of the forms in doc.
+// Forms without an action submit to the page itself, so they're skipped.
+func formActions(pageurl *url.URL, doc *html.Node) []formAction {
+	pageurl = documentBase(pageurl, doc)
+	var actions []formAction
+	visitAll(doc, func(n *html.Node) {
+		if fa, ok := actionOf(pageurl, n); ok {
+			fa.context = linkContext{selector: nodeSelector(n)}
+			actions = append(actions, fa)
+		}
+	})
+	return actions
+}
+
+// actionOf returns where n submits to if it's a <form> with an action.
+func actionOf(pageurl *url.URL, n *html.Node) (formAction, bool) {
+	if n.Type != html.ElementNode || n.DataAtom != atom.Form {
+		return formAction{}, false
+	}
+	action := strings.TrimSpace(attr(n, "action"))
+	method := strings.ToLower(strings.TrimSpace(attr(n, "method")))
+	// Dialog forms close a dialog instead of submitting
+	if action == "" || method == "dialog" {
+		return formAction{}, false
+	}
+	link := resolveRef(pageurl, action)
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return formAction{}, false
+	}
+	return formAction{url: link, post: method == "post"}, true
+}
+
+// postEndpoints are the URLs that forms POST to, which are
+// checked with a HEAD request instead of fetched like pages.
+// URLs that are also linked as pages, such as a contact page
+// that posts to itself, are fetched like pages so their IDs and links are known.
+type postEndpoints struct {
+	mu    sync.Mutex
+	urls  map[string]bool
+	pages map[string]bool
+}
+
+func newPostEndpoints() *postEndpoints {
+	return &postEndpoints{
+		urls:  make(map[string]bool),
+		pages: make(map[string]bool),
+	}
+}
+
+func (pe *postEndpoints) add(link string) {
+	if pe == nil {
+		return
+	}
+	link, err := Normalize(link)
+	if err != nil {
+		return
+	}
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	pe.urls[link] = true
+}
+
+// addResult records the POST endpoints of a page and the URLs it links to as pages.
+func (pe *postEndpoints) addResult(fr *fetchResult) {
+	if pe == nil {
+		return
+	}
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	posts := make(map[string]bool, len(fr.postActions))
+	for _, link := range fr.postActions {
+		if link, err := Normalize(link); err == nil {
+			pe.urls[link] = true
+			posts[link] = true
+		}
+	}
+	for _, link := range fr.links {
+		if link, err := Normalize(link); err == nil && !posts[link] {
+			pe.pages[link] = true
+		}
+	}
+}
+
+// has reports whether link should only be checked as a POST endpoint.
+func (pe *postEndpoints) has(link string) bool {
+	if pe == nil {
+		return false
+	}
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return pe.urls[link] && !pe.pages[link]
+}
+
+// addFormActions adds the form actions on a page to its links.
+// GET forms are checked like links; POST endpoints are only checked to exist,
+// unless the page also links to them.
+func (c *crawler) addFormActions(fr *fetchResult, links []string, actions []formAction) []string {
+	linked := make(map[string]bool, len(links))
+	for _, link := range links {
+		linked[link] = true
+	}
+	for _, fa := range actions {
+		if fa.post && !linked[fa.url] {
+			fr.postActions = append(fr.postActions, fa.url)
+		}
+		// Tokenized pages have no contexts
+		if _, ok := fr.contexts[fa.url]; !ok && fr.contexts != nil {
+			fr.contexts[fa.url] = fa.context
+		}
+		links = append(links, fa.url)
+	}
+	return links
+}
+
+// checkPostEndpoint makes a HEAD request to a form's POST endpoint.
+// Many endpoints reject anything but a POST, so only 404 and 410
+// and hosts that don't exist are reported, unless in strict mode.
+// If an internal endpoint turns out to be an HTML page,
+// isPage is true and it should be fetched like one.
+func (c *crawler) checkPostEndpoint(ctx context.Context, fr *fetchResult) (isPage bool, err error) {
+	err = requests.
+		URL(c.requestURL(fr.url)).
+		Head().
+		UserAgent(c.userAgentFor(fr.url)).
+		Client(c.Client).
+		AddValidator(func(res *http.Response) error {
+			fr.status = res.StatusCode
+			if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+				return requests.CheckStatus(http.StatusOK)(res)
+			}
+			mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+			isPage = res.StatusCode == http.StatusOK && mediaType == "text/html" &&
+				c.shouldGetLinks(fr.url)
+			// Anything else, like 405 Method Not Allowed, means something is there
+			return nil
+		}).
+		Fetch(ctx)
+	if err == nil || requests.HasStatusErr(err, http.StatusNotFound, http.StatusGone) {
+		return isPage && err == nil, err
+	}
+	if d := new(net.DNSError); errors.As(err, &d) && d.IsNotFound {
+		return false, err
+	}
+	if c.strict {
+		return false, err
+	}
+	c.Info("ignoring error", "url", fr.url, "error", err)
+	return false, nil
+}
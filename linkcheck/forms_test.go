@@ -0,0 +1,124 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestFormActions(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body>
+<form id="search" action="/search"><input name="q"></form>
+<form action="subscribe" method="POST"></form>
+<form method="post"></form>
+<form action="/close" method="dialog"></form>
+<form action="mailto:tips@example.com"></form>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/news/")
+	got := formActions(base, doc)
+	want := []formAction{
+		{"https://example.com/search", false, linkContext{selector: "#search"}},
+		{"https://example.com/news/subscribe", true, linkContext{selector: "form:nth-child(2)"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestCheckForms(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		posted []string
+	)
+	site, c, pages := crawlSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<body><form action="/search"></form><form action="/old-search"></form>
+<form action="/subscribe" method="post"></form><form action="/contact" method="post"></form>`)
+		case "/search":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<body><p>results`)
+		case "/subscribe":
+			mu.Lock()
+			posted = append(posted, r.Method)
+			mu.Unlock()
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", "POST")
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		default:
+			mu.Lock()
+			posted = append(posted, r.Method)
+			mu.Unlock()
+			http.NotFound(w, r)
+		}
+	}), func(c *crawler) {
+		c.strict = true
+		c.checkForms = true
+	})
+	errs := pages.toURLErrors(c.scope(), true)
+	if len(errs) != 2 || errs[site+"/old-search"] == nil || errs[site+"/contact"] == nil {
+		t.Errorf("want errors for /old-search and /contact; got %v", errs)
+	}
+	slices.Sort(posted)
+	if want := []string{"GET", "HEAD", "HEAD"}; !reflect.DeepEqual(posted, want) {
+		t.Errorf("got requests %v; want %v", posted, want)
+	}
+}
+
+func TestCheckFormsSelfPost(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		methods = make(map[string][]string)
+	)
+	site, c, pages := crawlSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			io.WriteString(w, `<body><form action="/contact" method="post"></form>
+<a href="/about">about</a><form action="/feedback" method="post"></form>`)
+		case "/about", "/hidden":
+			io.WriteString(w, `<body><a href="/contact#form">contact us</a>`)
+		case "/contact":
+			mu.Lock()
+			methods[r.URL.Path] = append(methods[r.URL.Path], r.Method)
+			mu.Unlock()
+			io.WriteString(w, `<body><form id="form" action="/contact" method="post"></form>`)
+		case "/feedback":
+			// Only linked by a POST form, but a page all the same
+			mu.Lock()
+			methods[r.URL.Path] = append(methods[r.URL.Path], r.Method)
+			mu.Unlock()
+			io.WriteString(w, `<body><a href="/about#top">about</a><a href="/hidden">hidden</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}), func(c *crawler) {
+		c.strict = true
+		c.checkForms = true
+	})
+	// The contact page is linked as a page, so its IDs are known
+	if errs := pages.toURLErrors(c.scope(), true); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if _, ok := pages[site+"/hidden"]; !ok {
+		t.Error("links on /feedback weren't followed")
+	}
+	// /contact is only checked with HEAD if it's reached before /about
+	if got := methods["/contact"]; len(got) == 0 || got[len(got)-1] != "GET" {
+		t.Errorf("got requests for /contact %v; want it fetched as a page", got)
+	}
+	if got, want := methods["/feedback"], []string{"HEAD", "GET"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests for /feedback %v; want %v", got, want)
+	}
+}
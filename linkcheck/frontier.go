@@ -0,0 +1,384 @@
+package linkcheck
+
+import (
+	"bufio"
+	"hash/maphash"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+)
+
+// frontier holds the URLs waiting to be crawled.
+type frontier interface {
+	empty() bool
+	head() string
+	pophead()
+	// add queues a link found depth links away from the base URL
+	add(link string, depth int)
+	addLow(link string, depth int)
+	// depth is how deep a queued URL was found, if the frontier knows
+	depth(link string) int
+	// len is about how many URLs are waiting
+	len() int
+	close() error
+}
+
+func (q *queue) close() error { return nil }
+
+func (q *queue) len() int {
+	return q.n
+}
+
+// spillChunk is how many URLs a spillList keeps in memory before writing to disk.
+const spillChunk = 10_000
+
+// diskQueue is a frontier for crawls too large to track in memory.
+// Pending URLs are spilled to files in a directory,
+// and seen URLs are tracked with a bloom filter instead of a map.
+//
+// Unlike queue, a URL in the low priority lane is not promoted
+// when it is later added to the normal lane, a URL keeps the depth
+// it was first found at, and a small fraction of URLs may be wrongly
+// skipped as already seen.
+type diskQueue struct {
+	seen *bloomFilter
+	// lanes are drained in order: internal, external, then low priority
+	q, external, low *depthLanes
+	// popped are the depths of internal pages handed out
+	// whose links haven't been queued yet
+	popped map[string]int
+	// internal reports whether a link is crawled rather than just checked;
+	// if it's nil, every link is internal
+	internal func(link string) bool
+	*slog.Logger
+}
+
+func newDiskQueue(dir string, capacity int, url string, internal func(link string) bool, l *slog.Logger) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	dq := &diskQueue{
+		seen:     newBloomFilter(capacity, 1e-6),
+		q:        &depthLanes{dir: dir, Logger: l},
+		external: &depthLanes{dir: dir, Logger: l},
+		low:      &depthLanes{dir: dir, Logger: l},
+		popped:   make(map[string]int),
+		internal: internal,
+		Logger:   l,
+	}
+	dq.seen.add(url)
+	if err := dq.q.push(url, 0); err != nil {
+		return nil, err
+	}
+	return dq, nil
+}
+
+// lane returns the first lane with URLs waiting.
+func (dq *diskQueue) lane() *depthLanes {
+	for _, dl := range []*depthLanes{dq.q, dq.external} {
+		if dl.len() > 0 {
+			return dl
+		}
+	}
+	return dq.low
+}
+
+func (dq *diskQueue) empty() bool {
+	return dq.len() == 0
+}
+
+func (dq *diskQueue) head() string {
+	link, _ := dq.lane().head()
+	return link
+}
+
+func (dq *diskQueue) pophead() {
+	dl := dq.lane()
+	link, depth := dl.head()
+	dl.pop()
+	// Only internal pages have their links queued
+	if dl == dq.q && link != "" {
+		dq.popped[link] = depth
+	}
+}
+
+func (dq *diskQueue) add(link string, depth int) {
+	if dq.internal != nil && !dq.internal(link) {
+		dq.push(dq.external, link, depth)
+		return
+	}
+	dq.push(dq.q, link, depth)
+}
+
+func (dq *diskQueue) addLow(link string, depth int) {
+	dq.push(dq.low, link, depth)
+}
+
+// depth returns the depth of a page handed out from the internal lane.
+// It forgets the page, so it's only known until its links are queued.
+func (dq *diskQueue) depth(link string) int {
+	depth := dq.popped[link]
+	delete(dq.popped, link)
+	return depth
+}
+
+func (dq *diskQueue) push(dl *depthLanes, link string, depth int) {
+	link, err := Normalize(link)
+	if err != nil {
+		return
+	}
+	if dq.seen.has(link) {
+		return
+	}
+	dq.seen.add(link)
+	if err = dl.push(link, depth); err != nil {
+		dq.Error("could not queue URL", "url", link, "error", err)
+	}
+}
+
+func (dq *diskQueue) len() int {
+	return dq.q.len() + dq.external.len() + dq.low.len()
+}
+
+func (dq *diskQueue) close() error {
+	var err error
+	for _, dl := range []*depthLanes{dq.q, dq.external, dq.low} {
+		if err1 := dl.close(); err == nil {
+			err = err1
+		}
+	}
+	return err
+}
+
+// depthLanes is a lane of a diskQueue with a spillList for each depth,
+// so URLs come out closest first and in FIFO order within a depth.
+// Lists are created as URLs are found at each depth,
+// so lists is nil at depths with none yet.
+type depthLanes struct {
+	dir   string
+	lists []*spillList
+	*slog.Logger
+}
+
+func (dl *depthLanes) push(link string, depth int) error {
+	for len(dl.lists) <= depth {
+		dl.lists = append(dl.lists, nil)
+	}
+	if dl.lists[depth] == nil {
+		sl, err := newSpillList(dl.dir, dl.Logger)
+		if err != nil {
+			return err
+		}
+		dl.lists[depth] = sl
+	}
+	dl.lists[depth].push(link)
+	return nil
+}
+
+// first returns the shallowest list with URLs waiting and its depth,
+// or nil if there are none.
+func (dl *depthLanes) first() (*spillList, int) {
+	for depth, sl := range dl.lists {
+		if sl != nil && sl.len() > 0 {
+			return sl, depth
+		}
+	}
+	return nil, 0
+}
+
+func (dl *depthLanes) head() (string, int) {
+	sl, depth := dl.first()
+	if sl == nil {
+		return "", 0
+	}
+	return sl.head(), depth
+}
+
+func (dl *depthLanes) pop() {
+	if sl, _ := dl.first(); sl != nil {
+		sl.pop()
+	}
+}
+
+func (dl *depthLanes) len() int {
+	n := 0
+	for _, sl := range dl.lists {
+		if sl != nil {
+			n += sl.len()
+		}
+	}
+	return n
+}
+
+func (dl *depthLanes) close() error {
+	var err error
+	for _, sl := range dl.lists {
+		if sl == nil {
+			continue
+		}
+		if err1 := sl.close(); err == nil {
+			err = err1
+		}
+	}
+	return err
+}
+
+// spillList is a FIFO of strings that keeps at most
+// about 2*spillChunk entries in memory and the rest in a temporary file.
+type spillList struct {
+	f *os.File
+	w *bufio.Writer
+	r *bufio.Reader
+	// front is read back from disk, back is waiting to be written
+	front, back []string
+	onDisk      int
+	*slog.Logger
+}
+
+func newSpillList(dir string, l *slog.Logger) (*spillList, error) {
+	f, err := os.CreateTemp(dir, "frontier-*.txt")
+	if err != nil {
+		return nil, err
+	}
+	// Reads keep their own offset with a section reader over the same file
+	return &spillList{
+		f:      f,
+		w:      bufio.NewWriter(f),
+		r:      bufio.NewReader(io.NewSectionReader(f, 0, math.MaxInt64)),
+		Logger: l,
+	}, nil
+}
+
+func (sl *spillList) len() int {
+	return len(sl.front) + sl.onDisk + len(sl.back)
+}
+
+func (sl *spillList) push(s string) {
+	sl.back = append(sl.back, s)
+	if len(sl.back) < spillChunk {
+		return
+	}
+	if err := sl.spill(); err != nil {
+		sl.Warn("could not spill crawl queue to disk, keeping it in memory", "error", err)
+	}
+}
+
+// spill writes back to the end of the file.
+// Everything on disk is ahead of back, so FIFO order is kept.
+func (sl *spillList) spill() error {
+	for _, s := range sl.back {
+		if _, err := sl.w.WriteString(s + "\n"); err != nil {
+			return err
+		}
+	}
+	if err := sl.w.Flush(); err != nil {
+		return err
+	}
+	sl.onDisk += len(sl.back)
+	sl.back = nil
+	return nil
+}
+
+// fill reads the next chunk from disk into front, if front is empty.
+func (sl *spillList) fill() {
+	if len(sl.front) > 0 || sl.onDisk == 0 {
+		return
+	}
+	for len(sl.front) < spillChunk && sl.onDisk > 0 {
+		line, err := sl.r.ReadString('\n')
+		if err != nil {
+			sl.Error("lost queued URLs reading crawl queue from disk", "count", sl.onDisk, "error", err)
+			sl.onDisk = 0
+			return
+		}
+		sl.front = append(sl.front, strings.TrimSuffix(line, "\n"))
+		sl.onDisk--
+	}
+}
+
+func (sl *spillList) head() string {
+	sl.fill()
+	switch {
+	case len(sl.front) > 0:
+		return sl.front[0]
+	case len(sl.back) > 0:
+		return sl.back[0]
+	}
+	return ""
+}
+
+func (sl *spillList) pop() {
+	sl.fill()
+	switch {
+	case len(sl.front) > 0:
+		sl.front = sl.front[1:]
+	case len(sl.back) > 0:
+		sl.back = sl.back[1:]
+	}
+}
+
+func (sl *spillList) close() error {
+	err := sl.f.Close()
+	if err1 := os.Remove(sl.f.Name()); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// bloomFilter is a probabilistic set that may report false positives
+// but never false negatives.
+type bloomFilter struct {
+	bits         []uint64
+	k            uint64
+	seed1, seed2 maphash.Seed
+}
+
+// newBloomFilter sizes a filter for n items with false positive rate p.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/float64(n)*math.Ln2))
+	return &bloomFilter{
+		bits:  make([]uint64, (uint64(m)+63)/64),
+		k:     uint64(k),
+		seed1: maphash.MakeSeed(),
+		seed2: maphash.MakeSeed(),
+	}
+}
+
+// locations uses double hashing to derive k bit positions for s
+// and calls f with each until it returns false.
+func (bf *bloomFilter) locations(s string, f func(word, mask uint64) bool) bool {
+	var h maphash.Hash
+	h.SetSeed(bf.seed1)
+	h.WriteString(s)
+	h1 := h.Sum64()
+	h.SetSeed(bf.seed2)
+	h.WriteString(s)
+	h2 := h.Sum64() | 1
+	nbits := uint64(len(bf.bits)) * 64
+	for i := uint64(0); i < bf.k; i++ {
+		bit := (h1 + i*h2) % nbits
+		if !f(bit/64, 1<<(bit%64)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (bf *bloomFilter) add(s string) {
+	bf.locations(s, func(word, mask uint64) bool {
+		bf.bits[word] |= mask
+		return true
+	})
+}
+
+func (bf *bloomFilter) has(s string) bool {
+	return bf.locations(s, func(word, mask uint64) bool {
+		return bf.bits[word]&mask != 0
+	})
+}
@@ -0,0 +1,136 @@
+package linkcheck
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiskQueue(t *testing.T) {
+	dir := t.TempDir()
+	dq, err := newDiskQueue(dir, 100_000, "http://example.com/", nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 2*spillChunk + 500
+	dq.addLow("http://other.example/low", 1)
+	for i := 0; i < n; i++ {
+		dq.add(fmt.Sprintf("http://example.com/%d", i), 1)
+		// duplicates are dropped
+		dq.add(fmt.Sprintf("http://example.com/%d#frag", i), 1)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 3 {
+		t.Fatalf("expected 3 spill files; got %d", len(files))
+	}
+
+	want := []string{"http://example.com/"}
+	for i := 0; i < n; i++ {
+		want = append(want, fmt.Sprintf("http://example.com/%d", i))
+	}
+	want = append(want, "http://other.example/low")
+	var got []string
+	for !dq.empty() {
+		got = append(got, dq.head())
+		dq.pophead()
+		if len(got) == n/2 {
+			// adding while draining keeps FIFO order
+			dq.add("http://example.com/late", 1)
+			want = append(want[:len(want)-1], "http://example.com/late", "http://other.example/low")
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d URLs; want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("item %d: got %q; want %q", i, got[i], want[i])
+		}
+	}
+	if err = dq.close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("spill files not cleaned up: %d", len(files))
+	}
+}
+
+func TestDiskQueueExternalLast(t *testing.T) {
+	internal := func(link string) bool { return strings.HasPrefix(link, "http://example.com/") }
+	dq, err := newDiskQueue(t.TempDir(), 1000, "http://example.com/", internal, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dq.close()
+	dq.addLow("http://stale.example/", 1)
+	dq.add("http://other.example/", 1)
+	dq.add("http://example.com/a", 1)
+	var got []string
+	for !dq.empty() {
+		got = append(got, dq.head())
+		dq.pophead()
+	}
+	want := []string{"http://example.com/", "http://example.com/a", "http://other.example/", "http://stale.example/"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	const n = 10_000
+	bf := newBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		bf.add(fmt.Sprint(i))
+	}
+	for i := 0; i < n; i++ {
+		if !bf.has(fmt.Sprint(i)) {
+			t.Fatalf("false negative for %d", i)
+		}
+	}
+	falsePositives := 0
+	for i := n; i < 2*n; i++ {
+		if bf.has(fmt.Sprint(i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > n/50 {
+		t.Errorf("too many false positives: %d of %d", falsePositives, n)
+	}
+}
+
+func TestDiskQueueDepth(t *testing.T) {
+	dq, err := newDiskQueue(t.TempDir(), 1000, "http://example.com/", nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dq.close()
+	dq.pophead()
+	if d := dq.depth("http://example.com/"); d != 0 {
+		t.Errorf("root depth = %d; want 0", d)
+	}
+	dq.add("http://example.com/deep", 3)
+	dq.add("http://example.com/a", 1)
+	dq.add("http://example.com/b", 2)
+	dq.add("http://example.com/c", 1)
+	depths := make(map[string]int)
+	var got []string
+	for !dq.empty() {
+		link := dq.head()
+		dq.pophead()
+		got = append(got, link)
+		depths[link] = dq.depth(link)
+	}
+	want := []string{"http://example.com/a", "http://example.com/c", "http://example.com/b", "http://example.com/deep"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q; want closest first %q", got, want)
+	}
+	if depths["http://example.com/c"] != 1 || depths["http://example.com/deep"] != 3 {
+		t.Errorf("got depths %v", depths)
+	}
+	if len(dq.popped) != 0 {
+		t.Errorf("depths not forgotten: %v", dq.popped)
+	}
+}
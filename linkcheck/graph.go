@@ -0,0 +1,149 @@
+package linkcheck
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/carlmjohnson/errutil"
+)
+
+// Link graph formats for -graph, chosen by file extension
+const (
+	graphDOT     = "dot"
+	graphGraphML = "graphml"
+)
+
+// graphFormat returns the link graph format for a -graph file name.
+func graphFormat(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".dot", ".gv":
+		return graphDOT, nil
+	case ".graphml":
+		return graphGraphML, nil
+	}
+	return "", fmt.Errorf("unknown link graph format for %q: want .dot, .gv, or .graphml", name)
+}
+
+// linkGraph is the graph of links between internal pages.
+type linkGraph struct {
+	// nodes are sorted page URLs
+	nodes []string
+	// broken are nodes that could not be fetched
+	broken map[string]bool
+	// edges maps each page to its sorted link targets
+	edges map[string][]string
+}
+
+// toLinkGraph builds the graph of internal pages in sc and the links among them.
+func (cp crawledPages) toLinkGraph(sc scope) linkGraph {
+	g := linkGraph{
+		broken: make(map[string]bool),
+		edges:  make(map[string][]string),
+	}
+	for page, pi := range cp {
+		if !sc.contains(page) || pi.unchecked {
+			continue
+		}
+		g.nodes = append(g.nodes, page)
+		if pi.err != nil {
+			g.broken[page] = true
+		}
+		seen := make(map[string]bool)
+		for link := range pi.links {
+			target, err := Normalize(link)
+			if err != nil || target == page || seen[target] || !sc.contains(target) {
+				continue
+			}
+			if ti, ok := cp[target]; !ok || ti.unchecked {
+				continue
+			}
+			seen[target] = true
+			g.edges[page] = append(g.edges[page], target)
+		}
+		sort.Strings(g.edges[page])
+	}
+	sort.Strings(g.nodes)
+	return g
+}
+
+// inbound counts the links to each page in the graph.
+func (g linkGraph) inbound() map[string]int {
+	inbound := make(map[string]int)
+	for _, targets := range g.edges {
+		for _, target := range targets {
+			inbound[target]++
+		}
+	}
+	return inbound
+}
+
+// writeDOT writes the graph in Graphviz DOT format.
+func (g linkGraph) writeDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph linkrot {")
+	for _, node := range g.nodes {
+		attrs := ""
+		if g.broken[node] {
+			attrs = " [color=red]"
+		}
+		fmt.Fprintf(bw, "\t%s%s;\n", strconv.Quote(node), attrs)
+	}
+	for _, from := range g.nodes {
+		for _, to := range g.edges[from] {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", strconv.Quote(from), strconv.Quote(to))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// writeGraphML writes the graph in GraphML format.
+func (g linkGraph) writeGraphML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	escape := func(s string) string {
+		var sb strings.Builder
+		xml.EscapeText(&sb, []byte(s))
+		return sb.String()
+	}
+	fmt.Fprint(bw, xml.Header)
+	fmt.Fprintln(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(bw, `  <key id="broken" for="node" attr.name="broken" attr.type="boolean"/>`)
+	fmt.Fprintln(bw, `  <graph id="linkrot" edgedefault="directed">`)
+	for _, node := range g.nodes {
+		fmt.Fprintf(bw, "    <node id=\"%s\"><data key=\"broken\">%t</data></node>\n",
+			escape(node), g.broken[node])
+	}
+	for _, from := range g.nodes {
+		for _, to := range g.edges[from] {
+			fmt.Fprintf(bw, "    <edge source=\"%s\" target=\"%s\"/>\n", escape(from), escape(to))
+		}
+	}
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+	return bw.Flush()
+}
+
+// saveGraph writes the internal link graph to the -graph file.
+func (c *crawler) saveGraph(pages crawledPages) (err error) {
+	format, err := graphFormat(c.graphFile)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(c.graphFile)
+	if err != nil {
+		return err
+	}
+	defer errutil.Defer(&err, f.Close)
+	g := pages.toLinkGraph(c.scope())
+	if format == graphGraphML {
+		return g.writeGraphML(f)
+	}
+	return g.writeDOT(f)
+}
@@ -0,0 +1,63 @@
+package linkcheck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLinkGraph(t *testing.T) {
+	pages := crawledPages{
+		"https://example.com/": {links: map[string]linkContext{
+			"https://example.com/a#top":    {},
+			"https://example.com/a":        {},
+			"https://example.com/missing":  {},
+			"https://other.example.net/":   {},
+			"https://example.com/":         {},
+			"https://example.com/?a=1&b=2": {},
+		}},
+		"https://example.com/a":        {links: map[string]linkContext{"https://example.com/": {}}},
+		"https://example.com/missing":  {err: errors.New("404")},
+		"https://example.com/?a=1&b=2": {},
+		"https://other.example.net/":   {},
+	}
+	g := pages.toLinkGraph(scope{base: "https://example.com/"})
+
+	var dot strings.Builder
+	if err := g.writeDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph linkrot {
+	"https://example.com/";
+	"https://example.com/?a=1&b=2";
+	"https://example.com/a";
+	"https://example.com/missing" [color=red];
+	"https://example.com/" -> "https://example.com/?a=1&b=2";
+	"https://example.com/" -> "https://example.com/a";
+	"https://example.com/" -> "https://example.com/missing";
+	"https://example.com/a" -> "https://example.com/";
+}
+`
+	if dot.String() != want {
+		t.Errorf("got DOT:\n%s\nwant:\n%s", dot.String(), want)
+	}
+
+	var graphml strings.Builder
+	if err := g.writeGraphML(&graphml); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`<node id="https://example.com/missing"><data key="broken">true</data></node>`,
+		`<edge source="https://example.com/" target="https://example.com/?a=1&amp;b=2"/>`,
+	} {
+		if !strings.Contains(graphml.String(), s) {
+			t.Errorf("GraphML missing %s:\n%s", s, graphml.String())
+		}
+	}
+
+	for name, ok := range map[string]bool{"site.dot": true, "site.GV": true, "site.graphml": true, "site.png": false} {
+		if _, err := graphFormat(name); (err == nil) != ok {
+			t.Errorf("graphFormat(%q) err = %v", name, err)
+		}
+	}
+}
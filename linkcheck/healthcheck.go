@@ -0,0 +1,65 @@
+package linkcheck
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// healthcheckCLI runs the linkrot healthcheck subcommand, which checks
+// a single URL without recursing, for use by container HEALTHCHECKs
+// and uptime probes. It exits 0 if the page is healthy and 1 otherwise.
+func healthcheckCLI(args []string) error {
+	fl := flag.NewFlagSet("linkrot healthcheck", flag.ContinueOnError)
+	fl.Usage = func() {
+		const usage = `Usage of linkrot healthcheck %s:
+
+linkrot healthcheck [options] <url>
+
+    linkrot healthcheck fetches a single URL and checks that it returns
+    HTTP 200 with parseable HTML, without following any links.
+    It exits 0 if the page is healthy and 1 otherwise.
+
+Options:
+
+`
+		fmt.Fprintf(os.Stderr, usage, getVersion())
+		fl.PrintDefaults()
+	}
+	verbose := fl.Bool("verbose", false, "verbose")
+	timeout := fl.Duration("timeout", 5*time.Second, "total time budget for the check")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	if fl.NArg() != 1 {
+		fl.Usage()
+		return fmt.Errorf("healthcheck needs exactly one URL; got %d", fl.NArg())
+	}
+	pageurl := fl.Arg(0)
+
+	logger := newLogger(os.Stderr, logFormatText, *verbose)
+	c := &crawler{
+		base:      pageurl,
+		workers:   1,
+		Logger:    logger,
+		Client:    &http.Client{Timeout: *timeout},
+		userAgent: chromeUserAgent,
+		strict:    true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	start := time.Now()
+	fr := c.fetch(ctx, pageurl)
+	duration := time.Since(start).Round(time.Millisecond)
+	if fr.err != nil {
+		fmt.Printf("linkrot: status=unhealthy url=%s duration=%s error=%q\n",
+			pageurl, duration, fr.err)
+		return fr.err
+	}
+	fmt.Printf("linkrot: status=healthy url=%s duration=%s\n", pageurl, duration)
+	return nil
+}
@@ -0,0 +1,65 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what f prints to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	b, _ := io.ReadAll(r)
+	return string(b)
+}
+
+func TestHealthcheckCLI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<a href="/missing">not followed</a>`)
+		case "/data.bin":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0, 1, 2})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	for _, test := range []struct {
+		path, status string
+	}{
+		{"/", "status=healthy"},
+		{"/missing", "status=unhealthy"},
+		{"/data.bin", "status=unhealthy"},
+	} {
+		var err error
+		out := captureStdout(t, func() {
+			err = healthcheckCLI([]string{ts.URL + test.path})
+		})
+		if healthy := test.status == "status=healthy"; (err == nil) != healthy {
+			t.Errorf("%s: got error %v", test.path, err)
+		}
+		if !strings.HasPrefix(out, "linkrot: "+test.status+" url="+ts.URL+test.path+" ") {
+			t.Errorf("%s: got output %q", test.path, out)
+		}
+	}
+
+	if err := healthcheckCLI(nil); err == nil {
+		t.Error("expected an error without a URL")
+	}
+}
@@ -0,0 +1,54 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// variantOrigin returns the root URL of link's origin,
+// such as http://www.example.com/ for http://www.example.com/about.
+func variantOrigin(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	origin, err := Normalize((&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}).String())
+	if err != nil {
+		return ""
+	}
+	return origin
+}
+
+// isVariantOrigin reports whether link is the root of
+// an http/https or www/apex variant of the base URL's origin.
+func (s scope) isVariantOrigin(link string) bool {
+	_, ok := s.canonicalHost(link)
+	return ok && variantOrigin(link) == link
+}
+
+// checkVariantOrigin requests the root of a variant of the base URL's origin.
+// Links to the variant are checked under the base origin instead,
+// so this is where a variant that doesn't resolve, has a bad certificate,
+// or serves http without redirecting to https gets caught.
+// Unlike other pages, every failure is reported.
+func (c *crawler) checkVariantOrigin(ctx context.Context, fr *fetchResult) error {
+	var final *url.URL
+	err := c.request(fr.url, c.userAgentFor(fr.url)).
+		AddValidator(func(res *http.Response) error {
+			fr.status = res.StatusCode
+			final = res.Request.URL
+			return nil
+		}).
+		CheckStatus(http.StatusOK).
+		Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(c.base, "https:") && final.Scheme == "http" {
+		return fmt.Errorf("%w: ended at %s", ErrNoHTTPSRedirect, final)
+	}
+	return nil
+}
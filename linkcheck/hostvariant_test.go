@@ -0,0 +1,85 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestVariantOriginLinks(t *testing.T) {
+	c := crawler{base: "https://example.com/", Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	fr := &fetchResult{contexts: map[string]linkContext{
+		"http://www.example.com/about": {text: "About"},
+	}}
+	c.addLinks(fr, c.base, []string{"http://www.example.com/about", "https://example.com/contact"})
+	want := []string{"http://www.example.com/", "https://example.com/about", "https://example.com/contact"}
+	if !slices.Equal(fr.links, want) {
+		t.Errorf("got links %q; want %q", fr.links, want)
+	}
+	if fr.contexts["http://www.example.com/"].text != "About" {
+		t.Errorf("variant origin lost its context: %+v", fr.contexts)
+	}
+	sc := c.scope()
+	for link, want := range map[string]bool{
+		"http://www.example.com/":      true,
+		"https://www.example.com/":     true,
+		"http://example.com/":          true,
+		"https://example.com/":         false,
+		"http://www.example.com/about": false,
+		"http://other.example/":        false,
+	} {
+		if got := sc.isVariantOrigin(link); got != want {
+			t.Errorf("isVariantOrigin(%q) = %v", link, got)
+		}
+	}
+}
+
+func TestCheckVariantOrigin(t *testing.T) {
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<p>hi")
+	}))
+	defer secure.Close()
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect/":
+			http.Redirect(w, r, secure.URL+"/", http.StatusMovedPermanently)
+		case "/gone/":
+			http.NotFound(w, r)
+		default:
+			io.WriteString(w, "<p>hi")
+		}
+	}))
+	defer plain.Close()
+
+	for _, test := range []struct {
+		name, url string
+		client    *http.Client
+		ok        bool
+	}{
+		{"redirects to https", plain.URL + "/redirect/", secure.Client(), true},
+		{"serves http", plain.URL + "/", secure.Client(), false},
+		{"error status", plain.URL + "/gone/", secure.Client(), false},
+		{"https", secure.URL + "/", secure.Client(), true},
+		{"bad certificate", secure.URL + "/", http.DefaultClient, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := crawler{
+				base:      "https://example.com/",
+				Client:    test.client,
+				userAgent: chromeUserAgent,
+			}
+			err := c.checkVariantOrigin(context.Background(), &fetchResult{url: test.url})
+			if (err == nil) != test.ok {
+				t.Errorf("got %v", err)
+			}
+			if test.name == "serves http" && !errors.Is(err, ErrNoHTTPSRedirect) {
+				t.Errorf("got %v; want ErrNoHTTPSRedirect", err)
+			}
+		})
+	}
+}
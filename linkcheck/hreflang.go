@@ -0,0 +1,88 @@
+package linkcheck
+
+import (
+	"fmt"
+	"sort"
+)
+
+// hreflangAlternates returns the hreflang translations of a page
+// with the same canonical URLs as the links that are queued,
+// so they can be matched against crawled pages.
+func (c *crawler) hreflangAlternates(variants localeVariants) localeVariants {
+	if len(variants) == 0 {
+		return nil
+	}
+	alternates := make(localeVariants, len(variants))
+	for lang, link := range variants {
+		alternates[lang] = c.queuedLink(link)
+	}
+	return alternates
+}
+
+// queuedLink rewrites link the way addLinks does before it's queued,
+// and normalizes it like the queue does, without the findings.
+func (c *crawler) queuedLink(link string) string {
+	if canonical, ok := c.scope().canonicalHost(link); ok {
+		link = canonical
+	}
+	link = c.stripToken(c.canonicalLink(link))
+	if normalized, err := Normalize(link); err == nil {
+		link = normalized
+	}
+	return link
+}
+
+// includes reports whether link is one of the variants.
+func (lv localeVariants) includes(link string) bool {
+	for _, variant := range lv {
+		if variant == link {
+			return true
+		}
+	}
+	return false
+}
+
+// markOneWayHreflang adds findings to pages whose hreflang alternates
+// redirect or don't list them in return. Search engines ignore annotations
+// that aren't reciprocal or that point at redirects. Broken alternates
+// are already reported as broken links, and alternates that weren't parsed,
+// such as external pages, can't be checked.
+func (cp crawledPages) markOneWayHreflang() {
+	for page, pi := range cp {
+		if len(pi.hreflang) == 0 {
+			continue
+		}
+		langs := make([]string, 0, len(pi.hreflang))
+		for lang := range pi.hreflang {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+		for _, lang := range langs {
+			alternate := pi.hreflang[lang]
+			if alternate == page {
+				continue
+			}
+			ai, ok := cp[alternate]
+			if !ok || ai.err != nil || ai.unchecked {
+				continue
+			}
+			if ai.redirect != "" {
+				pi.findings = append(pi.findings, finding{
+					categoryHreflangRedirect,
+					fmt.Sprintf("hreflang %s alternate %s redirects to %s", lang, alternate, ai.redirect),
+				})
+				continue
+			}
+			if ai.contentHash == "" {
+				continue
+			}
+			if !ai.hreflang.includes(page) {
+				pi.findings = append(pi.findings, finding{
+					categoryOneWayHreflang,
+					fmt.Sprintf("hreflang %s alternate %s doesn't link back", lang, alternate),
+				})
+			}
+		}
+		cp[page] = pi
+	}
+}
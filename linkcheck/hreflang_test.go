@@ -0,0 +1,70 @@
+package linkcheck
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestOneWayHreflang(t *testing.T) {
+	en := localeVariants{"en": "/", "es": "/es/"}
+	cp := crawledPages{
+		"/": {
+			hreflang:    localeVariants{"en": "/", "es": "/es/", "fr": "/fr/", "de": "/de/"},
+			contentHash: "en",
+		},
+		"/es/": {hreflang: en, contentHash: "es"},
+		"/fr/": {contentHash: "fr"},
+		"/de/": {err: errTest},
+	}
+	cp.markOneWayHreflang()
+	want := map[string][]string{
+		"/": {"hreflang fr alternate /fr/ doesn't link back"},
+	}
+	for page, pi := range cp {
+		var got []string
+		for _, f := range pi.findings {
+			got = append(got, f.detail)
+		}
+		if !slices.Equal(got, want[page]) {
+			t.Errorf("%s: got %q; want %q", page, got, want[page])
+		}
+	}
+}
+
+func TestHreflangAlternates(t *testing.T) {
+	c := crawler{
+		base:        "https://example.com/",
+		stripParams: []string{"utm_*"},
+		token:       queryToken{"token", "secret"},
+	}
+	got := c.hreflangAlternates(localeVariants{
+		"es": "http://www.example.com/es/?token=secret",
+		"fr": "https://example.com/fr/?utm_source=nav",
+		"de": "https://EXAMPLE.com:443/de/#top",
+	})
+	want := localeVariants{
+		"es": "https://example.com/es/",
+		"fr": "https://example.com/fr/",
+		"de": "https://example.com/de/",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestHreflangRedirect(t *testing.T) {
+	cp := crawledPages{
+		"https://example.com/": {
+			hreflang:    localeVariants{"en": "https://example.com/", "es": "https://example.com/es"},
+			contentHash: "a",
+		},
+		"https://example.com/es": {redirect: "https://example.com/es/"},
+	}
+	cp.markOneWayHreflang()
+	fs := cp["https://example.com/"].findings
+	if len(fs) != 1 || fs[0].category != categoryHreflangRedirect ||
+		fs[0].detail != "hreflang es alternate https://example.com/es redirects to https://example.com/es/" {
+		t.Errorf("got %v", fs)
+	}
+}
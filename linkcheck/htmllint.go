@@ -0,0 +1,69 @@
+package linkcheck
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// lintHTML tokenizes body and reports places where the HTML parser
+// will recover from malformed markup in ways that affect which links
+// it finds. The parser silently "fixes" these, so the tree we extract
+// links from may not match what the author intended.
+func lintHTML(body []byte) []finding {
+	var (
+		findings  []finding
+		z         = html.NewTokenizer(bytes.NewReader(body))
+		line      = 1
+		openLinks []int // lines of currently open <a> tags
+		openForms []int // lines of currently open <form> tags
+	)
+	add := func(format string, args ...interface{}) {
+		findings = append(findings, finding{categoryHTMLLint, fmt.Sprintf(format, args...)})
+	}
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tokenLine := line
+		line += bytes.Count(z.Raw(), []byte("\n"))
+		if tt != html.StartTagToken && tt != html.EndTagToken {
+			continue
+		}
+		name, _ := z.TagName()
+		switch a := atom.Lookup(name); {
+		case a == atom.A && tt == html.StartTagToken:
+			if len(openLinks) > 0 {
+				add("<a> on line %d is not closed before <a> on line %d",
+					openLinks[len(openLinks)-1], tokenLine)
+				openLinks = openLinks[:len(openLinks)-1]
+			}
+			openLinks = append(openLinks, tokenLine)
+		case a == atom.A && tt == html.EndTagToken:
+			if len(openLinks) == 0 {
+				add("stray </a> on line %d", tokenLine)
+				continue
+			}
+			openLinks = openLinks[:len(openLinks)-1]
+		case a == atom.Form && tt == html.StartTagToken:
+			if len(openForms) > 0 {
+				add("<form> on line %d is nested inside <form> on line %d and will be ignored",
+					tokenLine, openForms[0])
+			}
+			openForms = append(openForms, tokenLine)
+		case a == atom.Form && tt == html.EndTagToken:
+			if len(openForms) == 0 {
+				add("stray </form> on line %d", tokenLine)
+				continue
+			}
+			openForms = openForms[:len(openForms)-1]
+		}
+	}
+	for _, l := range openLinks {
+		add("<a> on line %d is never closed", l)
+	}
+	return findings
+}
@@ -0,0 +1,39 @@
+package linkcheck
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintHTML(t *testing.T) {
+	var testcases = []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"clean", `<p><a href="a">a</a> <a href="b">b</a></p><form></form>`, nil},
+		{"unclosed anchor", "<a href=a>a\n<a href=b>b</a>", []string{
+			"<a> on line 1 is not closed before <a> on line 2",
+		}},
+		{"never closed", "<p>\n<a href=a>a", []string{
+			"<a> on line 2 is never closed",
+		}},
+		{"stray close", "</a>", []string{
+			"stray </a> on line 1",
+		}},
+		{"nested form", "<form>\n<form>\n</form>\n</form>", []string{
+			"<form> on line 2 is nested inside <form> on line 1 and will be ignored",
+		}},
+	}
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, f := range lintHTML([]byte(test.body)) {
+				got = append(got, f.detail)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q; want %q", got, test.want)
+			}
+		})
+	}
+}
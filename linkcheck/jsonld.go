@@ -0,0 +1,71 @@
+package linkcheck
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// jsonLDProperties are the structured data properties whose URLs get checked.
+var jsonLDProperties = map[string]bool{
+	"url":          true,
+	"logo":         true,
+	"image":        true,
+	"sameAs":       true,
+	"contentUrl":   true,
+	"thumbnailUrl": true,
+}
+
+// jsonLDLink is a URL from a JSON-LD block and the property it came from.
+type jsonLDLink struct {
+	url, property string
+}
+
+// linksFromJSONLD returns the URLs in a <script type="application/ld+json">
+// block, such as an Organization's logo and sameAs profiles.
+// Blocks that aren't valid JSON are skipped.
+func linksFromJSONLD(pageurl *url.URL, n *html.Node) []jsonLDLink {
+	if n.Type != html.ElementNode || n.DataAtom != atom.Script ||
+		!strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json") ||
+		n.FirstChild == nil {
+		return nil
+	}
+	var data any
+	if err := json.Unmarshal([]byte(n.FirstChild.Data), &data); err != nil {
+		return nil
+	}
+	var links []jsonLDLink
+	var visit func(property string, v any)
+	visit = func(property string, v any) {
+		switch v := v.(type) {
+		case string:
+			if !jsonLDProperties[property] {
+				return
+			}
+			link := resolveRef(pageurl, strings.TrimSpace(v))
+			if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+				links = append(links, jsonLDLink{link, property})
+			}
+		case []any:
+			for _, item := range v {
+				visit(property, item)
+			}
+		case map[string]any:
+			// Sort keys so links are found in a stable order
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				visit(key, v[key])
+			}
+		}
+	}
+	visit("", data)
+	return links
+}
@@ -0,0 +1,51 @@
+package linkcheck
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestJSONLDLinks(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head>
+<script type="application/ld+json">{
+	"@context": "https://schema.org",
+	"@type": "NewsArticle",
+	"url": "/news/story",
+	"headline": "https://example.com/not-a-link-property",
+	"image": ["/img/a.jpg", {"@type": "ImageObject", "url": "/img/b.jpg"}],
+	"publisher": {
+		"@type": "Organization",
+		"logo": {"@type": "ImageObject", "url": "https://example.com/logo.png"},
+		"sameAs": ["https://twitter.com/example", "mailto:tips@example.com"]
+	}
+}</script>
+<script type="application/ld+json">{"broken": </script>
+<script>var url = "/not/json-ld";</script>
+</head>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/news/story")
+	_, links, _ := getIDsAndLinks(base, doc, parseOptions{links: true})
+	if len(links) != 0 {
+		t.Errorf("got %q without json-ld; want none", links)
+	}
+	_, links, contexts := getIDsAndLinks(base, doc, parseOptions{links: true, jsonLD: true})
+	want := []string{
+		"https://example.com/img/a.jpg",
+		"https://example.com/img/b.jpg",
+		"https://example.com/logo.png",
+		"https://twitter.com/example",
+		"https://example.com/news/story",
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("got %q; want %q", links, want)
+	}
+	if got, want := contexts["https://twitter.com/example"], (linkContext{"sameAs", "head > script:nth-child(1)"}); got != want {
+		t.Errorf("got context %+v; want %+v", got, want)
+	}
+}
@@ -1,12 +1,16 @@
 package linkcheck
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestRun(t *testing.T) {
@@ -37,24 +41,23 @@ func TestRun(t *testing.T) {
 		{"good ID link", ts.URL + "/id-good-a.html", 1, 0, ""},
 		{"bad ID link", ts.URL + "/id-bad-a.html", 1, 1, "missing fragment"},
 		{"ignore ID link", ts.URL + "/id-ignore-a.html", 1, 0, ""},
+		{"normalized ID link", ts.URL + "/id-normalized-a.html", 1, 1, "match only after normalization"},
+		{"percent-encoded ID link", ts.URL + "/id-encoded-a.html", 1, 0, ""},
 		{"excluded path", ts.URL + "/excluded.html", 1, 0, ""},
+		{"base href", ts.URL + "/base-a.html", 1, 0, ""},
+		{"meta refresh", ts.URL + "/refresh-bad.html", 1, 1, "404 Not Found"},
+		{"bad canonical", ts.URL + "/canonical-bad.html", 1, 1, "404 Not Found"},
 	}
 
 	for _, test := range testcases {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
-			c := crawler{
-				test.base,
-				test.crawlers,
-				excludePaths,
-				log.New(io.Discard, "linkrot", log.LstdFlags),
-				http.DefaultClient,
-				chromeUserAgent,
-				false,
-			}
+			c := testCrawler(test.base)
+			c.workers = test.crawlers
+			c.excludePaths = excludePaths
 
 			pages, _ := c.crawl()
-			errs := pages.toURLErrors(c.base)
+			errs := pages.toURLErrors(c.scope(), true)
 			output := errs.String()
 
 			if len(errs) != test.errLen {
@@ -72,3 +75,208 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestTooManyRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="/loop">loop</a>`)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	site, c, pages := crawlSite(t, mux, func(c *crawler) {
+		c.Client = &http.Client{CheckRedirect: checkRedirect(3)}
+	})
+	errs := pages.toURLErrors(c.scope(), true)
+	pe := errs[site+"/loop"]
+	if pe == nil {
+		t.Fatalf("expected error for redirect loop; got %v", errs)
+	}
+	if got := pe.category(); got != categoryTooManyRedirects {
+		t.Errorf("category = %q; want %q", got, categoryTooManyRedirects)
+	}
+}
+
+func TestQueryToken(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="/b?token=secret">b</a><a href="/c">c</a>`)
+	})
+	_, c, pages := crawlSite(t, h, func(c *crawler) {
+		c.strict = true
+		c.token = queryToken{"token", "secret"}
+	})
+	if errs := pages.toURLErrors(c.scope(), true); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	for page := range pages {
+		if strings.Contains(page, "secret") {
+			t.Errorf("token leaked into page URL %q", page)
+		}
+	}
+	if len(pages) != 3 {
+		t.Errorf("got %d pages; want 3", len(pages))
+	}
+}
+
+func TestLocaleFragments(t *testing.T) {
+	site, c, pages := crawlSite(t, http.FileServer(http.Dir("test-fixtures/sample-site")), func(c *crawler) {
+		c.base += "locale/"
+		c.checkLocales = true
+	})
+	errs := pages.toURLErrors(c.scope(), true)
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors; want 2: %v", len(errs), errs)
+	}
+	pe := errs[site+"/locale/explainer-es.html#how-it-works"]
+	if pe == nil || pe.err != ErrLocaleFragment || len(pe.missingFragments) != 1 || !pe.missingFragments["how-it-works"] {
+		t.Errorf("missing locale fragment error: %v", errs)
+	}
+	// The translation's own missing fragments are reported apart
+	pe = errs[site+"/locale/explainer-es.html"]
+	if pe == nil || pe.err != ErrMissingFragment || len(pe.missingFragments) != 1 || !pe.missingFragments["nope"] {
+		t.Errorf("missing fragment error: %v", errs)
+	}
+}
+
+func TestImplicitFragments(t *testing.T) {
+	site, c, pages := crawlSite(t, htmlSite(map[string]string{
+		"/": `<a href="/b#TOP">top</a><a href="/b#search">form</a>
+<a href="/b#q">input</a><a href="/b#intro">intro</a><a href="/b#Intro">case</a>`,
+		"/b": `<body><h2 id="intro">Intro</h2><form name="search"><input name="q"></form>`,
+	}), nil)
+	errs := pages.toURLErrors(c.scope(), true)
+	pe := errs[site+"/b"]
+	if len(errs) != 1 || pe == nil || len(pe.missingFragments) != 1 || !pe.missingFragments["Intro"] {
+		t.Errorf("want only #Intro missing, since IDs are case sensitive; got %v", errs)
+	}
+}
+
+func TestInternalOnly(t *testing.T) {
+	var hits int32
+	ext := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.NotFound(w, r)
+	}))
+	defer ext.Close()
+	_, c, pages := crawlSite(t, htmlSite(map[string]string{
+		"/": fmt.Sprintf(`<a href="%[1]s/a">a</a><a href="%[1]s/b#top">b</a>`, ext.URL),
+	}), func(c *crawler) {
+		c.internalOnly = true
+	})
+	if hits != 0 {
+		t.Errorf("external host got %d requests; want 0", hits)
+	}
+	if errs := pages.toURLErrors(c.scope(), true); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	s := newRunSummary(pages, results{}, nil, false, 0)
+	if s.pages != 1 || s.unchecked != 2 {
+		t.Errorf("got pages=%d unchecked=%d; want 1 and 2", s.pages, s.unchecked)
+	}
+}
+
+func TestWarnLatency(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/", htmlSite(map[string]string{"/": `<a href="/slow">slow</a>`}))
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<p>finally</p>`)
+	})
+	site, _, pages := crawlSite(t, mux, func(c *crawler) {
+		c.warnLatency = 50 * time.Millisecond
+	})
+	findings := pages.toFindings()
+	if len(findings) != 1 || len(findings[site+"/slow"]) != 1 ||
+		findings[site+"/slow"][0].category != categoryHighLatency {
+		t.Errorf("got findings %v; want high-latency for /slow", findings)
+	}
+}
+
+func TestSVGSprite(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/", htmlSite(map[string]string{
+		"/": `<body><svg><use xlink:href="/icons.svg#search"></use><use href="/icons.svg#close"></use></svg>`,
+	}))
+	mux.HandleFunc("/icons.svg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		io.WriteString(w, `<svg xmlns="http://www.w3.org/2000/svg"><symbol id="search"><path d="M0 0"/></symbol></svg>`)
+	})
+	site, c, pages := crawlSite(t, mux, func(c *crawler) {
+		c.strict = true
+	})
+	errs := pages.toURLErrors(c.scope(), true)
+	pe := errs[site+"/icons.svg"]
+	if len(errs) != 1 || pe == nil || len(pe.missingFragments) != 1 || !pe.missingFragments["close"] {
+		t.Errorf("want only #close missing from the sprite; got %v", errs)
+	}
+}
+
+// TestLinkHeader checks that Link header targets are crawled.
+// linksFromHeader's parsing is tested in linkheader_test.go.
+func TestLinkHeader(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Add("Link", `</style.css>; rel=preload; as=style, <https://origin.invalid>; rel=preconnect`)
+			w.Header().Add("Link", `</report.pdf>; rel="canonical"`)
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<body>`)
+		case "/style.css":
+			w.Header().Set("Content-Type", "text/css")
+			io.WriteString(w, `body {}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	site, c, pages := crawlSite(t, h, func(c *crawler) {
+		c.strict = true
+		c.checkAssets = true
+	})
+	errs := pages.toURLErrors(c.scope(), true)
+	if len(errs) != 1 || errs[site+"/report.pdf"] == nil {
+		t.Errorf("want only the canonical link to be broken; got %v", errs)
+	}
+	if _, ok := pages[site+"/style.css"]; !ok {
+		t.Error("want the preloaded stylesheet to be checked")
+	}
+}
+
+func TestCapLinks(t *testing.T) {
+	c := crawler{maxLinksPerPage: 2}
+	fr := fetchResult{links: []string{
+		"http://example.com/a",
+		"http://example.com/a#one",
+		"http://example.com/b",
+		"http://example.com/a#two",
+		"http://example.com/b",
+		"http://example.com/c",
+		"http://example.com/d#x",
+	}}
+	c.capLinks(&fr)
+	want := []string{
+		"http://example.com/a",
+		"http://example.com/a#one",
+		"http://example.com/b",
+		"http://example.com/a#two",
+		"http://example.com/b",
+	}
+	if !slices.Equal(fr.links, want) {
+		t.Errorf("got %q; want %q", fr.links, want)
+	}
+	if len(fr.findings) != 1 || fr.findings[0].detail != "page links to 4 URLs; only the first 2 were checked" {
+		t.Errorf("got findings %v", fr.findings)
+	}
+
+	fr = fetchResult{links: []string{"http://example.com/a#1", "http://example.com/a#2", "http://example.com/a#3"}}
+	c.capLinks(&fr)
+	if len(fr.links) != 3 || len(fr.findings) != 0 {
+		t.Errorf("fragments of one URL were capped: %v %v", fr.links, fr.findings)
+	}
+}
@@ -0,0 +1,107 @@
+package linkcheck
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxLinkText is how many characters of anchor text are kept.
+const maxLinkText = 80
+
+// linkContext tells editors where a link lives on its page.
+type linkContext struct {
+	// text is the anchor text, or alt text for images
+	text string
+	// selector is a CSS selector-ish path, e.g. article > p:nth-child(4) > a
+	selector string
+}
+
+func (lc linkContext) String() string {
+	if lc.text == "" {
+		return lc.selector
+	}
+	return fmt.Sprintf("%q at %s", lc.text, lc.selector)
+}
+
+func describeLink(n *html.Node) linkContext {
+	return linkContext{linkText(n), nodeSelector(n)}
+}
+
+func linkText(n *html.Node) string {
+	var buf strings.Builder
+	visitAll(n, func(c *html.Node) {
+		switch {
+		case c.Type == html.TextNode:
+			buf.WriteString(c.Data)
+			buf.WriteByte(' ')
+		case c.Type == html.ElementNode && c.DataAtom == atom.Img:
+			buf.WriteString(attr(c, "alt"))
+			buf.WriteByte(' ')
+		}
+	})
+	text := strings.Join(strings.Fields(buf.String()), " ")
+	if text == "" && n.DataAtom == atom.Area {
+		text = strings.TrimSpace(attr(n, "alt"))
+	}
+	if text == "" {
+		text = strings.TrimSpace(attr(n, "title"))
+	}
+	if utf8.RuneCountInString(text) > maxLinkText {
+		text = string([]rune(text)[:maxLinkText-1]) + "…"
+	}
+	return text
+}
+
+var simpleIDRe = regexp.MustCompile(`^[A-Za-z][\w-]*$`)
+
+// nodeSelector returns a path to n from the nearest ancestor with an ID,
+// or from <body>. Positions are only given when needed to tell siblings apart.
+func nodeSelector(n *html.Node) string {
+	var parts []string
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		if n.DataAtom == atom.Body || n.DataAtom == atom.Html {
+			break
+		}
+		if id := attr(n, "id"); simpleIDRe.MatchString(id) {
+			parts = append(parts, "#"+id)
+			break
+		}
+		part := n.Data
+		if hasSiblingTag(n) {
+			part = fmt.Sprintf("%s:nth-child(%d)", n.Data, elementIndex(n))
+		}
+		parts = append(parts, part)
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
+
+func hasSiblingTag(n *html.Node) bool {
+	if n.Parent == nil {
+		return false
+	}
+	for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c != n && c.Type == html.ElementNode && c.Data == n.Data {
+			return true
+		}
+	}
+	return false
+}
+
+// elementIndex returns the 1-based position of n among its element siblings.
+func elementIndex(n *html.Node) int {
+	i := 1
+	for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode {
+			i++
+		}
+	}
+	return i
+}
@@ -0,0 +1,42 @@
+package linkcheck
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestLinkContexts(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<article>
+	<h1>Title</h1>
+	<p>One</p>
+	<p>Two <a href="/a.html">Read
+		more</a></p>
+	<p><a href="/b.html"><img src="x.png" alt="Chart"></a><a href="/c.html" title="Tooltip"></a></p>
+</article>
+<div id="footer"><ul><li><a href="/d.html">About us</a></li></ul></div>
+<a href="/a.html">Duplicate</a>
+</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/")
+	_, _, contexts := getIDsAndLinks(base, doc, parseOptions{links: true})
+	var testcases = []struct {
+		link string
+		want linkContext
+	}{
+		{"https://example.com/a.html", linkContext{"Read more", "article > p:nth-child(3) > a"}},
+		{"https://example.com/b.html", linkContext{"Chart", "article > p:nth-child(4) > a:nth-child(1)"}},
+		{"https://example.com/c.html", linkContext{"Tooltip", "article > p:nth-child(4) > a:nth-child(2)"}},
+		{"https://example.com/d.html", linkContext{"About us", "#footer > ul > li > a"}},
+	}
+	for _, test := range testcases {
+		if got := contexts[test.link]; got != test.want {
+			t.Errorf("%s: got %+v; want %+v", test.link, got, test.want)
+		}
+	}
+}
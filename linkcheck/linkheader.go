@@ -0,0 +1,110 @@
+package linkcheck
+
+import (
+	"net/url"
+	"strings"
+)
+
+// preloadRels are the Link header relations for assets,
+// which are only checked along with other assets.
+// Hints about origins, like preconnect, aren't links to anything in particular.
+var preloadRels = []string{"preload", "modulepreload", "prefetch"}
+
+// headerLinkContext marks links found in a Link response header.
+var headerLinkContext = linkContext{selector: "Link header"}
+
+// linksFromHeader returns the targets of the checked relations
+// in Link header values, such as `</style.css>; rel=preload; as=style`.
+// See https://www.rfc-editor.org/rfc/rfc8288#section-3
+func linksFromHeader(pageurl *url.URL, values []string, assets bool) []string {
+	var links []string
+	for _, value := range values {
+		for _, hl := range parseLinkHeader(value) {
+			if !relsInclude(hl.rels, checkedRels) &&
+				!(assets && relsInclude(hl.rels, preloadRels)) {
+				continue
+			}
+			if link := resolveRef(pageurl, hl.ref); link != "" {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+type headerLink struct {
+	ref  string
+	rels []string
+}
+
+// parseLinkHeader parses one Link header value,
+// skipping links that are malformed.
+func parseLinkHeader(value string) []headerLink {
+	var links []headerLink
+	s := value
+	for {
+		start := strings.IndexByte(s, '<')
+		if start == -1 {
+			return links
+		}
+		end := strings.IndexByte(s[start:], '>')
+		if end == -1 {
+			return links
+		}
+		hl := headerLink{ref: strings.TrimSpace(s[start+1 : start+end])}
+		s = s[start+end+1:]
+		// Parameters run until a comma outside of quotes
+		var params string
+		params, s = cutParams(s)
+		for _, param := range strings.Split(params, ";") {
+			key, val, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(key), "rel") {
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				hl.rels = append(hl.rels, strings.Fields(strings.ToLower(val))...)
+			}
+		}
+		links = append(links, hl)
+	}
+}
+
+// cutParams splits s at the first comma outside of a quoted string.
+func cutParams(s string) (params, rest string) {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			i++
+		case ',':
+			if !quoted {
+				return s[:i], s[i+1:]
+			}
+		}
+	}
+	return s, ""
+}
+
+func relsInclude(rels, want []string) bool {
+	for _, rel := range rels {
+		for _, w := range want {
+			if rel == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addHeaderLinks adds links from the page's Link header to its links.
+func (c *crawler) addHeaderLinks(fr *fetchResult, pageurl string, links []string) {
+	if fr.contexts == nil {
+		fr.contexts = make(map[string]linkContext, len(links))
+	}
+	for _, link := range links {
+		if _, ok := fr.contexts[link]; !ok {
+			fr.contexts[link] = headerLinkContext
+		}
+	}
+	c.addLinks(fr, pageurl, links)
+}
@@ -0,0 +1,36 @@
+package linkcheck
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestLinksFromHeader(t *testing.T) {
+	base, _ := url.Parse("https://example.com/a/page")
+	cases := []struct {
+		name   string
+		values []string
+		assets bool
+		want   []string
+	}{
+		{"none", nil, false, nil},
+		{"preload", []string{`</style.css>; rel=preload; as=style`}, true, []string{"https://example.com/style.css"}},
+		{"preload without assets", []string{`</style.css>; rel=preload; as=style`}, false, nil},
+		{"relative", []string{`<next>; rel="next"`}, false, []string{"https://example.com/a/next"}},
+		{"several", []string{`<a.js>; rel=modulepreload, <b.js>; rel=prefetch`, `<https://example.org/>; rel="alternate canonical"`}, true,
+			[]string{"https://example.com/a/a.js", "https://example.com/a/b.js", "https://example.org/"}},
+		{"quoted comma", []string{`</x>; title="a, b"; rel=canonical`}, false, []string{"https://example.com/x"}},
+		{"origin hints", []string{`<https://cdn.example.com>; rel=preconnect, <https://cdn.example.com>; rel=dns-prefetch`}, true, nil},
+		{"no rel", []string{`</x>; title="x"`}, false, nil},
+		{"malformed", []string{`</x; rel=canonical`}, false, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := linksFromHeader(base, tc.values, tc.assets)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package linkcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxLinkStats caps how many most linked pages are reported.
+const maxLinkStats = 20
+
+// linkStats describe the health of internal linking beyond breakage.
+type linkStats struct {
+	// mostLinked are internal pages with the most inbound internal links
+	mostLinked []linkedPage
+	// deadEnds are internal pages that link nowhere
+	deadEnds []string
+}
+
+type linkedPage struct {
+	page    string
+	inbound int
+}
+
+// toLinkStats counts links among the internal pages in sc.
+func (cp crawledPages) toLinkStats(sc scope) *linkStats {
+	g := cp.toLinkGraph(sc)
+	var s linkStats
+	for page, n := range g.inbound() {
+		s.mostLinked = append(s.mostLinked, linkedPage{page, n})
+	}
+	sort.Slice(s.mostLinked, func(i, j int) bool {
+		a, b := s.mostLinked[i], s.mostLinked[j]
+		if a.inbound != b.inbound {
+			return a.inbound > b.inbound
+		}
+		return a.page < b.page
+	})
+	if len(s.mostLinked) > maxLinkStats {
+		s.mostLinked = s.mostLinked[:maxLinkStats]
+	}
+
+	// Duplicate pages aren't parsed, so judge them by their original
+	hashHasLinks := make(map[string]bool)
+	for _, pi := range cp {
+		if len(pi.links) > 0 {
+			hashHasLinks[pi.contentHash] = true
+		}
+	}
+	for _, page := range g.nodes {
+		pi := cp[page]
+		// Only HTML pages have a content hash; other files can't link
+		if pi.err == nil && pi.contentHash != "" &&
+			len(pi.links) == 0 && !hashHasLinks[pi.contentHash] {
+			s.deadEnds = append(s.deadEnds, page)
+		}
+	}
+	return &s
+}
+
+func (s *linkStats) String() string {
+	var buf strings.Builder
+	if len(s.mostLinked) > 0 {
+		fmt.Fprintf(&buf, "Most linked pages:\n")
+		for _, lp := range s.mostLinked {
+			fmt.Fprintf(&buf, " - %s: %d inbound links\n", lp.page, lp.inbound)
+		}
+	}
+	if len(s.deadEnds) > 0 {
+		fmt.Fprintf(&buf, "Dead end pages (no outbound links):\n")
+		for _, page := range s.deadEnds {
+			fmt.Fprintf(&buf, " - %s\n", page)
+		}
+	}
+	return buf.String()
+}
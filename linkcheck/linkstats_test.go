@@ -0,0 +1,48 @@
+package linkcheck
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLinkStats(t *testing.T) {
+	links := func(urls ...string) map[string]linkContext {
+		m := make(map[string]linkContext)
+		for _, u := range urls {
+			m[u] = linkContext{}
+		}
+		return m
+	}
+	pages := crawledPages{
+		"https://example.com/": {
+			contentHash: "home",
+			links:       links("https://example.com/a", "https://example.com/b", "https://example.com/c.pdf"),
+		},
+		"https://example.com/a": {
+			contentHash: "a",
+			links:       links("https://example.com/", "https://example.com/b#top", "https://other.example.net/"),
+		},
+		// Links only off site, so it isn't a dead end
+		"https://example.com/b": {contentHash: "b", links: links("https://other.example.net/")},
+		// A duplicate of a, so its links weren't parsed
+		"https://example.com/a/":     {contentHash: "a"},
+		"https://example.com/dead":   {contentHash: "dead"},
+		"https://example.com/c.pdf":  {},
+		"https://example.com/gone":   {err: errors.New("404")},
+		"https://other.example.net/": {},
+	}
+	s := pages.toLinkStats(scope{base: "https://example.com/"})
+	wantLinked := []linkedPage{
+		{"https://example.com/b", 2},
+		{"https://example.com/", 1},
+		{"https://example.com/a", 1},
+		{"https://example.com/c.pdf", 1},
+	}
+	if !reflect.DeepEqual(s.mostLinked, wantLinked) {
+		t.Errorf("mostLinked = %v; want %v", s.mostLinked, wantLinked)
+	}
+	if want := []string{"https://example.com/dead"}; !reflect.DeepEqual(s.deadEnds, want) {
+		t.Errorf("deadEnds = %v; want %v", s.deadEnds, want)
+	}
+}
@@ -0,0 +1,53 @@
+package linkcheck
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxListedSelectors caps how many elements are listed in a finding.
+const maxListedSelectors = 10
+
+// emptyLinkText returns a finding listing the links in doc that have
+// no discernible text for screen readers: no text, image alt text,
+// title, or ARIA label. Icon-only links are the usual culprit,
+// and many of them on one page usually point to a template bug.
+func emptyLinkText(doc *html.Node) []finding {
+	var selectors []string
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom != atom.A || !hasAttr(n, "href") {
+			return
+		}
+		if linkText(n) != "" ||
+			strings.TrimSpace(attr(n, "aria-label")) != "" ||
+			strings.TrimSpace(attr(n, "aria-labelledby")) != "" {
+			return
+		}
+		selectors = append(selectors, nodeSelector(n))
+	})
+	if len(selectors) == 0 {
+		return nil
+	}
+	return []finding{{categoryEmptyLinkText, listSelectors("link has", "links have", "no text", selectors)}}
+}
+
+// listSelectors describes the elements at selectors, like
+// "2 links have no text: nav > a:nth-child(1), nav > a:nth-child(2)".
+func listSelectors(singular, plural, problem string, selectors []string) string {
+	noun := plural
+	if len(selectors) == 1 {
+		noun = singular
+	}
+	listed := selectors
+	if len(listed) > maxListedSelectors {
+		listed = listed[:maxListedSelectors]
+	}
+	detail := fmt.Sprintf("%d %s %s: %s", len(selectors), noun, problem, strings.Join(listed, ", "))
+	if more := len(selectors) - len(listed); more > 0 {
+		detail += fmt.Sprintf(", and %d more", more)
+	}
+	return detail
+}
@@ -0,0 +1,46 @@
+package linkcheck
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestEmptyLinkText(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body><nav id="social">
+<a href="/news/">News</a>
+<a href="https://twitter.com/example"><svg class="icon"></svg></a>
+<a href="https://facebook.com/example" aria-label="Facebook"><svg class="icon"></svg></a>
+<a href="/search" title="Search"><i class="icon-search"></i></a>
+<a href="/"><img src="/logo.png" alt="Home"></a>
+<a href="/feed"><img src="/rss.png" alt=""></a>
+<a href="/about">   </a>
+<a name="top"></a>
+</nav></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := emptyLinkText(doc)
+	want := []finding{{categoryEmptyLinkText,
+		"3 links have no text: #social > a:nth-child(2), #social > a:nth-child(6), #social > a:nth-child(7)"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	var page strings.Builder
+	page.WriteString("<body>")
+	for i := 0; i < maxListedSelectors+2; i++ {
+		fmt.Fprintf(&page, `<a id="a%d" href="/%d"></a>`, i, i)
+	}
+	if doc, err = html.Parse(strings.NewReader(page.String())); err != nil {
+		t.Fatal(err)
+	}
+	got = emptyLinkText(doc)
+	if len(got) != 1 || !strings.HasPrefix(got[0].detail, "12 links have no text: #a0, #a1,") ||
+		!strings.HasSuffix(got[0].detail, "#a9, and 2 more") {
+		t.Errorf("got %v", got)
+	}
+}
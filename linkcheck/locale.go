@@ -0,0 +1,94 @@
+package linkcheck
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// localeVariants maps lowercase hreflang codes to the URLs of a page's translations.
+type localeVariants map[string]string
+
+// pageLocales returns the language of doc from <html lang>
+// and its <link rel=alternate hreflang> translations.
+func pageLocales(pageurl *url.URL, doc *html.Node) (lang string, variants localeVariants) {
+	pageurl = documentBase(pageurl, doc)
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		switch {
+		case n.DataAtom == atom.Html && lang == "":
+			lang = strings.ToLower(strings.TrimSpace(attr(n, "lang")))
+		case n.DataAtom == atom.Link:
+			variants = variants.addFrom(pageurl, n)
+		}
+	})
+	return lang, variants
+}
+
+// addFrom adds the translation in n, if it's a <link rel=alternate hreflang>,
+// to lv, which it returns, making it if it's nil.
+func (lv localeVariants) addFrom(pageurl *url.URL, n *html.Node) localeVariants {
+	if n.DataAtom != atom.Link || !hasRel(n, "alternate") {
+		return lv
+	}
+	hreflang := strings.ToLower(strings.TrimSpace(attr(n, "hreflang")))
+	if hreflang == "" || hreflang == "x-default" {
+		return lv
+	}
+	link := resolveRef(pageurl, href(n))
+	if link == "" {
+		return lv
+	}
+	if norm, err := Normalize(link); err == nil {
+		link = norm
+	}
+	if lv == nil {
+		lv = make(localeVariants)
+	}
+	lv[hreflang] = link
+	return lv
+}
+
+// lookup returns the variant for lang, falling back to its primary subtag,
+// so that a page in es-MX is matched with an "es" translation.
+func (lv localeVariants) lookup(lang string) string {
+	if lang == "" {
+		return ""
+	}
+	if u, ok := lv[lang]; ok {
+		return u
+	}
+	primary, _, _ := strings.Cut(lang, "-")
+	return lv[primary]
+}
+
+// checkLocaleVariant records in errs if frag, which exists on target,
+// is missing from the translation of target in lang.
+// errs is keyed by the translation's URL with the missing fragment,
+// so these errors stay apart from the translation's own missing fragments.
+func (cp crawledPages) checkLocaleVariant(errs urlErrors, page string, lc linkContext, lang, link string, target pageInfo, frag string) {
+	variant := target.locales.lookup(lang)
+	if variant == "" || variant == link {
+		return
+	}
+	vi, ok := cp[variant]
+	if !ok || vi.err != nil || vi.ids.has(frag) {
+		return
+	}
+	key := variant + "#" + frag
+	pe := errs[key]
+	if pe == nil {
+		pe = &pageError{
+			err:                 ErrLocaleFragment,
+			missingFragments:    make(map[string]bool),
+			normalizedFragments: make(map[string]bool),
+		}
+		errs[key] = pe
+	}
+	pe.addRef(page, lc)
+	pe.missingFragments[frag] = true
+}
@@ -0,0 +1,28 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+)
+
+// serveDir serves a static site build directory, such as Hugo's public/,
+// on a random local port so it can be crawled without deploying it.
+// It returns the server's base URL and a function to shut it down.
+func serveDir(dir string) (baseURL string, stop func(), err error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.IsDir() {
+		return "", nil, fmt.Errorf("not a directory: %q", dir)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	srv := &http.Server{Handler: http.FileServer(http.Dir(dir))}
+	go srv.Serve(l)
+	return "http://" + l.Addr().String(), func() { srv.Close() }, nil
+}
@@ -0,0 +1,44 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<p>home"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	base, stop, err := serveDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(base, "http://127.0.0.1:") {
+		t.Errorf("got base URL %q", base)
+	}
+	res, err := http.Get(base + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(b) != "<p>home" {
+		t.Errorf("got %q", b)
+	}
+	stop()
+	if _, err = http.Get(base + "/"); err == nil {
+		t.Error("still serving after stop")
+	}
+
+	if _, _, err = serveDir(filepath.Join(dir, "index.html")); err == nil {
+		t.Error("served a file as a directory")
+	}
+	if _, _, err = serveDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("served a missing directory")
+	}
+}
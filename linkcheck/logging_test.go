@@ -0,0 +1,38 @@
+package linkcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(&buf, logFormatJSON, false)
+	l.Info("done fetching", "url", "http://example.com/")
+	if buf.Len() != 0 {
+		t.Fatalf("info logged without verbose: %s", &buf)
+	}
+	l = newLogger(&buf, logFormatJSON, true)
+	l.Info("done fetching",
+		"url", "http://example.com/",
+		"status", 200,
+		"timings", fetchTimings{ttfb: time.Millisecond, total: 2 * time.Millisecond})
+	var entry struct {
+		Level   string
+		Msg     string
+		URL     string
+		Status  int
+		Timings map[string]time.Duration
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("bad JSON log %q: %v", &buf, err)
+	}
+	if entry.Level != "INFO" || entry.URL != "http://example.com/" || entry.Status != 200 {
+		t.Errorf("unexpected log entry: %s", &buf)
+	}
+	if entry.Timings["total"] != 2*time.Millisecond {
+		t.Errorf("timings not logged as a group: %s", &buf)
+	}
+}
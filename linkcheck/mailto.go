@@ -0,0 +1,74 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// isMailto reports whether link is a mailto: link.
+func isMailto(link string) bool {
+	return len(link) >= len("mailto:") && strings.EqualFold(link[:len("mailto:")], "mailto:")
+}
+
+// mailtoLink drops the subject, body, and other headers from a mailto: link,
+// so each address is only checked once.
+func mailtoLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return "mailto:" + u.Opaque
+}
+
+// checkMailAddresses checks the syntax of the addresses in a mailto: link
+// and that their domains have a mail exchanger.
+// Domains without MX records aren't assumed to accept mail on their A record,
+// since that's almost never intended.
+func (c *crawler) checkMailAddresses(ctx context.Context, fr *fetchResult) error {
+	u, err := url.Parse(fr.url)
+	if err != nil {
+		return err
+	}
+	addrs, err := url.PathUnescape(u.Opaque)
+	if err != nil {
+		return err
+	}
+	lookupMX := c.lookupMX
+	if lookupMX == nil {
+		lookupMX = net.DefaultResolver.LookupMX
+	}
+	for _, addr := range strings.Split(addrs, ",") {
+		addr = strings.TrimSpace(addr)
+		// A mailto: link with only headers lets the reader pick the recipient
+		if addr == "" {
+			continue
+		}
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return fmt.Errorf("%w: %q", ErrBadEmail, addr)
+		}
+		domain := a.Address[strings.LastIndexByte(a.Address, '@')+1:]
+		mxs, err := lookupMX(ctx, domain)
+		if d := new(net.DNSError); errors.As(err, &d) {
+			if d.IsNotFound {
+				return fmt.Errorf("%w: %s", ErrNoMailExchanger, domain)
+			}
+			if d.IsTemporary || d.IsTimeout {
+				return fmt.Errorf("%w: %v", ErrFlakyDNS, err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		// A null MX record means the domain doesn't accept mail (RFC 7505)
+		if len(mxs) == 0 || (len(mxs) == 1 && mxs[0].Host == ".") {
+			return fmt.Errorf("%w: %s", ErrNoMailExchanger, domain)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestMailtoLink(t *testing.T) {
+	for link, want := range map[string]string{
+		"mailto:tips@example.com?subject=Hi":          "mailto:tips@example.com",
+		"MAILTO:tips@example.com":                     "mailto:tips@example.com",
+		"mailto:Tips%20Desk%20%3Ctips@example.com%3E": "mailto:Tips%20Desk%20%3Ctips@example.com%3E",
+		"mailto:?subject=Share%20this":                "mailto:",
+	} {
+		if got := mailtoLink(link); got != want {
+			t.Errorf("mailtoLink(%q) = %q; want %q", link, got, want)
+		}
+	}
+	c := testCrawler("https://example.com/")
+	if !c.isExcluded("mailto:tips@example.com") {
+		t.Error("mailto: links are checked without -check-mailto")
+	}
+	c.checkMailto = true
+	if c.isExcluded("mailto:tips@example.com") {
+		t.Error("mailto: links aren't checked with -check-mailto")
+	}
+}
+
+func TestCheckMailAddresses(t *testing.T) {
+	c := crawler{lookupMX: func(ctx context.Context, name string) ([]*net.MX, error) {
+		switch name {
+		case "example.com":
+			return []*net.MX{{Host: "mx.example.com.", Pref: 10}}, nil
+		case "nullmx.example":
+			return []*net.MX{{Host: "."}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}}
+	for link, want := range map[string]error{
+		"mailto:tips@example.com":                     nil,
+		"mailto:Tips%20Desk%20%3Ctips@example.com%3E": nil,
+		"mailto:": nil,
+		"mailto:tips@example.com,bad@@example.com": ErrBadEmail,
+		"mailto:news@nomx.example":                 ErrNoMailExchanger,
+		"mailto:news@nullmx.example":               ErrNoMailExchanger,
+	} {
+		err := c.checkMailAddresses(context.Background(), &fetchResult{url: link})
+		if want == nil && err != nil || !errors.Is(err, want) {
+			t.Errorf("%s: got %v; want %v", link, err, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package linkcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// pageMetadata returns the text of the first <title> in doc
+// and the content of its meta description, with spaces collapsed.
+// Titles inside inline SVG are ignored.
+func pageMetadata(doc *html.Node) (title, description string) {
+	var hasTitle, hasDescription bool
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.Namespace != "" {
+			return
+		}
+		switch {
+		case n.DataAtom == atom.Title && !hasTitle:
+			hasTitle = true
+			var buf strings.Builder
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.TextNode {
+					buf.WriteString(c.Data)
+				}
+			}
+			title = strings.Join(strings.Fields(buf.String()), " ")
+		case n.DataAtom == atom.Meta && !hasDescription &&
+			strings.EqualFold(strings.TrimSpace(attr(n, "name")), "description"):
+			hasDescription = true
+			description = strings.Join(strings.Fields(attr(n, "content")), " ")
+		}
+	})
+	return title, description
+}
+
+// missingMetadata returns findings for a page without a title or description.
+func missingMetadata(title, description string) []finding {
+	var findings []finding
+	if title == "" {
+		findings = append(findings, finding{categoryMissingMetadata, "page has no <title>"})
+	}
+	if description == "" {
+		findings = append(findings, finding{categoryMissingMetadata, "page has no meta description"})
+	}
+	return findings
+}
+
+// markDuplicateMetadata adds findings to internal pages that share
+// their title or meta description with other pages. Pages with identical
+// content are already reported as duplicate content, so only one of them
+// is counted. The first URL of each group, in sorted order, is treated as
+// the original.
+func (cp crawledPages) markDuplicateMetadata() {
+	cp.markShared("title", func(pi pageInfo) string { return pi.title })
+	cp.markShared("meta description", func(pi pageInfo) string { return pi.description })
+}
+
+func (cp crawledPages) markShared(what string, value func(pageInfo) string) {
+	groups := make(map[string][]string)
+	for page, pi := range cp {
+		if v := value(pi); v != "" && pi.err == nil && pi.redirect == "" {
+			groups[v] = append(groups[v], page)
+		}
+	}
+	for _, pages := range groups {
+		if len(pages) < 2 {
+			continue
+		}
+		sort.Strings(pages)
+		seen := make(map[string]bool)
+		distinct := pages[:0]
+		for _, page := range pages {
+			hash := cp[page].contentHash
+			if hash != "" && seen[hash] {
+				continue
+			}
+			seen[hash] = true
+			distinct = append(distinct, page)
+		}
+		if len(distinct) < 2 {
+			continue
+		}
+		for _, page := range distinct[1:] {
+			pi := cp[page]
+			pi.findings = append(pi.findings, finding{
+				categoryDuplicateMetadata,
+				fmt.Sprintf("same %s as %s; %d pages share it", what, distinct[0], len(distinct)),
+			})
+			cp[page] = pi
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package linkcheck
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestPageMetadata(t *testing.T) {
+	cases := []struct {
+		page, title, description string
+	}{
+		{`<title>Home</title><meta name="description" content="About us">`, "Home", "About us"},
+		{`<head><title>
+  Spotlight
+  PA </title><meta name="Description" content=" Local   news "></head>`, "Spotlight PA", "Local news"},
+		{`<body><svg><title>Icon</title></svg>`, "", ""},
+		{`<title></title><meta name="description" content="">`, "", ""},
+		{`<title>First</title><title>Second</title>`, "First", ""},
+	}
+	for _, tc := range cases {
+		doc, err := html.Parse(strings.NewReader(tc.page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		title, description := pageMetadata(doc)
+		if title != tc.title || description != tc.description {
+			t.Errorf("pageMetadata(%q) = %q, %q; want %q, %q",
+				tc.page, title, description, tc.title, tc.description)
+		}
+	}
+}
+
+func TestMissingMetadata(t *testing.T) {
+	if fs := missingMetadata("Home", "About us"); fs != nil {
+		t.Errorf("got %v; want no findings", fs)
+	}
+	want := []finding{
+		{categoryMissingMetadata, "page has no <title>"},
+		{categoryMissingMetadata, "page has no meta description"},
+	}
+	if fs := missingMetadata("", ""); !slices.Equal(fs, want) {
+		t.Errorf("got %v; want %v", fs, want)
+	}
+}
+
+func TestMarkDuplicateMetadata(t *testing.T) {
+	cp := crawledPages{
+		"/":     {title: "Home", description: "News", contentHash: "home"},
+		"/a":    {title: "Story", description: "News", contentHash: "a"},
+		"/b":    {title: "Story", description: "B", contentHash: "b"},
+		"/copy": {title: "Story", description: "B", contentHash: "b"},
+		"/gone": {title: "Story", description: "B", err: ErrCancelled},
+	}
+	cp.markDuplicateMetadata()
+	want := map[string][]finding{
+		"/":  nil,
+		"/a": {{categoryDuplicateMetadata, "same meta description as /; 2 pages share it"}},
+		"/b": {{categoryDuplicateMetadata, "same title as /a; 2 pages share it"}},
+		// Duplicate content is only counted once
+		"/copy": nil,
+		"/gone": nil,
+	}
+	for page, want := range want {
+		if got := cp[page].findings; !slices.Equal(got, want) {
+			t.Errorf("%s: got findings %v; want %v", page, got, want)
+		}
+	}
+}
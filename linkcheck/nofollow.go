@@ -0,0 +1,151 @@
+package linkcheck
+
+import (
+	"net/url"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Modes for -respect-nofollow
+const (
+	// nofollowCheck checks nofollow links without crawling the pages they lead to
+	nofollowCheck = "check"
+	// nofollowSkip doesn't check nofollow links at all
+	nofollowSkip = "skip"
+)
+
+// nofollowLinks returns the links in doc marked rel=nofollow
+// that aren't also linked without it, or every link
+// if a robots meta tag marks the page nofollow.
+// Only <a> and <area> links are followed, so other links never are nofollow.
+func nofollowLinks(pageurl *url.URL, doc *html.Node) map[string]bool {
+	pageurl = documentBase(pageurl, doc)
+	var nf nofollowFinder
+	visitAll(doc, func(n *html.Node) {
+		nf.visit(pageurl, n)
+	})
+	return nf.result()
+}
+
+// nofollowFinder finds the nofollow links of a page one element at a time,
+// so pages can be read with a tokenizer instead of as a DOM.
+type nofollowFinder struct {
+	// links are whether every link to a URL so far was nofollow
+	links map[string]bool
+	all   bool
+}
+
+func (nf *nofollowFinder) visit(pageurl *url.URL, n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	switch n.DataAtom {
+	case atom.Meta:
+		if isRobotsMeta(n) && hasDirective(robotsDirectives(attr(n, "content")), "nofollow") {
+			nf.all = true
+		}
+		return
+	case atom.A, atom.Area:
+	default:
+		return
+	}
+	if !hasAttr(n, "href") {
+		return
+	}
+	link := resolveRef(pageurl, href(n))
+	if link == "" {
+		return
+	}
+	if nf.links == nil {
+		nf.links = make(map[string]bool)
+	}
+	nofollow := hasRel(n, "nofollow")
+	if seen, ok := nf.links[link]; ok {
+		nofollow = nofollow && seen
+	}
+	nf.links[link] = nofollow
+}
+
+func (nf *nofollowFinder) result() map[string]bool {
+	links := make(map[string]bool)
+	for link, nofollow := range nf.links {
+		if nofollow || nf.all {
+			links[link] = true
+		}
+	}
+	return links
+}
+
+// applyNofollow drops the nofollow links from links with -respect-nofollow=skip
+// or marks them on fr with -respect-nofollow=check.
+func (c *crawler) applyNofollow(fr *fetchResult, links []string, nofollow map[string]bool) []string {
+	if len(nofollow) == 0 {
+		return links
+	}
+	if c.respectNofollow == nofollowSkip {
+		kept := links[:0]
+		for _, link := range links {
+			if !nofollow[link] {
+				kept = append(kept, link)
+			}
+		}
+		return kept
+	}
+	fr.nofollow = make(map[string]bool)
+	for _, link := range links {
+		if nofollow[link] {
+			fr.nofollow[link] = true
+		}
+	}
+	return links
+}
+
+// followTracker holds back the links of internal pages that were
+// only reached by nofollow links, until a followed link reaches them,
+// the way search crawlers do.
+type followTracker struct {
+	followed map[string]bool
+	// held are crawled pages whose links haven't been queued
+	held map[string]bool
+}
+
+func newFollowTracker(base string) *followTracker {
+	return &followTracker{
+		followed: map[string]bool{base: true},
+		held:     make(map[string]bool),
+	}
+}
+
+// pagesToQueue returns the pages whose links should be queued
+// now that pageurl has been crawled: pageurl itself if it was followed,
+// along with any held pages that it leads to by followed links.
+func (ft *followTracker) pagesToQueue(cp crawledPages, pageurl string) []string {
+	if ft == nil {
+		return []string{pageurl}
+	}
+	if !ft.followed[pageurl] {
+		ft.held[pageurl] = true
+		return nil
+	}
+	pages := []string{pageurl}
+	for i := 0; i < len(pages); i++ {
+		pi := cp[pages[i]]
+		for rawLink := range pi.links {
+			if pi.nofollow[rawLink] {
+				continue
+			}
+			// Pages are crawled without their fragments
+			link, err := Normalize(rawLink)
+			if err != nil || ft.followed[link] {
+				continue
+			}
+			ft.followed[link] = true
+			if ft.held[link] {
+				delete(ft.held, link)
+				pages = append(pages, link)
+			}
+		}
+	}
+	return pages
+}
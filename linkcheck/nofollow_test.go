@@ -0,0 +1,152 @@
+package linkcheck
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestNofollowLinks(t *testing.T) {
+	cases := []struct {
+		name string
+		page string
+		want map[string]bool
+	}{
+		{"none", `<body><a href="/a">a</a>`, map[string]bool{}},
+		{"anchor", `<body><a rel="nofollow" href="/a">a</a><a href="/b">b</a>`,
+			map[string]bool{"https://example.com/a": true}},
+		{"area", `<body><map><area rel="sponsored nofollow" href="/a"></map>`,
+			map[string]bool{"https://example.com/a": true}},
+		{"also followed", `<body><a rel="nofollow" href="/a">a</a><a href="/a">again</a>`,
+			map[string]bool{}},
+		{"meta nofollow", `<head><meta name="robots" content="noindex, nofollow"></head><body><a href="/a">a</a><img src="/i.png">`,
+			map[string]bool{"https://example.com/a": true}},
+		{"meta none", `<head><meta name="ROBOTS" content="none"></head>`, map[string]bool{}},
+		{"meta noindex", `<head><meta name="robots" content="noindex"></head>`, map[string]bool{}},
+	}
+	base, _ := url.Parse("https://example.com/")
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tc.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := nofollowLinks(base, doc); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyNofollow(t *testing.T) {
+	links := []string{"https://example.com/a", "https://example.com/i.png"}
+	nofollow := map[string]bool{"https://example.com/a": true}
+
+	c := crawler{respectNofollow: nofollowSkip}
+	var fr fetchResult
+	got := c.applyNofollow(&fr, slices.Clone(links), nofollow)
+	if want := links[1:]; !slices.Equal(got, want) {
+		t.Errorf("skip: got %v; want %v", got, want)
+	}
+	c.respectNofollow = nofollowCheck
+	got = c.applyNofollow(&fr, slices.Clone(links), nofollow)
+	if !slices.Equal(got, links) || !reflect.DeepEqual(fr.nofollow, nofollow) {
+		t.Errorf("check: got %v marking %v", got, fr.nofollow)
+	}
+}
+
+func TestFollowTracker(t *testing.T) {
+	cp := crawledPages{
+		"/":  {links: map[string]linkContext{"/a": {}, "/b": {}}, nofollow: map[string]bool{"/b": true}},
+		"/a": {links: map[string]linkContext{"/c": {}}},
+		"/b": {links: map[string]linkContext{"/b1": {}}},
+		"/c": {links: map[string]linkContext{"/b": {}}},
+	}
+	ft := newFollowTracker("/")
+	if got := ft.pagesToQueue(cp, "/"); !reflect.DeepEqual(got, []string{"/"}) {
+		t.Errorf("got %v for /", got)
+	}
+	// Only linked with nofollow so far
+	if got := ft.pagesToQueue(cp, "/b"); got != nil {
+		t.Errorf("got %v for /b; want it held", got)
+	}
+	if got := ft.pagesToQueue(cp, "/a"); !reflect.DeepEqual(got, []string{"/a"}) {
+		t.Errorf("got %v for /a", got)
+	}
+	// /c links to /b without nofollow, so /b is released
+	if got := ft.pagesToQueue(cp, "/c"); !reflect.DeepEqual(got, []string{"/c", "/b"}) {
+		t.Errorf("got %v for /c; want /b released", got)
+	}
+}
+
+func TestFollowTrackerFragments(t *testing.T) {
+	cp := crawledPages{
+		"https://example.com/": {
+			links:    map[string]linkContext{"https://example.com/a#top": {}, "https://example.com/b": {}},
+			nofollow: map[string]bool{"https://example.com/b": true},
+		},
+		"https://example.com/a": {links: map[string]linkContext{"https://EXAMPLE.com/b#section": {}}},
+		"https://example.com/b": {},
+	}
+	ft := newFollowTracker("https://example.com/")
+	ft.pagesToQueue(cp, "https://example.com/")
+	if got := ft.pagesToQueue(cp, "https://example.com/b"); got != nil {
+		t.Errorf("got %v for /b; want it held", got)
+	}
+	// /a was linked with a fragment, and its link to /b releases it
+	got := ft.pagesToQueue(cp, "https://example.com/a")
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v for /a; want %v", got, want)
+	}
+}
+
+func TestRespectNofollow(t *testing.T) {
+	site := htmlSite(map[string]string{
+		"/":  `<body><a href="/a">a</a><a rel="nofollow" href="/b">b</a>`,
+		"/a": `<head><meta name="robots" content="nofollow"></head><body><a href="/a1">a1</a>`,
+		"/b": `<body><a href="/b1">b1</a>`,
+	})
+	for _, tc := range []struct {
+		mode      string
+		requested []string
+		errs      []string
+	}{
+		{"", []string{"/", "/a", "/a1", "/b", "/b1"}, []string{"/a1", "/b1"}},
+		{nofollowCheck, []string{"/", "/a", "/a1", "/b"}, []string{"/a1"}},
+		{nofollowSkip, []string{"/", "/a"}, nil},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			var (
+				mu        sync.Mutex
+				requested []string
+			)
+			base, c, pages := crawlSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requested = append(requested, r.URL.Path)
+				mu.Unlock()
+				site.ServeHTTP(w, r)
+			}), func(c *crawler) {
+				c.strict = true
+				c.respectNofollow = tc.mode
+			})
+			slices.Sort(requested)
+			if !reflect.DeepEqual(requested, tc.requested) {
+				t.Errorf("requested %v; want %v", requested, tc.requested)
+			}
+			var errs []string
+			for link := range pages.toURLErrors(c.scope(), true) {
+				errs = append(errs, strings.TrimPrefix(link, base))
+			}
+			slices.Sort(errs)
+			if !reflect.DeepEqual(errs, tc.errs) {
+				t.Errorf("got errors for %v; want %v", errs, tc.errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// robotsMetaNames are the <meta name> values that hold robots directives.
+var robotsMetaNames = []string{"robots", "googlebot"}
+
+// robotsDirectives splits the content of a robots meta tag
+// or an X-Robots-Tag value, such as "googlebot: noindex, nofollow",
+// into lowercase directives, without any user agent prefix.
+func robotsDirectives(content string) []string {
+	var directives []string
+	for _, directive := range strings.Split(content, ",") {
+		if i := strings.LastIndexByte(directive, ':'); i != -1 {
+			directive = directive[i+1:]
+		}
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive != "" {
+			directives = append(directives, directive)
+		}
+	}
+	return directives
+}
+
+// headerRobots returns the directives in a response's X-Robots-Tag headers.
+func headerRobots(h http.Header) []string {
+	var directives []string
+	for _, value := range h.Values("X-Robots-Tag") {
+		directives = append(directives, robotsDirectives(value)...)
+	}
+	return directives
+}
+
+func isRobotsMeta(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.DataAtom == atom.Meta &&
+		slices.Contains(robotsMetaNames, strings.ToLower(attr(n, "name")))
+}
+
+// metaRobots returns the directives in doc's robots meta tags.
+func metaRobots(doc *html.Node) []string {
+	var directives []string
+	visitAll(doc, func(n *html.Node) {
+		if isRobotsMeta(n) {
+			directives = append(directives, robotsDirectives(attr(n, "content"))...)
+		}
+	})
+	return directives
+}
+
+// hasDirective reports whether directives include want.
+// The none directive means both noindex and nofollow.
+func hasDirective(directives []string, want string) bool {
+	return slices.Contains(directives, want) || slices.Contains(directives, "none")
+}
+
+// markNoindexLinked adds findings to internal pages marked noindex
+// that have at least minInbound internal links to them,
+// which is usually a page deindexed by accident.
+func (cp crawledPages) markNoindexLinked(sc scope, minInbound int) {
+	if minInbound < 1 {
+		return
+	}
+	found := false
+	for _, pi := range cp {
+		found = found || hasDirective(pi.robots, "noindex")
+	}
+	if !found {
+		return
+	}
+	for page, n := range cp.toLinkGraph(sc).inbound() {
+		pi := cp[page]
+		if n < minInbound || !hasDirective(pi.robots, "noindex") {
+			continue
+		}
+		pi.findings = append(pi.findings, finding{
+			categoryNoindexLinked,
+			fmt.Sprintf("page is marked noindex but has %d inbound links", n),
+		})
+		cp[page] = pi
+	}
+}
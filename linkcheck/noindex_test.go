@@ -0,0 +1,68 @@
+package linkcheck
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestRobotsDirectives(t *testing.T) {
+	cases := []struct {
+		content string
+		want    []string
+	}{
+		{"", nil},
+		{"noindex", []string{"noindex"}},
+		{"NoIndex, NoFollow", []string{"noindex", "nofollow"}},
+		{"googlebot: noindex", []string{"noindex"}},
+		{"max-snippet:20, noarchive", []string{"20", "noarchive"}},
+		{" , none", []string{"none"}},
+	}
+	for _, tc := range cases {
+		if got := robotsDirectives(tc.content); !slices.Equal(got, tc.want) {
+			t.Errorf("robotsDirectives(%q) = %q; want %q", tc.content, got, tc.want)
+		}
+	}
+}
+
+func TestHeaderRobots(t *testing.T) {
+	h := http.Header{}
+	h.Add("X-Robots-Tag", "googlebot: none")
+	h.Add("X-Robots-Tag", "noarchive, NoSnippet")
+	if got, want := headerRobots(h), []string{"none", "noarchive", "nosnippet"}; !slices.Equal(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestNoindexLinked(t *testing.T) {
+	const base = "https://example.com/"
+	links := func(paths ...string) map[string]linkContext {
+		m := make(map[string]linkContext)
+		for _, path := range paths {
+			m[base+path] = linkContext{}
+		}
+		return m
+	}
+	cp := crawledPages{
+		base:            {links: links("a", "b", "hidden", "tagged", "rare")},
+		base + "a":      {links: links("hidden", "tagged")},
+		base + "b":      {links: links("hidden", "tagged#top")},
+		base + "hidden": {robots: []string{"noindex"}},
+		base + "tagged": {robots: []string{"none"}},
+		base + "rare":   {robots: []string{"noindex", "follow"}},
+	}
+	cp.markNoindexLinked(scope{base: base}, 3)
+	for page, pi := range cp {
+		var got []string
+		for _, f := range pi.findings {
+			got = append(got, f.detail)
+		}
+		var want []string
+		if page == base+"hidden" || page == base+"tagged" {
+			want = []string{"page is marked noindex but has 3 inbound links"}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: got findings %q; want %q", page, got, want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize returns the canonical form of rawurl used by linkrot
+// for deduplicating and comparing links.
+// The scheme and host are lowercased, default ports are dropped,
+// and "." and ".." path segments are resolved.
+//
+// Normalize guarantees the following invariants for any input
+// for which it does not return an error:
+//
+//   - The result parses successfully with url.Parse.
+//   - The result has no fragment and contains no literal '#'.
+//   - Normalize is idempotent: Normalize(Normalize(s)) == Normalize(s).
+//   - Adding or changing a fragment on the input does not change the result.
+//
+// Inputs that cannot be parsed as URLs are reported as errors
+// rather than silently passed through.
+func Normalize(rawurl string) (string, error) {
+	link, _, err := splitFragment(rawurl)
+	return link, err
+}
+
+// maxNormalizePasses bounds how often a URL is re-encoded
+// while looking for a fixed point.
+const maxNormalizePasses = 4
+
+// splitFragment normalizes linkIn and returns its fragment separately.
+// The fragment is returned unescaped.
+func splitFragment(linkIn string) (link, frag string, err error) {
+	u, err := url.Parse(linkIn)
+	if err != nil {
+		return "", "", err
+	}
+	frag = u.Fragment
+	u.Fragment = ""
+	u.RawFragment = ""
+	if err = canonicalize(u); err != nil {
+		return "", "", fmt.Errorf("normalizing %q: %w", linkIn, err)
+	}
+	link = u.String()
+	// Some URLs re-encode differently after a round trip,
+	// so keep going until the encoding is stable.
+	for i := 0; i < maxNormalizePasses; i++ {
+		u, err = url.Parse(link)
+		if err != nil {
+			return "", "", fmt.Errorf("normalizing %q: %w", linkIn, err)
+		}
+		next := u.String()
+		if next == link {
+			return link, frag, nil
+		}
+		link = next
+	}
+	return "", "", fmt.Errorf("normalizing %q: encoding does not stabilize", linkIn)
+}
+
+// normalizeFragment puts a fragment or ID into a canonical form for comparison
+// by percent-decoding it and applying Unicode NFC normalization.
+func normalizeFragment(s string) string {
+	if unescaped, err := url.PathUnescape(s); err == nil {
+		s = unescaped
+	}
+	return norm.NFC.String(s)
+}
+
+// canonicalize rewrites the parts of u that have more than one spelling.
+func canonicalize(u *url.URL) error {
+	u.Host = strings.ToLower(u.Host)
+	if u.Scheme == "http" {
+		u.Host = strings.TrimSuffix(u.Host, ":80")
+	} else if u.Scheme == "https" {
+		u.Host = strings.TrimSuffix(u.Host, ":443")
+	}
+	if u.Opaque != "" || !strings.HasPrefix(u.Path, "/") {
+		return nil
+	}
+	escaped := u.EscapedPath()
+	cleaned := removeDotSegments(escaped)
+	if cleaned == escaped {
+		return nil
+	}
+	path, err := url.PathUnescape(cleaned)
+	if err != nil {
+		return err
+	}
+	u.Path, u.RawPath = path, cleaned
+	return nil
+}
+
+// removeDotSegments resolves "." and ".." segments in an absolute path
+// as described in RFC 3986 section 5.2.4.
+func removeDotSegments(path string) string {
+	if !strings.Contains(path, "/.") {
+		return path
+	}
+	segments := strings.Split(path[1:], "/")
+	out := make([]string, 0, len(segments))
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch seg {
+		case ".":
+		case "..":
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, seg)
+			continue
+		}
+		// A trailing dot segment leaves a directory
+		if last {
+			out = append(out, "")
+		}
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+// stripParamPresets are named lists of -strip-params patterns.
+var stripParamPresets = map[string][]string{
+	// tracking is the analytics and ad click parameters of common platforms
+	"tracking": {
+		"utm_*",     // Google Analytics campaigns
+		"fbclid",    // Facebook
+		"gclid",     // Google Ads
+		"gbraid",    // Google Ads on iOS
+		"wbraid",    // Google Ads on iOS
+		"dclid",     // Google Display & Video
+		"msclkid",   // Microsoft Ads
+		"twclid",    // X
+		"ttclid",    // TikTok
+		"li_fat_id", // LinkedIn
+		"mc_cid",    // Mailchimp campaigns
+		"mc_eid",    // Mailchimp subscribers
+		"_hsenc",    // HubSpot
+		"_hsmi",     // HubSpot
+		"mkt_tok",   // Marketo
+		"igshid",    // Instagram
+		"yclid",     // Yandex
+	},
+}
+
+// parseStripParams parses a comma separated list of parameter name patterns
+// and names of presets, such as tracking.
+func parseStripParams(s string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(s, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if preset, ok := stripParamPresets[pattern]; ok {
+			patterns = append(patterns, preset...)
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad parameter pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// shouldStripParam reports whether the query parameter name
+// matches one of the -strip-params patterns.
+func (c *crawler) shouldStripParam(name string) bool {
+	for _, pattern := range c.stripParams {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalLink applies the optional -strip-params, -drop-trailing-slash,
+// and -sort-query normalizations to link, keeping its fragment.
+func (c *crawler) canonicalLink(link string) string {
+	if !c.dropTrailingSlash && !c.sortQuery && len(c.stripParams) == 0 {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	if c.dropTrailingSlash && u.Path != "/" && strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	}
+	if len(c.stripParams) > 0 && u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		kept := params[:0]
+		for _, param := range params {
+			name, _, _ := strings.Cut(param, "=")
+			if unescaped, err := url.QueryUnescape(name); err == nil {
+				name = unescaped
+			}
+			if !c.shouldStripParam(name) {
+				kept = append(kept, param)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+	}
+	if c.sortQuery && strings.Contains(u.RawQuery, "&") {
+		params := strings.Split(u.RawQuery, "&")
+		sort.Strings(params)
+		u.RawQuery = strings.Join(params, "&")
+	}
+	return u.String()
+}
@@ -0,0 +1,125 @@
+package linkcheck
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	var testcases = []struct {
+		in, out string
+		ok      bool
+	}{
+		{"http://example.com", "http://example.com", true},
+		{"HTTP://example.com/a#frag", "http://example.com/a", true},
+		{"https://example.com/a?b=c#", "https://example.com/a?b=c", true},
+		{"https://example.com/caf%C3%A9#x", "https://example.com/caf%C3%A9", true},
+		{"https://example.com/%zz", "", false},
+		{"http://[::1", "", false},
+		{"mailto:someone@example.com", "mailto:someone@example.com", true},
+		{"HTTPS://WWW.Example.COM/Path", "https://www.example.com/Path", true},
+		{"http://example.com:80/a", "http://example.com/a", true},
+		{"https://example.com:443/a", "https://example.com/a", true},
+		{"https://example.com:8443/a", "https://example.com:8443/a", true},
+		{"https://example.com/a/./b/../c", "https://example.com/a/c", true},
+		{"https://example.com/a/b/..", "https://example.com/a/", true},
+		{"https://example.com/../../a", "https://example.com/a", true},
+		{"https://example.com/caf%C3%A9/../x%2Fy", "https://example.com/x%2Fy", true},
+	}
+	for _, test := range testcases {
+		out, err := Normalize(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("Normalize(%q) err = %v; want ok = %v", test.in, err, test.ok)
+			continue
+		}
+		if out != test.out {
+			t.Errorf("Normalize(%q) = %q; want %q", test.in, out, test.out)
+		}
+	}
+}
+
+func FuzzNormalize(f *testing.F) {
+	for _, seed := range []string{
+		"http://example.com",
+		"https://example.com/a/b?c=d#e",
+		"https://example.com/caf%C3%A9#caf%C3%A9",
+		"//example.com/path",
+		"/relative/path?x#y",
+		"mailto:someone@example.com",
+		"javascript:void(0)",
+		"http://[::1]:80/",
+		"http://example.com/%2F%23?q=%23#%23",
+		"HTTP://Example.com:80/a/./b/../c/.",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		out, err := Normalize(s)
+		if err != nil {
+			return
+		}
+		if _, err := url.Parse(out); err != nil {
+			t.Fatalf("Normalize(%q) = %q, which does not parse: %v", s, out, err)
+		}
+		if strings.Contains(out, "#") {
+			t.Fatalf("Normalize(%q) = %q, which has a fragment", s, out)
+		}
+		again, err := Normalize(out)
+		if err != nil || again != out {
+			t.Fatalf("Normalize not idempotent: %q -> %q -> %q (%v)", s, out, again, err)
+		}
+		if !strings.Contains(s, "#") {
+			withFrag, err := Normalize(s + "#frag")
+			if err != nil || withFrag != out {
+				t.Fatalf("fragment changed result: %q -> %q; with fragment %q (%v)",
+					s, out, withFrag, err)
+			}
+		}
+	})
+}
+
+func TestCanonicalLink(t *testing.T) {
+	c := crawler{dropTrailingSlash: true, sortQuery: true}
+	for in, want := range map[string]string{
+		"https://example.com/":              "https://example.com/",
+		"https://example.com/a/#top":        "https://example.com/a#top",
+		"https://example.com/a?z=1&a=2&m=3": "https://example.com/a?a=2&m=3&z=1",
+		"https://example.com/a/?b=1&a=1":    "https://example.com/a?a=1&b=1",
+	} {
+		if got := c.canonicalLink(in); got != want {
+			t.Errorf("canonicalLink(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestStripParams(t *testing.T) {
+	patterns, err := parseStripParams("utm_*,fbclid,gclid, ref_*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := crawler{stripParams: patterns}
+	for in, want := range map[string]string{
+		"https://example.com/a?utm_source=x&utm_medium=y":  "https://example.com/a",
+		"https://example.com/a?id=1&fbclid=abc#top":        "https://example.com/a?id=1#top",
+		"https://example.com/a?ref_src=tw&z=1&gclid=2&a=3": "https://example.com/a?z=1&a=3",
+		"https://example.com/a?utm%5Fcampaign=spring&ok=1": "https://example.com/a?ok=1",
+		"https://example.com/a?utmost=1":                   "https://example.com/a?utmost=1",
+	} {
+		if got := c.canonicalLink(in); got != want {
+			t.Errorf("canonicalLink(%q) = %q; want %q", in, got, want)
+		}
+	}
+	patterns, err = parseStripParams("tracking, ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = crawler{stripParams: patterns}
+	in := "https://example.com/a?id=1&utm_source=x&fbclid=2&msclkid=3&mc_eid=4&ref=5"
+	if got, want := c.canonicalLink(in), "https://example.com/a?id=1"; got != want {
+		t.Errorf("canonicalLink(%q) with tracking preset = %q; want %q", in, got, want)
+	}
+	if _, err := parseStripParams("utm_[*"); err == nil {
+		t.Error("want error for bad pattern")
+	}
+}
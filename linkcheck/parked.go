@@ -0,0 +1,89 @@
+package linkcheck
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// parkedPhrases are what registrars and domain marketplaces
+// say on the pages they serve for parked or for-sale domains.
+var parkedPhrases = []string{
+	"this domain is for sale",
+	"this domain may be for sale",
+	"buy this domain",
+	"this domain name is for sale",
+	"this web page is parked",
+	"this domain is parked",
+	"parked free, courtesy of godaddy",
+	"the domain has expired",
+}
+
+// parkingHosts are the parking services and marketplaces
+// that parking pages load scripts from and link to.
+var parkingHosts = []string{
+	"sedoparking.com",
+	"parkingcrew.net",
+	"bodis.com",
+	"dan.com/buy-domain",
+	"afternic.com",
+	"hugedomains.com",
+	"domainmarket.com",
+}
+
+// maxParkedPageSize is the largest page searched for parking markers
+// outside of its title and meta description. Parking pages are small,
+// while real pages that mention a marker in passing usually aren't.
+const maxParkedPageSize = 16 << 10
+
+// parkedMarker returns the first sign in body that a page is a parking page
+// rather than the content that was linked, or "" if there is none.
+// Only the title and meta description of large pages are searched.
+func parkedMarker(body []byte) string {
+	markers := parkedPhrases
+	haystack := bytes.ToLower(body)
+	if len(body) > maxParkedPageSize {
+		haystack = []byte(strings.ToLower(pageHead(body)))
+	} else {
+		markers = append(markers[:len(markers):len(markers)], parkingHosts...)
+	}
+	for _, marker := range markers {
+		if bytes.Contains(haystack, []byte(marker)) {
+			return marker
+		}
+	}
+	return ""
+}
+
+// pageHead returns the title and meta description of a page.
+func pageHead(body []byte) string {
+	var buf strings.Builder
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return buf.String()
+		case html.StartTagToken, html.SelfClosingTagToken:
+		default:
+			continue
+		}
+		t := z.Token()
+		switch t.DataAtom {
+		case atom.Body:
+			return buf.String()
+		case atom.Title:
+			if z.Next() == html.TextToken {
+				buf.Write(z.Text())
+				buf.WriteByte('\n')
+			}
+		case atom.Meta:
+			n := elementFromToken(t, false)
+			if strings.EqualFold(attr(n, "name"), "description") {
+				buf.WriteString(attr(n, "content"))
+				buf.WriteByte('\n')
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package linkcheck
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParkedDomain(t *testing.T) {
+	ext := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/parked" {
+			io.WriteString(w, `<html><body><h1>This Domain Is For Sale!</h1></body></html>`)
+			return
+		}
+		io.WriteString(w, `<html><body><p>Still here.</p></body></html>`)
+	}))
+	defer ext.Close()
+	_, c, pages := crawlSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		// Our own pages can say anything
+		fmt.Fprintf(w, `<p>buy this domain</p><a href="%[1]s/parked">a</a><a href="%[1]s/live">b</a>`, ext.URL)
+	}), nil)
+	if errs := pages.toURLErrors(c.scope(), true); len(errs) != 0 {
+		t.Fatalf("got errors %v without -detect-parked", errs)
+	}
+
+	c.detectParked = true
+	pages, _ = c.crawl()
+	errs := pages.toURLErrors(c.scope(), true)
+	if len(errs) != 1 {
+		t.Fatalf("got errors %v; want only the parked page", errs)
+	}
+	pe := errs[ext.URL+"/parked"]
+	if pe == nil || !errors.Is(pe.err, ErrParkedDomain) {
+		t.Fatalf("got %v; want parked domain error", pe)
+	}
+	if pe.category() != categoryParkedDomain || pe.level() != severityWarning {
+		t.Errorf("got %s %s; want parked-domain warning", pe.category(), pe.level())
+	}
+}
+
+func TestParkedMarker(t *testing.T) {
+	long := strings.Repeat("<p>Lorem ipsum dolor sit amet.</p>\n", maxParkedPageSize/30)
+	cases := map[string]string{
+		`<p>Buy this domain</p>`:                               "buy this domain",
+		`<script src="https://sedoparking.com/x.js"></script>`: "sedoparking.com",
+		`<p>Still here.</p>`:                                   "",
+		// Large pages are only searched in their title and description
+		`<title>Example.com is for sale</title>` + long:                          "",
+		`<title>This domain is for sale</title>` + long:                          "this domain is for sale",
+		`<meta name="Description" content="This domain may be for sale">` + long: "this domain may be for sale",
+		`<p>Last year, a reader told us to buy this domain.</p>` + long:          "",
+		`<p>Our ads are served from sedoparking.com.</p>` + long:                 "",
+	}
+	for body, want := range cases {
+		if got := parkedMarker([]byte(body)); got != want {
+			t.Errorf("parkedMarker(%.60q) = %q; want %q", body, got, want)
+		}
+	}
+}
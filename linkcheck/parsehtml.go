@@ -1,24 +1,127 @@
 package linkcheck
 
 import (
+	"fmt"
 	"net/url"
+	"slices"
+	"strings"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 )
 
-func getIDsAndLinks(pageurl *url.URL, doc *html.Node, getLinks bool) (ids, links []string) {
+// parseOptions controls what getIDsAndLinks extracts from a page.
+type parseOptions struct {
+	ids   bool
+	links bool
+	// iframes adds <iframe> and <frame> sources to links
+	iframes bool
+	// assets adds images, including responsive srcset candidates,
+	// stylesheets, including url() references in inline CSS,
+	// and <object>, <embed>, and <track> resources to links
+	assets bool
+	// jsonLD adds URLs in JSON-LD structured data to links
+	jsonLD bool
+	// text has tokenizeIDsAndLinks return the page's text
+	text bool
+}
+
+// getIDsAndLinks returns the IDs and links in doc,
+// and where each link first appears.
+func getIDsAndLinks(pageurl *url.URL, doc *html.Node, opts parseOptions) (ids, links []string, contexts map[string]linkContext) {
+	pageurl = documentBase(pageurl, doc)
+	contexts = make(map[string]linkContext)
 	visitAll(doc, func(n *html.Node) {
-		ids = append(ids, getIDs(n)...)
-		if !getLinks {
+		if opts.ids {
+			ids = append(ids, idsFromNode(n)...)
+			ids = append(ids, targetNamesFromNode(n)...)
+		}
+		if !opts.links {
+			return
+		}
+		if opts.jsonLD {
+			for _, jl := range linksFromJSONLD(pageurl, n) {
+				links = append(links, jl.url)
+				if _, ok := contexts[jl.url]; !ok {
+					contexts[jl.url] = linkContext{jl.property, nodeSelector(n)}
+				}
+			}
+		}
+		found := len(links)
+		links = append(links, linksFromNode(pageurl, n, opts)...)
+		if len(links) == found {
 			return
 		}
-		if link := linkFromAHref(pageurl, n); link != "" {
+		lc := describeLink(n)
+		for _, link := range links[found:] {
+			if _, ok := contexts[link]; !ok {
+				contexts[link] = lc
+			}
+		}
+	})
+
+	return ids, links, contexts
+}
+
+// linksFromNode returns the links n makes, other than JSON-LD ones.
+func linksFromNode(pageurl *url.URL, n *html.Node, opts parseOptions) (links []string) {
+	if link := linkFromAHref(pageurl, n); link != "" {
+		links = append(links, link)
+	}
+	if link := linkFromArea(pageurl, n); link != "" {
+		links = append(links, link)
+	}
+	if link := linkFromSVGUse(pageurl, n); link != "" {
+		links = append(links, link)
+	}
+	if link := linkFromMetaRefresh(pageurl, n); link != "" {
+		links = append(links, link)
+	}
+	if link := linkFromHeadLink(pageurl, n); link != "" {
+		links = append(links, link)
+	}
+	if opts.iframes {
+		if link := linkFromFrameSrc(pageurl, n); link != "" {
+			links = append(links, link)
+		}
+	}
+	if opts.assets {
+		links = append(links, linksFromImage(pageurl, n)...)
+		links = append(links, linksFromStyle(pageurl, n)...)
+		if link := linkFromEmbed(pageurl, n); link != "" {
 			links = append(links, link)
 		}
+	}
+	return links
+}
+
+// documentBase returns the URL that relative links in doc resolve against,
+// which is set by the first <base href> element if there is one.
+func documentBase(pageurl *url.URL, doc *html.Node) *url.URL {
+	var base *html.Node
+	visitAll(doc, func(n *html.Node) {
+		if base == nil && n.Type == html.ElementNode &&
+			n.DataAtom == atom.Base && hasAttr(n, "href") {
+			base = n
+		}
 	})
+	if base == nil {
+		return pageurl
+	}
+	u, err := url.Parse(href(base))
+	if err != nil {
+		return pageurl
+	}
+	return pageurl.ResolveReference(u)
+}
 
-	return ids, links
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
 }
 
 func visitAll(n *html.Node, callback func(*html.Node)) {
@@ -36,21 +139,217 @@ func linkFromAHref(pageurl *url.URL, n *html.Node) (link string) {
 	return resolveRef(pageurl, href(n))
 }
 
+// linkFromArea returns the link of an image map <area>.
+func linkFromArea(pageurl *url.URL, n *html.Node) (link string) {
+	if n.Type != html.ElementNode || n.DataAtom != atom.Area {
+		return
+	}
+	ref := href(n)
+	if ref == "" {
+		return
+	}
+	return resolveRef(pageurl, ref)
+}
+
+// linkFromSVGUse returns what an inline SVG <use> element references,
+// usually a symbol in an icon sprite. The parser keeps xlink:href as
+// an href attribute in the xlink namespace, so both spellings are found.
+func linkFromSVGUse(pageurl *url.URL, n *html.Node) (link string) {
+	if n.Type != html.ElementNode || n.Namespace != "svg" || n.Data != "use" {
+		return
+	}
+	ref := href(n)
+	if ref == "" {
+		return
+	}
+	return resolveRef(pageurl, ref)
+}
+
+// checkedRels are the <link rel> types whose targets get checked.
+var checkedRels = []string{"canonical", "prev", "next", "alternate", "amphtml"}
+
+func linkFromHeadLink(pageurl *url.URL, n *html.Node) (link string) {
+	if n.Type != html.ElementNode || n.DataAtom != atom.Link ||
+		!hasRel(n, checkedRels...) {
+		return
+	}
+	ref := href(n)
+	if ref == "" {
+		return
+	}
+	return resolveRef(pageurl, ref)
+}
+
+// hasRel reports whether n's rel attribute includes any of rels.
+func hasRel(n *html.Node, rels ...string) bool {
+	for _, rel := range strings.Fields(attr(n, "rel")) {
+		for _, want := range rels {
+			if strings.EqualFold(rel, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func linkFromMetaRefresh(pageurl *url.URL, n *html.Node) (link string) {
+	if n.Type != html.ElementNode || n.DataAtom != atom.Meta ||
+		!strings.EqualFold(attr(n, "http-equiv"), "refresh") {
+		return
+	}
+	ref := refreshURL(attr(n, "content"))
+	if ref == "" {
+		return
+	}
+	return resolveRef(pageurl, ref)
+}
+
+// refreshURL extracts the target from a meta refresh content attribute,
+// such as "5; url='https://example.com/'".
+// See https://html.spec.whatwg.org/multipage/semantics.html#shared-declarative-refresh-steps
+func refreshURL(content string) string {
+	const whitespace = " \t\n\r\f"
+	s := strings.TrimLeft(content, whitespace)
+	s = strings.TrimLeft(s, "0123456789.")
+	s = strings.TrimLeft(s, whitespace)
+	if s == "" || (s[0] != ';' && s[0] != ',') {
+		return ""
+	}
+	s = strings.TrimLeft(s[1:], whitespace)
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		rest := strings.TrimLeft(s[3:], whitespace)
+		if strings.HasPrefix(rest, "=") {
+			s = strings.TrimLeft(rest[1:], whitespace)
+		}
+	}
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		quote := s[0]
+		s = s[1:]
+		if i := strings.IndexByte(s, quote); i != -1 {
+			s = s[:i]
+		}
+	}
+	return strings.TrimRight(s, whitespace)
+}
+
+func linkFromFrameSrc(pageurl *url.URL, n *html.Node) (link string) {
+	if n.Type != html.ElementNode ||
+		(n.DataAtom != atom.Iframe && n.DataAtom != atom.Frame) {
+		return
+	}
+	src := attr(n, "src")
+	if src == "" {
+		return
+	}
+	return resolveRef(pageurl, src)
+}
+
+// linksFromImage returns the sources of <img> and <picture> <source> elements,
+// including every candidate in their srcset.
+func linksFromImage(pageurl *url.URL, n *html.Node) (links []string) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	switch {
+	case n.DataAtom == atom.Img:
+	case n.DataAtom == atom.Source && n.Parent != nil && n.Parent.DataAtom == atom.Picture:
+	default:
+		return
+	}
+	refs := parseSrcset(attr(n, "srcset"))
+	if src := attr(n, "src"); src != "" {
+		refs = append(refs, src)
+	}
+	for _, ref := range refs {
+		if link := resolveRef(pageurl, ref); link != "" {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// linkFromEmbed returns the resource of an <object>, <embed>,
+// or <track> element, such as a PDF viewer's document or subtitles.
+func linkFromEmbed(pageurl *url.URL, n *html.Node) (link string) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	var ref string
+	switch n.DataAtom {
+	case atom.Object:
+		ref = attr(n, "data")
+	case atom.Embed, atom.Track:
+		ref = attr(n, "src")
+	default:
+		return
+	}
+	if ref == "" {
+		return
+	}
+	return resolveRef(pageurl, ref)
+}
+
+// parseSrcset returns the URLs of the image candidates in a srcset attribute,
+// such as "a.jpg 1x, b.jpg 2x" or "small.jpg 480w, large.jpg 1080w".
+// See https://html.spec.whatwg.org/multipage/images.html#parsing-a-srcset-attribute
+func parseSrcset(srcset string) []string {
+	const whitespace = " \t\n\r\f"
+	var urls []string
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, whitespace+",")
+		if s == "" {
+			return urls
+		}
+		end := strings.IndexAny(s, whitespace)
+		if end == -1 {
+			end = len(s)
+		}
+		u := s[:end]
+		s = s[end:]
+		if strings.HasSuffix(u, ",") {
+			// no descriptors
+			u = strings.TrimRight(u, ",")
+		} else {
+			// skip descriptors up to the next comma outside of parentheses
+			depth, i := 0, 0
+		descriptors:
+			for ; i < len(s); i++ {
+				switch s[i] {
+				case '(':
+					depth++
+				case ')':
+					depth--
+				case ',':
+					if depth <= 0 {
+						break descriptors
+					}
+				}
+			}
+			s = s[i:]
+		}
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+}
+
 func isAnchor(n *html.Node) bool {
 	return n.Type == html.ElementNode && n.DataAtom == atom.A
 }
 
-func getIDs(n *html.Node) []string {
+func idsFromNode(n *html.Node) []string {
 	var ids []string
 	for _, attr := range n.Attr {
 		if attr.Key == "id" {
 			ids = append(ids, attr.Val)
 		}
 	}
-	// collect old fashioned <a name=""> anchors
+	// collect old fashioned <a name=""> anchors,
+	// unless they repeat the element's own id
 	if isAnchor(n) {
 		for _, attr := range n.Attr {
-			if attr.Key == "name" {
+			if attr.Key == "name" && !slices.Contains(ids, attr.Val) {
 				ids = append(ids, attr.Val)
 			}
 		}
@@ -58,10 +357,64 @@ func getIDs(n *html.Node) []string {
 	return ids
 }
 
+// targetNamesFromNode returns the name of a form control or frame,
+// which fragment links can also target. Unlike IDs, these names
+// are often shared, as by the radio buttons in a group.
+func targetNamesFromNode(n *html.Node) []string {
+	if n.Type != html.ElementNode || !namedTargets[n.DataAtom] {
+		return nil
+	}
+	if name := attr(n, "name"); name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+var namedTargets = map[atom.Atom]bool{
+	atom.Form:     true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Button:   true,
+	atom.Fieldset: true,
+	atom.Output:   true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+}
+
+// duplicateIDs returns findings for IDs that appear more than once in doc,
+// since fragment links to them are ambiguous.
+func duplicateIDs(doc *html.Node) []finding {
+	var ids []string
+	counts := make(map[string]int)
+	visitAll(doc, func(n *html.Node) {
+		for _, id := range idsFromNode(n) {
+			ids = append(ids, id)
+			counts[id]++
+		}
+	})
+	var findings []finding
+	for _, id := range ids {
+		if n := counts[id]; n > 1 && id != "" {
+			findings = append(findings, finding{
+				categoryDuplicateID,
+				fmt.Sprintf("id %q appears %d times", id, n),
+			})
+			// Only report each ID once
+			counts[id] = 0
+		}
+	}
+	return findings
+}
+
 func href(n *html.Node) string {
-	for _, attr := range n.Attr {
-		if attr.Key == "href" {
-			return attr.Val
+	return attr(n, "href")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
 		}
 	}
 	return ""
@@ -0,0 +1,136 @@
+package linkcheck
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseSrcset(t *testing.T) {
+	var testcases = []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"a.jpg", []string{"a.jpg"}},
+		{"a.jpg 1x, b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+		{"a.jpg,b.jpg", []string{"a.jpg,b.jpg"}}, // commas may be part of a URL
+		{" small.jpg 480w,\n large.jpg 1080w ", []string{"small.jpg", "large.jpg"}},
+		{"data:image/png;base64,xyz 1x", []string{"data:image/png;base64,xyz"}},
+		{"a.jpg 1x (odd, descriptor), b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+	}
+	for _, test := range testcases {
+		if got := parseSrcset(test.in); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseSrcset(%q) = %q; want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestRefreshURL(t *testing.T) {
+	var testcases = []struct{ in, want string }{
+		{"", ""},
+		{"5", ""},
+		{"0; url=https://example.com/", "https://example.com/"},
+		{"0;URL='/next page.html'", "/next page.html"},
+		{"3, next.html", "next.html"},
+		{` 1.5 ; url = "quoted.html" trailing`, "quoted.html"},
+		{"0; urlish.html", "urlish.html"},
+	}
+	for _, test := range testcases {
+		if got := refreshURL(test.in); got != test.want {
+			t.Errorf("refreshURL(%q) = %q; want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestDuplicateIDs(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body>
+<a id="main" name="main">skip</a>
+<h2 id="intro">Intro</h2>
+<main id="main"><h2 id="intro">Intro again</h2><a name="intro">old anchor</a></main>
+<p id="unique">`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range duplicateIDs(doc) {
+		if f.category != categoryDuplicateID {
+			t.Errorf("unexpected category %q", f.category)
+		}
+		got = append(got, f.detail)
+	}
+	want := []string{`id "main" appears 2 times`, `id "intro" appears 3 times`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestFragmentTargets(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body>
+<h2 id="intro">Intro</h2><a name="legacy">old</a>
+<form name="search"><input name="q"><input type="radio" name="size"><input type="radio" name="size"></form>
+<iframe name="player"></iframe><div name="ignored"></div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/")
+	ids, _, _ := getIDsAndLinks(base, doc, parseOptions{ids: true})
+	want := []string{"intro", "legacy", "search", "q", "size", "size", "player"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %q; want %q", ids, want)
+	}
+	if dups := duplicateIDs(doc); len(dups) != 0 {
+		t.Errorf("names reported as duplicate IDs: %v", dups)
+	}
+}
+
+func TestImageMapAndSVGLinks(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body>
+<map name="counties"><area shape="rect" coords="0,0,10,10" href="/county/adams" alt="Adams County"><area nohref alt="Nothing"></map>
+<svg><use xlink:href="/icons.svg#search"></use><use href="#local"></use>
+<a xlink:href="/svg-link">inside svg</a></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/page")
+	_, links, contexts := getIDsAndLinks(base, doc, parseOptions{links: true})
+	want := []string{
+		"https://example.com/county/adams",
+		"https://example.com/icons.svg#search",
+		"https://example.com/page#local",
+		"https://example.com/svg-link",
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("got %q; want %q", links, want)
+	}
+	if got := contexts["https://example.com/county/adams"].text; got != "Adams County" {
+		t.Errorf("area context text = %q; want alt text", got)
+	}
+}
+
+func TestEmbedLinks(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body>
+<object data="/docs/budget.pdf" type="application/pdf"></object>
+<embed src="/media/map.swf">
+<video src="/media/clip.mp4"><track kind="captions" src="clip.vtt" srclang="en"></video>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/news/")
+	_, links, _ := getIDsAndLinks(base, doc, parseOptions{links: true})
+	if len(links) != 0 {
+		t.Errorf("got %q without assets; want none", links)
+	}
+	_, links, _ = getIDsAndLinks(base, doc, parseOptions{links: true, assets: true})
+	want := []string{
+		"https://example.com/docs/budget.pdf",
+		"https://example.com/media/map.swf",
+		"https://example.com/news/clip.vtt",
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("got %q; want %q", links, want)
+	}
+}
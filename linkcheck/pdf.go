@@ -0,0 +1,171 @@
+package linkcheck
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// maxPDFStream caps how much of each compressed PDF stream is inflated.
+const maxPDFStream = 8 << 20
+
+// linksFromPDF returns the targets of URI actions in a PDF, resolved against baseurl.
+//
+// Rather than fully parsing the document, it scans for /URI entries
+// in the file and in its Flate compressed streams,
+// which is where object streams keep link annotations.
+func linksFromPDF(baseurl *url.URL, body []byte) (links []string) {
+	seen := make(map[string]bool)
+	for _, ref := range pdfURIs(body, true) {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		if link := resolveRef(baseurl, ref); link != "" {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+func pdfURIs(data []byte, inflate bool) (refs []string) {
+	key := []byte("/URI")
+	for s := data; ; {
+		i := bytes.Index(s, key)
+		if i == -1 {
+			break
+		}
+		s = s[i+len(key):]
+		// Skip /URI action types, e.g. /S /URI
+		rest := bytes.TrimLeft(s, " \t\r\n\f\x00")
+		if ref, ok := pdfString(rest); ok && ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	if !inflate {
+		return refs
+	}
+	for s := data; ; {
+		i := bytes.Index(s, []byte("stream"))
+		if i == -1 {
+			break
+		}
+		s = s[i+len("stream"):]
+		// stream keyword is followed by CRLF or LF
+		if bytes.HasPrefix(s, []byte("\r\n")) {
+			s = s[2:]
+		} else if bytes.HasPrefix(s, []byte("\n")) {
+			s = s[1:]
+		} else {
+			continue
+		}
+		end := bytes.Index(s, []byte("endstream"))
+		if end == -1 {
+			break
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(s[:end]))
+		s = s[end:]
+		if err != nil {
+			continue
+		}
+		// Truncated or corrupt streams are expected; use what inflated
+		inflated, _ := io.ReadAll(io.LimitReader(zr, maxPDFStream))
+		refs = append(refs, pdfURIs(inflated, false)...)
+	}
+	return refs
+}
+
+// pdfString decodes a PDF literal (string) or hex <string> at the start of s.
+func pdfString(s []byte) (string, bool) {
+	if len(s) == 0 {
+		return "", false
+	}
+	switch s[0] {
+	case '(':
+		return pdfLiteral(s[1:])
+	case '<':
+		end := bytes.IndexByte(s, '>')
+		if end == -1 {
+			return "", false
+		}
+		hex := bytes.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' || r == '\n' || r == '\f' {
+				return -1
+			}
+			return r
+		}, s[1:end])
+		if len(hex)%2 == 1 {
+			hex = append(hex, '0')
+		}
+		var buf bytes.Buffer
+		for i := 0; i < len(hex); i += 2 {
+			b, err := strconv.ParseUint(string(hex[i:i+2]), 16, 8)
+			if err != nil {
+				return "", false
+			}
+			buf.WriteByte(byte(b))
+		}
+		return buf.String(), true
+	}
+	return "", false
+}
+
+// pdfLiteral decodes the body of a literal string up to its closing parenthesis.
+func pdfLiteral(s []byte) (string, bool) {
+	var buf bytes.Buffer
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return buf.String(), true
+			}
+			depth--
+		case '\\':
+			i++
+			if i >= len(s) {
+				return "", false
+			}
+			switch e := s[i]; e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// line continuation
+				if i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e < '0' || e > '7' {
+					c = e
+					break
+				}
+				// up to three octal digits
+				n := 0
+				for j := 0; j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; j++ {
+					n = n*8 + int(s[i]-'0')
+					i++
+				}
+				i--
+				c = byte(n)
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return "", false
+}
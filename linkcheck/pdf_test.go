@@ -0,0 +1,58 @@
+package linkcheck
+
+import (
+	"bytes"
+	"compress/zlib"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestLinksFromPDF(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write([]byte("<< /Type /Annot /Subtype /Link /A << /S /URI /URI (/in-stream.html) >> >>"))
+	zw.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.5\n")
+	pdf.WriteString("1 0 obj << /A << /S /URI /URI (https://example.com/a\\(1\\).html) >> >> endobj\n")
+	pdf.WriteString("2 0 obj << /A << /S /URI /URI <2F6865782E68746D6C> >> >> endobj\n")
+	pdf.WriteString("3 0 obj << /A << /S /URI /URI (https://example.com/a\\(1\\).html) >> >> endobj\n")
+	pdf.WriteString("4 0 obj << /Type /ObjStm /Filter /FlateDecode >> stream\r\n")
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\r\nendstream endobj\n%%EOF\n")
+
+	base, _ := url.Parse("https://example.com/docs/report.pdf")
+	got := linksFromPDF(base, pdf.Bytes())
+	want := []string{
+		"https://example.com/a(1).html",
+		"https://example.com/hex.html",
+		"https://example.com/in-stream.html",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestPDFLiteral(t *testing.T) {
+	var testcases = []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"abc)", "abc", true},
+		{"a(b)c)", "a(b)c", true},
+		{`a\)b)`, "a)b", true},
+		{`\101\102C)`, "ABC", true},
+		{"a\\\nb)", "ab", true},
+		{`\n\\)`, "\n\\", true},
+		{"unterminated", "", false},
+	}
+	for _, test := range testcases {
+		got, ok := pdfLiteral([]byte(test.in))
+		if got != test.want || ok != test.ok {
+			t.Errorf("pdfLiteral(%q) = %q, %v; want %q, %v", test.in, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package linkcheck
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// servePprof serves the runtime profiles at /debug/pprof/ on addr,
+// so a long crawl can be profiled while it runs.
+// It returns the address it listens on and a function to shut it down.
+func servePprof(addr string) (listening string, stop func(), err error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(l)
+	return l.Addr().String(), func() { srv.Close() }, nil
+}
@@ -0,0 +1,61 @@
+package linkcheck
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressInterval is how often the progress line is redrawn.
+const progressInterval = time.Second
+
+// progress draws a single, continually updated status line for interactive runs.
+type progress struct {
+	w     io.Writer
+	start time.Time
+	// width of the last line drawn, so it can be cleared
+	width int
+}
+
+func newProgress(w io.Writer) *progress {
+	return &progress{w: w, start: time.Now()}
+}
+
+// update redraws the progress line.
+// The time left is estimated from the average rate of fetches so far.
+func (p *progress) update(fetched, queued, errs int) {
+	line := fmt.Sprintf("fetched %d pages, %d queued, %d errors, %s",
+		fetched, queued, errs, p.eta(fetched, queued, time.Since(p.start)))
+	p.draw(line)
+}
+
+func (p *progress) eta(fetched, queued int, elapsed time.Duration) string {
+	if fetched == 0 {
+		return "estimating time left"
+	}
+	perPage := elapsed / time.Duration(fetched)
+	left := (perPage * time.Duration(queued)).Round(time.Second)
+	return fmt.Sprintf("about %v left", left)
+}
+
+// clear erases the progress line.
+func (p *progress) clear() {
+	p.draw("")
+}
+
+func (p *progress) draw(line string) {
+	pad := ""
+	if n := p.width - len(line); n > 0 {
+		pad = strings.Repeat(" ", n) + strings.Repeat("\b", n)
+	}
+	fmt.Fprintf(p.w, "\r%s%s", line, pad)
+	p.width = len(line)
+}
+
+// isTerminal reports whether f looks like an interactive terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
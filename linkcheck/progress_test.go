@@ -0,0 +1,27 @@
+package linkcheck
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var buf strings.Builder
+	p := newProgress(&buf)
+	if got := p.eta(0, 10, time.Second); got != "estimating time left" {
+		t.Errorf("eta with nothing fetched = %q", got)
+	}
+	if got := p.eta(10, 30, 5*time.Second); got != "about 15s left" {
+		t.Errorf("eta = %q; want about 15s left", got)
+	}
+	p.draw("fetched 10 pages")
+	p.draw("done")
+	p.clear()
+	want := "\rfetched 10 pages" +
+		"\rdone            \b\b\b\b\b\b\b\b\b\b\b\b" +
+		"\r    \b\b\b\b"
+	if got := buf.String(); got != want {
+		t.Errorf("drew %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// proxyDirect is the -proxy value for connecting without a proxy.
+const proxyDirect = "direct"
+
+// parseProxy returns an http.Transport.Proxy func for a -proxy setting:
+// an http, https, or socks5 URL, "direct" for no proxy,
+// or blank to use $HTTP_PROXY, $HTTPS_PROXY, and $NO_PROXY.
+func parseProxy(s string) (func(*http.Request) (*url.URL, error), error) {
+	switch s {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case proxyDirect:
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("bad proxy %q: %w", s, err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("bad proxy %q: scheme must be http, https, or socks5", s)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("bad proxy %q: missing host", s)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// proxyByHost returns an http.Transport.Proxy func
+// that sends requests for host through internal
+// and all other requests through external.
+func proxyByHost(host string, internal, external func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := external
+		if strings.EqualFold(req.URL.Host, host) {
+			proxy = internal
+		}
+		if proxy == nil {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+}
@@ -0,0 +1,60 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseProxy(t *testing.T) {
+	for _, s := range []string{"", "direct", "http://proxy:3128", "socks5://127.0.0.1:1080"} {
+		if _, err := parseProxy(s); err != nil {
+			t.Errorf("parseProxy(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"ftp://proxy", "http://", "%"} {
+		if _, err := parseProxy(s); err == nil {
+			t.Errorf("parseProxy(%q) succeeded; want error", s)
+		}
+	}
+}
+
+func TestProxyByHost(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "site")
+	}))
+	defer site.Close()
+	// A plain HTTP proxy gets requests with absolute URLs
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		io.WriteString(w, "proxy")
+	}))
+	defer proxy.Close()
+
+	siteURL, _ := url.Parse(site.URL)
+	proxyURL, _ := url.Parse(proxy.URL)
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = proxyByHost(siteURL.Host, nil, http.ProxyURL(proxyURL))
+	cl := &http.Client{Transport: tr}
+
+	for _, test := range []struct{ url, want string }{
+		{site.URL + "/page", "site"},
+		{"http://external.example/page", "proxy"},
+	} {
+		res, err := cl.Get(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(b) != test.want {
+			t.Errorf("%s: served by %q; want %q", test.url, b, test.want)
+		}
+	}
+	if len(proxied) != 1 || proxied[0] != "http://external.example/page" {
+		t.Errorf("proxied %v", proxied)
+	}
+}
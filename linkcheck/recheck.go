@@ -0,0 +1,159 @@
+package linkcheck
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// recheckCLI runs the linkrot recheck subcommand, which fetches only the URLs
+// with problems in a previous JSON report, to confirm fixes without a full crawl.
+func recheckCLI(args []string) error {
+	fl := flag.NewFlagSet("linkrot recheck", flag.ContinueOnError)
+	fl.Usage = func() {
+		const usage = `Usage of linkrot recheck %s:
+
+linkrot recheck [options] -from <report.json>
+
+    linkrot recheck skips crawling and fetches again only the URLs with
+    problems in a report written with -format=json, printing which are
+    fixed and which are still broken. It exits 0 if everything is fixed.
+
+Options:
+
+`
+		fmt.Fprintf(os.Stderr, usage, getVersion())
+		fl.PrintDefaults()
+	}
+	from := fl.String("from", "", "JSON report `file` from an earlier run")
+	verbose := fl.Bool("verbose", false, "verbose")
+	crawlers := fl.Int("crawlers", runtime.NumCPU(), "number of concurrent requests")
+	timeout := fl.Duration("timeout", 10*time.Second, "timeout for requesting a URL")
+	maxRedirects := fl.Int("max-redirects", 10, "report URLs that redirect more than `N` times")
+	userAgent := fl.String("user-agent", chromeUserAgent, "`agent` to send in the User-Agent header")
+	strict := fl.Bool("strict", false, "report all errors, not just 404s and missing fragments, as broken")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || fl.NArg() != 0 {
+		fl.Usage()
+		return fmt.Errorf("recheck needs a report from -from and no arguments")
+	}
+	b, err := os.ReadFile(*from)
+	if err != nil {
+		return err
+	}
+	var report jsonReport
+	if err = json.Unmarshal(b, &report); err != nil {
+		return fmt.Errorf("reading report %s: %w", *from, err)
+	}
+
+	jar, _ := cookiejar.New(nil)
+	c := &crawler{
+		base:    report.Base,
+		workers: *crawlers,
+		Logger:  newLogger(os.Stderr, logFormatText, *verbose),
+		Client: &http.Client{
+			Jar:           jar,
+			Timeout:       *timeout,
+			CheckRedirect: checkRedirect(*maxRedirects),
+		},
+		userAgent: *userAgent,
+		strict:    *strict,
+		// Keep page text for #:~:text= fragments
+		checkTextFragments: true,
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	results := c.recheck(ctx, report.Errors)
+	return printRecheck(os.Stdout, results)
+}
+
+// recheckResult is whether a problem from an earlier report was fixed.
+type recheckResult struct {
+	jsonError
+	// err is nil if the problem is fixed
+	err error
+}
+
+// recheck fetches the URL of each reported error again
+// and checks whether its missing fragments are still missing.
+func (c *crawler) recheck(ctx context.Context, errs []jsonError) []recheckResult {
+	results := make([]recheckResult, len(errs))
+	sem := make(chan struct{}, max(c.workers, 1))
+	var wg sync.WaitGroup
+	for i, je := range errs {
+		wg.Add(1)
+		go func(i int, je jsonError) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = recheckResult{je, c.recheckOne(ctx, je)}
+		}(i, je)
+	}
+	wg.Wait()
+	return results
+}
+
+func (c *crawler) recheckOne(ctx context.Context, je jsonError) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fr := c.fetch(ctx, je.URL)
+	if fr.err != nil {
+		return fr.err
+	}
+	var missing []string
+	frags := append(append([]string(nil), je.MissingFragments...), je.NormalizedFragments...)
+	if len(frags) > 0 {
+		// Match fragments the way the crawl did
+		cp := newCrawledPages()
+		cp.add(fr, make(stringPool))
+		pi := cp[fr.url]
+		for _, frag := range frags {
+			u, err := url.Parse(fr.url)
+			if err != nil {
+				return err
+			}
+			u.Fragment = frag
+			_, missingText, status := pi.checkFragment(u.String(), frag)
+			if missingText != "" || status == fragmentNormalized || status == fragmentMissing {
+				missing = append(missing, frag)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %v", ErrMissingFragment, missing)
+	}
+	return nil
+}
+
+// printRecheck writes a line for each result and a summary,
+// and returns an error if anything is still broken.
+func printRecheck(w io.Writer, results []recheckResult) error {
+	var fixed, broken int
+	for _, rr := range results {
+		if rr.err == nil {
+			fixed++
+			fmt.Fprintf(w, "fixed: %s\n", rr.URL)
+			continue
+		}
+		broken++
+		fmt.Fprintf(w, "still broken: %s: %v\n", rr.URL, rr.err)
+	}
+	fmt.Fprintf(w, "linkrot: recheck fixed=%d broken=%d\n", fixed, broken)
+	if broken > 0 {
+		return ErrBadLinks
+	}
+	return nil
+}
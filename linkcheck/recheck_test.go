@@ -0,0 +1,64 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecheck(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/fixed":
+			io.WriteString(w, `<h1 id="top">fixed</h1>`)
+		case "/frags":
+			io.WriteString(w, `<h1 id="top">still missing #bottom</h1>`)
+		case "/text":
+			io.WriteString(w, `<p>now it says hello world</p>`)
+		case "/normalized":
+			io.WriteString(w, `<h2 id="Intro">only differs by case</h2>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	c := testCrawler(ts.URL + "/")
+	c.workers = 2
+	c.checkTextFragments = true
+	results := c.recheck(context.Background(), []jsonError{
+		{URL: ts.URL + "/fixed", Type: categoryMissingFragment, MissingFragments: []string{"top"}},
+		{URL: ts.URL + "/frags", Type: categoryMissingFragment, MissingFragments: []string{"top", "bottom"}},
+		{URL: ts.URL + "/gone", Type: categoryRequestError},
+		{URL: ts.URL + "/text", Type: categoryMissingFragment, MissingFragments: []string{":~:text=hello", "Top"}},
+		{URL: ts.URL + "/normalized", Type: categoryNormalizedFragment, NormalizedFragments: []string{"intro"}},
+	})
+	if results[0].err != nil {
+		t.Errorf("/fixed: got %v; want fixed", results[0].err)
+	}
+	if !errors.Is(results[1].err, ErrMissingFragment) || !strings.Contains(results[1].err.Error(), "bottom") {
+		t.Errorf("/frags: got %v; want #bottom missing", results[1].err)
+	}
+	if results[2].err == nil {
+		t.Error("/gone: want still broken")
+	}
+	if results[3].err != nil {
+		t.Errorf("/text: got %v; want fixed", results[3].err)
+	}
+	if !errors.Is(results[4].err, ErrMissingFragment) {
+		t.Errorf("/normalized: got %v; want still broken", results[4].err)
+	}
+
+	var buf strings.Builder
+	if err := printRecheck(&buf, results); !errors.Is(err, ErrBadLinks) {
+		t.Errorf("printRecheck err = %v; want ErrBadLinks", err)
+	}
+	if !strings.HasSuffix(buf.String(), "linkrot: recheck fixed=2 broken=3\n") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,214 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// maxRecommendations caps how many redirects and pages are recommended.
+const maxRecommendations = 20
+
+// minQuarantine is how many broken URLs a host needs before it is quarantined.
+const minQuarantine = 3
+
+// blockedStatuses are responses from sites that refuse crawlers
+// rather than from missing pages.
+var blockedStatuses = []int{
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusTooManyRequests,
+	999, // LinkedIn
+}
+
+// recommendations turn the problems found by a crawl
+// into a prioritized worklist for an operator.
+type recommendations struct {
+	// exclusions are hosts that refuse the crawler
+	exclusions []recommendedExclusion
+	// redirects are links that work but should point to their new location
+	redirects []recommendedRedirect
+	// quarantine are hosts that appear to be gone entirely
+	quarantine []quarantinedHost
+	// fixFirst are pages ranked by how many problems fixing them resolves
+	fixFirst []pageImpact
+}
+
+type recommendedExclusion struct {
+	prefix, reason string
+}
+
+type recommendedRedirect struct {
+	from, to string
+	pages    []string
+}
+
+type quarantinedHost struct {
+	host   string
+	broken int
+}
+
+type pageImpact struct {
+	page     string
+	score    int
+	problems int
+}
+
+// recommend analyzes pages and errs. Only pages in scope are ever
+// suggested for fixing, since nothing can be done about the others.
+func recommend(sc scope, pages crawledPages, errs urlErrors) *recommendations {
+	var r recommendations
+
+	// Tally results by external host
+	type hostTally struct {
+		total, failed, blocked int
+	}
+	hosts := make(map[string]*hostTally)
+	var hostOrder []string
+	for link, pi := range pages {
+		if sc.contains(link) {
+			continue
+		}
+		u, err := url.Parse(link)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		origin := u.Scheme + "://" + u.Host + "/"
+		ht := hosts[origin]
+		if ht == nil {
+			ht = &hostTally{}
+			hosts[origin] = ht
+			hostOrder = append(hostOrder, origin)
+		}
+		ht.total++
+		if pi.err != nil {
+			ht.failed++
+			if requests.HasStatusErr(pi.err, blockedStatuses...) {
+				ht.blocked++
+			}
+		}
+	}
+	sort.Strings(hostOrder)
+	for _, origin := range hostOrder {
+		ht := hosts[origin]
+		switch {
+		case ht.failed == 0:
+		case ht.blocked == ht.failed && ht.failed == ht.total:
+			r.exclusions = append(r.exclusions, recommendedExclusion{
+				origin,
+				fmt.Sprintf("all %d requests were refused; the site may block crawlers", ht.total),
+			})
+		case ht.failed == ht.total && ht.failed >= minQuarantine:
+			r.quarantine = append(r.quarantine, quarantinedHost{origin, ht.failed})
+		}
+	}
+	sort.SliceStable(r.quarantine, func(i, j int) bool {
+		return r.quarantine[i].broken > r.quarantine[j].broken
+	})
+
+	// Links to pages that moved
+	moved := make(map[string]*recommendedRedirect)
+	for page, pi := range pages {
+		if !sc.contains(page) {
+			continue
+		}
+		for link := range pi.links {
+			target, ok := pages[link]
+			if !ok || target.redirect == "" {
+				continue
+			}
+			rr := moved[link]
+			if rr == nil {
+				rr = &recommendedRedirect{from: link, to: target.redirect}
+				moved[link] = rr
+			}
+			rr.pages = append(rr.pages, page)
+		}
+	}
+	for _, rr := range moved {
+		sort.Strings(rr.pages)
+		r.redirects = append(r.redirects, *rr)
+	}
+	sort.Slice(r.redirects, func(i, j int) bool {
+		if len(r.redirects[i].pages) != len(r.redirects[j].pages) {
+			return len(r.redirects[i].pages) > len(r.redirects[j].pages)
+		}
+		return r.redirects[i].from < r.redirects[j].from
+	})
+
+	// Score referring pages: broken links count double fragment problems,
+	// and warnings don't count.
+	impact := make(map[string]*pageImpact)
+	for _, pe := range errs {
+		if pe.level() != severityError {
+			continue
+		}
+		weight := 2
+		if pe.missingFragments != nil {
+			weight = 1
+		}
+		for _, page := range pe.refs {
+			pi := impact[page]
+			if pi == nil {
+				pi = &pageImpact{page: page}
+				impact[page] = pi
+			}
+			pi.score += weight
+			pi.problems++
+		}
+	}
+	for _, pi := range impact {
+		r.fixFirst = append(r.fixFirst, *pi)
+	}
+	sort.Slice(r.fixFirst, func(i, j int) bool {
+		if r.fixFirst[i].score != r.fixFirst[j].score {
+			return r.fixFirst[i].score > r.fixFirst[j].score
+		}
+		return r.fixFirst[i].page < r.fixFirst[j].page
+	})
+
+	if len(r.redirects) > maxRecommendations {
+		r.redirects = r.redirects[:maxRecommendations]
+	}
+	if len(r.fixFirst) > maxRecommendations {
+		r.fixFirst = r.fixFirst[:maxRecommendations]
+	}
+	return &r
+}
+
+func (r *recommendations) empty() bool {
+	return len(r.exclusions)+len(r.redirects)+len(r.quarantine)+len(r.fixFirst) == 0
+}
+
+func (r *recommendations) String() string {
+	var buf strings.Builder
+	if len(r.fixFirst) > 0 {
+		fmt.Fprintf(&buf, "Pages to fix first:\n")
+		for _, pi := range r.fixFirst {
+			fmt.Fprintf(&buf, " - %s: %d problems (impact %d)\n", pi.page, pi.problems, pi.score)
+		}
+	}
+	if len(r.redirects) > 0 {
+		fmt.Fprintf(&buf, "Links to update to their redirect targets:\n")
+		for _, rr := range r.redirects {
+			fmt.Fprintf(&buf, " - %s -> %s\n   on: %s\n", rr.from, rr.to, strings.Join(rr.pages, ", "))
+		}
+	}
+	if len(r.quarantine) > 0 {
+		fmt.Fprintf(&buf, "Hosts to quarantine (every link to them is broken):\n")
+		for _, qh := range r.quarantine {
+			fmt.Fprintf(&buf, " - %s: %d broken URLs\n", qh.host, qh.broken)
+		}
+	}
+	if len(r.exclusions) > 0 {
+		fmt.Fprintf(&buf, "Suggested exclusions:\n")
+		for _, re := range r.exclusions {
+			fmt.Fprintf(&buf, " - -exclude %s (%s)\n", re.prefix, re.reason)
+		}
+	}
+	return buf.String()
+}
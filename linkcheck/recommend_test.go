@@ -0,0 +1,52 @@
+package linkcheck
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+)
+
+func TestRecommend(t *testing.T) {
+	const base = "https://example.com/"
+	statusErr := func(code int) error {
+		return fmt.Errorf("wrapped: %w", (*requests.StatusError)(&http.Response{StatusCode: code}))
+	}
+	links := func(ls ...string) map[string]linkContext { return linksWithContext(ls, nil, nil) }
+	pages := crawledPages{
+		base + "a.html": {links: links(
+			base+"old.html",
+			"https://gone.example/1", "https://gone.example/2", "https://gone.example/3",
+			"https://blocked.example/x",
+		)},
+		base + "b.html":             {links: links(base+"old.html", base+"a.html")},
+		base + "old.html":           {redirect: base + "new.html"},
+		"https://gone.example/1":    {err: errors.New("dns")},
+		"https://gone.example/2":    {err: errors.New("dns")},
+		"https://gone.example/3":    {err: errors.New("dns")},
+		"https://blocked.example/x": {err: statusErr(http.StatusForbidden)},
+	}
+	errs := pages.toURLErrors(scope{base: base}, true)
+	r := recommend(scope{base: base}, pages, errs)
+
+	wantRedirects := []recommendedRedirect{
+		{base + "old.html", base + "new.html", []string{base + "a.html", base + "b.html"}},
+	}
+	if !reflect.DeepEqual(r.redirects, wantRedirects) {
+		t.Errorf("redirects = %v; want %v", r.redirects, wantRedirects)
+	}
+	wantQuarantine := []quarantinedHost{{"https://gone.example/", 3}}
+	if !reflect.DeepEqual(r.quarantine, wantQuarantine) {
+		t.Errorf("quarantine = %v; want %v", r.quarantine, wantQuarantine)
+	}
+	if len(r.exclusions) != 1 || r.exclusions[0].prefix != "https://blocked.example/" {
+		t.Errorf("exclusions = %v", r.exclusions)
+	}
+	wantFixFirst := []pageImpact{{base + "a.html", 8, 4}}
+	if !reflect.DeepEqual(r.fixFirst, wantFixFirst) {
+		t.Errorf("fixFirst = %v; want %v", r.fixFirst, wantFixFirst)
+	}
+}
@@ -0,0 +1,161 @@
+package linkcheck
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// loadRedirectRules reads a Netlify _redirects file or, if its name
+// ends in .json, a vercel.json file, and returns expectations for
+// requesting each rule's source path on base. Rules that can't be
+// requested as written, such as those with placeholders or conditions,
+// are skipped and counted.
+func loadRedirectRules(name, base string) (exps []expectation, skipped int, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var rules []redirectRule
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		rules, err = readVercelRedirects(f)
+	} else {
+		rules, err = readNetlifyRedirects(f)
+	}
+	if err == nil {
+		exps, skipped, err = redirectExpectations(rules, base)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading redirects %s: %w", name, err)
+	}
+	return exps, skipped, nil
+}
+
+func redirectExpectations(rules []redirectRule, base string) (exps []expectation, skipped int, err error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, rule := range rules {
+		if rule.conditional || isPattern(rule.from) {
+			skipped++
+			continue
+		}
+		from, err := baseURL.Parse(rule.from)
+		if err != nil {
+			return nil, 0, err
+		}
+		exp := expectation{URL: from.String(), Status: rule.status}
+		// Other statuses, like 200 for rewrites, serve to without redirecting
+		if rule.status >= 300 && rule.status < 400 {
+			exp.Location = rule.to
+		}
+		exps = append(exps, exp)
+	}
+	if err = checkExpectations(exps); err != nil {
+		return nil, 0, err
+	}
+	return exps, skipped, nil
+}
+
+// redirectRule is a redirect declared in a hosting provider's config.
+type redirectRule struct {
+	from, to string
+	status   int
+	// conditional rules only apply to some requests
+	conditional bool
+}
+
+// isPattern reports whether from matches more than one URL,
+// with :placeholders, *splats, or regular expression groups.
+func isPattern(from string) bool {
+	if u, err := url.Parse(from); err == nil {
+		from = u.Path
+	}
+	return strings.ContainsAny(from, ":*(")
+}
+
+// readNetlifyRedirects reads rules in the _redirects format:
+// from, to, and an optional status, separated by spaces.
+// Query parameters between from and to and anything after the status,
+// such as Country=us, are conditions.
+func readNetlifyRedirects(r io.Reader) ([]redirectRule, error) {
+	var rules []redirectRule
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		rule := redirectRule{from: fields[0], status: http.StatusMovedPermanently}
+		rest := fields[1:]
+		// Query parameter conditions come between from and to
+		for len(rest) > 0 && strings.Contains(rest[0], "=") {
+			rule.conditional = true
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return nil, fmt.Errorf("line %d: want from and to paths", n)
+		}
+		rule.to, rest = rest[0], rest[1:]
+		if len(rest) > 0 {
+			// A trailing ! forces the rule even if a file exists
+			status, err := strconv.Atoi(strings.TrimSuffix(rest[0], "!"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad status %q", n, rest[0])
+			}
+			rule.status = status
+			rule.conditional = rule.conditional || len(rest) > 1
+		}
+		// Query parameter conditions in from
+		if strings.Contains(rule.from, "?") {
+			rule.conditional = true
+		}
+		rules = append(rules, rule)
+	}
+	return rules, s.Err()
+}
+
+// readVercelRedirects reads the redirects section of vercel.json.
+func readVercelRedirects(r io.Reader) ([]redirectRule, error) {
+	var config struct {
+		Redirects []struct {
+			Source      string            `json:"source"`
+			Destination string            `json:"destination"`
+			Permanent   *bool             `json:"permanent"`
+			StatusCode  int               `json:"statusCode"`
+			Has         []json.RawMessage `json:"has"`
+			Missing     []json.RawMessage `json:"missing"`
+		} `json:"redirects"`
+	}
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, err
+	}
+	rules := make([]redirectRule, 0, len(config.Redirects))
+	for _, vr := range config.Redirects {
+		rule := redirectRule{
+			from:        vr.Source,
+			to:          vr.Destination,
+			status:      vr.StatusCode,
+			conditional: len(vr.Has) > 0 || len(vr.Missing) > 0,
+		}
+		if rule.status == 0 {
+			// Vercel redirects are permanent unless marked otherwise
+			rule.status = http.StatusPermanentRedirect
+			if vr.Permanent != nil && !*vr.Permanent {
+				rule.status = http.StatusTemporaryRedirect
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
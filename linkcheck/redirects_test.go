@@ -0,0 +1,109 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNetlifyRedirects(t *testing.T) {
+	rules, err := readNetlifyRedirects(strings.NewReader(`
+# Migrated sections
+/old-news        /news
+/temp            /news/today       302!
+/docs            /guide/           200
+/blog/:slug      /news/:slug
+/store id=:id    /products/:id     301
+/uk              /uk/home          302  Country=gb
+https://old.example.com/about  https://www.example.com/about  301!
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exps, skipped, err := redirectExpectations(rules, "https://www.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 3 {
+		t.Errorf("skipped %d rules; want 3", skipped)
+	}
+	want := []expectation{
+		{"https://www.example.com/old-news", 301, "https://www.example.com/news"},
+		{"https://www.example.com/temp", 302, "https://www.example.com/news/today"},
+		{"https://www.example.com/docs", 200, ""},
+		{"https://old.example.com/about", 301, "https://www.example.com/about"},
+	}
+	if !reflect.DeepEqual(exps, want) {
+		t.Errorf("got %v; want %v", exps, want)
+	}
+	for _, bad := range []string{"/lonely\n", "/a /b moved\n"} {
+		if _, err := readNetlifyRedirects(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: want error", bad)
+		}
+	}
+}
+
+func TestVercelRedirects(t *testing.T) {
+	rules, err := readVercelRedirects(strings.NewReader(`{
+  "redirects": [
+    {"source": "/old", "destination": "/new"},
+    {"source": "/temp", "destination": "/new", "permanent": false},
+    {"source": "/legacy", "destination": "https://archive.example.com/", "statusCode": 301},
+    {"source": "/posts/:id", "destination": "/news/:id"},
+    {"source": "/beta", "destination": "/new", "has": [{"type": "cookie", "key": "beta"}]}
+  ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exps, skipped, err := redirectExpectations(rules, "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 2 {
+		t.Errorf("skipped %d rules; want 2", skipped)
+	}
+	want := []expectation{
+		{"https://example.com/old", 308, "https://example.com/new"},
+		{"https://example.com/temp", 307, "https://example.com/new"},
+		{"https://example.com/legacy", 301, "https://archive.example.com/"},
+	}
+	if !reflect.DeepEqual(exps, want) {
+		t.Errorf("got %v; want %v", exps, want)
+	}
+}
+
+func TestRedirectRulesLive(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old-news":
+			http.Redirect(w, r, "/news", http.StatusMovedPermanently)
+		case "/temp":
+			http.Redirect(w, r, "/news", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	rules, err := readNetlifyRedirects(strings.NewReader("/old-news /news\n/temp /news/today 302\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exps, _, err := redirectExpectations(rules, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCrawler("")
+	results := c.expect(context.Background(), exps)
+	if results[0].err != nil {
+		t.Errorf("/old-news: got %v; want ok", results[0].err)
+	}
+	if err := results[1].err; !errors.Is(err, ErrUnexpectedResponse) || !strings.Contains(err.Error(), "/news/today") {
+		t.Errorf("/temp: got %v; want wrong location", err)
+	}
+}
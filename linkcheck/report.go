@@ -0,0 +1,351 @@
+package linkcheck
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/errutil"
+)
+
+// reportSchemaVersion is the major version of schema/report.schema.json.
+// Bump it only for changes that could break existing consumers.
+const reportSchemaVersion = 1
+
+// Report formats
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatHTML = "html"
+)
+
+type jsonReport struct {
+	SchemaVersion int           `json:"schema_version"`
+	Base          string        `json:"base"`
+	Errors        []jsonError   `json:"errors"`
+	Findings      []jsonFinding `json:"findings,omitempty"`
+	Timings       []jsonTiming  `json:"timings,omitempty"`
+	// Recommendations is only present when requested
+	Recommendations *jsonRecommendations `json:"recommendations,omitempty"`
+	// LinkStats is only present when requested
+	LinkStats *jsonLinkStats `json:"link_stats,omitempty"`
+	// OtherSchemes is only present when requested
+	OtherSchemes []jsonOtherScheme `json:"other_schemes,omitempty"`
+	Hosts        []jsonHostUsage   `json:"hosts,omitempty"`
+}
+
+type jsonError struct {
+	URL                 string           `json:"url"`
+	Type                string           `json:"type"`
+	Severity            severity         `json:"severity"`
+	Error               string           `json:"error"`
+	MissingFragments    []string         `json:"missing_fragments,omitempty"`
+	NormalizedFragments []string         `json:"normalized_fragments,omitempty"`
+	StaleOnly           bool             `json:"stale_only,omitempty"`
+	Suggestion          string           `json:"suggestion,omitempty"`
+	Refs                []string         `json:"refs"`
+	RefContexts         []jsonRefContext `json:"ref_contexts,omitempty"`
+}
+
+// jsonRefContext is where on a referring page a link appears.
+type jsonRefContext struct {
+	URL      string `json:"url"`
+	Text     string `json:"text"`
+	Selector string `json:"selector"`
+}
+
+// RefContext returns where the link appears on ref, if known.
+func (je jsonError) RefContext(ref string) *jsonRefContext {
+	for i := range je.RefContexts {
+		if je.RefContexts[i].URL == ref {
+			return &je.RefContexts[i]
+		}
+	}
+	return nil
+}
+
+type jsonFinding struct {
+	URL      string   `json:"url"`
+	Type     string   `json:"type"`
+	Severity severity `json:"severity"`
+	Detail   string   `json:"detail"`
+}
+
+type jsonRecommendations struct {
+	FixFirst   []jsonPageImpact `json:"fix_first"`
+	Redirects  []jsonRedirect   `json:"redirects"`
+	Quarantine []jsonQuarantine `json:"quarantine"`
+	Exclusions []jsonExclusion  `json:"exclusions"`
+}
+
+type jsonPageImpact struct {
+	URL      string `json:"url"`
+	Score    int    `json:"score"`
+	Problems int    `json:"problems"`
+}
+
+type jsonRedirect struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Pages []string `json:"pages"`
+}
+
+type jsonQuarantine struct {
+	Host   string `json:"host"`
+	Broken int    `json:"broken"`
+}
+
+type jsonExclusion struct {
+	Prefix string `json:"prefix"`
+	Reason string `json:"reason"`
+}
+
+func (r *recommendations) toJSON() *jsonRecommendations {
+	jr := &jsonRecommendations{
+		FixFirst:   make([]jsonPageImpact, 0, len(r.fixFirst)),
+		Redirects:  make([]jsonRedirect, 0, len(r.redirects)),
+		Quarantine: make([]jsonQuarantine, 0, len(r.quarantine)),
+		Exclusions: make([]jsonExclusion, 0, len(r.exclusions)),
+	}
+	for _, pi := range r.fixFirst {
+		jr.FixFirst = append(jr.FixFirst, jsonPageImpact{pi.page, pi.score, pi.problems})
+	}
+	for _, rr := range r.redirects {
+		jr.Redirects = append(jr.Redirects, jsonRedirect{rr.from, rr.to, rr.pages})
+	}
+	for _, qh := range r.quarantine {
+		jr.Quarantine = append(jr.Quarantine, jsonQuarantine{qh.host, qh.broken})
+	}
+	for _, re := range r.exclusions {
+		jr.Exclusions = append(jr.Exclusions, jsonExclusion{re.prefix, re.reason})
+	}
+	return jr
+}
+
+type jsonLinkStats struct {
+	MostLinked []jsonLinkedPage `json:"most_linked"`
+	DeadEnds   []string         `json:"dead_ends"`
+}
+
+type jsonLinkedPage struct {
+	URL     string `json:"url"`
+	Inbound int    `json:"inbound"`
+}
+
+func (s *linkStats) toJSON() *jsonLinkStats {
+	js := &jsonLinkStats{
+		MostLinked: make([]jsonLinkedPage, 0, len(s.mostLinked)),
+		DeadEnds:   make([]string, 0, len(s.deadEnds)),
+	}
+	for _, lp := range s.mostLinked {
+		js.MostLinked = append(js.MostLinked, jsonLinkedPage{lp.page, lp.inbound})
+	}
+	js.DeadEnds = append(js.DeadEnds, s.deadEnds...)
+	return js
+}
+
+// jsonOtherScheme is a link that wasn't checked because of its scheme.
+type jsonOtherScheme struct {
+	Link string   `json:"link"`
+	Refs []string `json:"refs"`
+}
+
+// jsonHostUsage is what was requested from a host.
+type jsonHostUsage struct {
+	Host     string `json:"host"`
+	Requests int    `json:"requests"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// jsonTiming is a fetch phase breakdown in milliseconds.
+type jsonTiming struct {
+	URL     string  `json:"url"`
+	DNS     float64 `json:"dns_ms"`
+	Connect float64 `json:"connect_ms"`
+	TLS     float64 `json:"tls_ms"`
+	TTFB    float64 `json:"ttfb_ms"`
+	Body    float64 `json:"body_ms"`
+	Total   float64 `json:"total_ms"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func (res results) toJSON(base string) jsonReport {
+	r := jsonReport{
+		SchemaVersion: reportSchemaVersion,
+		Base:          base,
+		Errors:        make([]jsonError, 0, len(res.errs)),
+	}
+	for _, page := range res.findings.pages() {
+		for _, f := range res.findings[page] {
+			r.Findings = append(r.Findings, jsonFinding{page, f.category, res.severities.of(f.category), f.detail})
+		}
+	}
+	if res.recs != nil {
+		r.Recommendations = res.recs.toJSON()
+	}
+	if res.stats != nil {
+		r.LinkStats = res.stats.toJSON()
+	}
+	for _, link := range res.schemes.links() {
+		r.OtherSchemes = append(r.OtherSchemes, jsonOtherScheme{link, res.schemes[link]})
+	}
+	for _, hu := range res.hosts {
+		r.Hosts = append(r.Hosts, jsonHostUsage{hu.host, hu.requests, hu.bytes})
+	}
+	for _, page := range res.timings.pages() {
+		ft := res.timings[page]
+		r.Timings = append(r.Timings, jsonTiming{
+			URL:     page,
+			DNS:     milliseconds(ft.dns),
+			Connect: milliseconds(ft.connect),
+			TLS:     milliseconds(ft.tls),
+			TTFB:    milliseconds(ft.ttfb),
+			Body:    milliseconds(ft.body),
+			Total:   milliseconds(ft.total),
+		})
+	}
+	for url, pe := range res.errs {
+		refs := append([]string{}, pe.refs...)
+		sort.Strings(refs)
+		var frags, normFrags []string
+		if len(pe.missingFragments) > 0 {
+			frags = setToSlice(pe.missingFragments)
+		}
+		if len(pe.normalizedFragments) > 0 {
+			normFrags = setToSlice(pe.normalizedFragments)
+		}
+		var contexts []jsonRefContext
+		for _, ref := range refs {
+			if lc, ok := pe.refContexts[ref]; ok && (len(contexts) == 0 || contexts[len(contexts)-1].URL != ref) {
+				contexts = append(contexts, jsonRefContext{ref, lc.text, lc.selector})
+			}
+		}
+		r.Errors = append(r.Errors, jsonError{
+			URL:                 url,
+			Type:                pe.category(),
+			Severity:            pe.level(),
+			Error:               pe.err.Error(),
+			MissingFragments:    frags,
+			NormalizedFragments: normFrags,
+			StaleOnly:           pe.staleOnly,
+			Suggestion:          pe.suggestion,
+			Refs:                refs,
+			RefContexts:         contexts,
+		})
+	}
+	sort.Slice(r.Errors, func(i, j int) bool {
+		return r.Errors[i].URL < r.Errors[j].URL
+	})
+	return r
+}
+
+//go:embed report.html
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join":       strings.Join,
+	"pageAnchor": pageAnchor,
+}).Parse(reportHTML))
+
+// categoryTitles are the headings for categories in the HTML report, in order.
+var categoryTitles = []struct{ name, title string }{
+	{categoryRequestError, "Broken links"},
+	{categoryTooManyRedirects, "Too many redirects"},
+	{categoryMissingFragment, "Missing page IDs"},
+	{categoryNormalizedFragment, "Page IDs matching only after normalization"},
+	{categoryLocaleFragment, "Page IDs missing on translated pages"},
+	{categoryFlakyDNS, "Temporary DNS failures"},
+	{categoryParkedDomain, "Content likely gone (parked domains)"},
+	{categorySkippedHost, "Skipped because the host kept failing"},
+}
+
+type htmlCategory struct {
+	Name, Title string
+	Errors      []jsonError
+}
+
+type htmlPage struct {
+	URL    string
+	Errors []jsonError
+}
+
+type htmlReport struct {
+	jsonReport
+	Categories []htmlCategory
+	Pages      []htmlPage
+}
+
+func (res results) toHTMLReport(base string) htmlReport {
+	r := htmlReport{jsonReport: res.toJSON(base)}
+	byCategory := make(map[string][]jsonError)
+	byPage := make(map[string][]jsonError)
+	for _, je := range r.Errors {
+		byCategory[je.Type] = append(byCategory[je.Type], je)
+		for _, ref := range je.Refs {
+			byPage[ref] = append(byPage[ref], je)
+		}
+	}
+	for _, ct := range categoryTitles {
+		if errs := byCategory[ct.name]; len(errs) > 0 {
+			r.Categories = append(r.Categories, htmlCategory{ct.name, ct.title, errs})
+		}
+	}
+	for page, errs := range byPage {
+		r.Pages = append(r.Pages, htmlPage{page, errs})
+	}
+	sort.Slice(r.Pages, func(i, j int) bool {
+		return r.Pages[i].URL < r.Pages[j].URL
+	})
+	return r
+}
+
+// pageAnchor makes a unique HTML id for a referring page.
+func pageAnchor(page string) string {
+	var buf strings.Builder
+	buf.WriteString("page-")
+	for _, b := range []byte(page) {
+		if b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' {
+			buf.WriteByte(b)
+		} else {
+			fmt.Fprintf(&buf, "_%02x", b)
+		}
+	}
+	return buf.String()
+}
+
+// saveReport writes the report to the output file or stdout.
+func (c *crawler) saveReport(res results) (err error) {
+	if c.output == "" {
+		return c.writeReport(os.Stdout, res)
+	}
+	f, err := os.Create(c.output)
+	if err != nil {
+		return err
+	}
+	defer errutil.Defer(&err, f.Close)
+	return c.writeReport(f, res)
+}
+
+func (c *crawler) writeReport(w io.Writer, res results) error {
+	switch c.format {
+	case formatHTML:
+		return reportTemplate.Execute(w, res.toHTMLReport(c.base))
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res.toJSON(c.base))
+	case formatText, "":
+		_, err := fmt.Fprintln(w, res)
+		return err
+	}
+	return fmt.Errorf("unknown report format: %q", c.format)
+}
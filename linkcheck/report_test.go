@@ -0,0 +1,198 @@
+package linkcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestJSONReportSchema(t *testing.T) {
+	b, err := os.ReadFile("../schema/report.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err = json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.FileServer(http.Dir("test-fixtures/sample-site")))
+	defer ts.Close()
+
+	for _, page := range []string{"/404", "/basic-a.html", "/id-bad-a.html", "/id-normalized-a.html"} {
+		c := testCrawler(ts.URL + page)
+		c.format = formatJSON
+		pages, _ := c.crawl()
+		var buf bytes.Buffer
+		res := results{
+			errs:     pages.toURLErrors(c.scope(), true),
+			findings: pages.toFindings(),
+			timings:  pages.toTimings(),
+			recs:     recommend(c.scope(), pages, pages.toURLErrors(c.scope(), true)),
+			stats:    pages.toLinkStats(c.scope()),
+			hosts:    []hostUsage{{"example.com", 2, 1024}},
+		}
+		if err = c.writeReport(&buf, res); err != nil {
+			t.Fatal(err)
+		}
+		var report interface{}
+		if err = json.Unmarshal(buf.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		if err = validateSchema(schema, report, "$"); err != nil {
+			t.Errorf("%s: report does not match schema: %v\n%s", page, err, &buf)
+		}
+	}
+}
+
+// validateSchema checks v against the subset of JSON Schema
+// used by schema/report.schema.json.
+func validateSchema(schema map[string]interface{}, v interface{}, path string) error {
+	if typ, ok := schema["type"].(string); ok {
+		if !hasJSONType(v, typ) {
+			return fmt.Errorf("%s: want %s, got %T", path, typ, v)
+		}
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, v) {
+		return fmt.Errorf("%s: want %v, got %v", path, c, v)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(e, v)
+		}
+		if !found {
+			return fmt.Errorf("%s: %v not in %v", path, v, enum)
+		}
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		req, _ := schema["required"].([]interface{})
+		for _, key := range req {
+			if _, ok := obj[key.(string)]; !ok {
+				return fmt.Errorf("%s: missing required %q", path, key)
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		for key, val := range obj {
+			sub, ok := props[key].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: undocumented property %q", path, key)
+			}
+			if err := validateSchema(sub, val, path+"."+key); err != nil {
+				return err
+			}
+		}
+	}
+	if arr, ok := v.([]interface{}); ok {
+		items, _ := schema["items"].(map[string]interface{})
+		for i, val := range arr {
+			if err := validateSchema(items, val, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hasJSONType(v interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	}
+	return false
+}
+
+func TestHTMLReportOutput(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "report.html")
+	c := crawler{base: "https://example.com/", format: formatHTML, output: out}
+	res := results{
+		errs: urlErrors{
+			"https://example.com/gone": {
+				err:         errTest,
+				refs:        []string{"https://example.com/b", "https://example.com/a"},
+				refContexts: map[string]linkContext{"https://example.com/a": {"<Read more>", "p > a"}},
+			},
+			"https://example.com/page": {
+				err:              ErrMissingFragment,
+				refs:             []string{"https://example.com/a"},
+				missingFragments: map[string]bool{"top": true},
+			},
+		},
+		findings: pageFindings{"https://example.com/a": {{categoryHTMLLint, "unclosed <div>"}}},
+	}
+	if err := c.saveReport(res); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := html.Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headings []string
+	ids := make(map[string]bool)
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if id := attr(n, "id"); id != "" {
+			ids[id] = true
+		}
+		if n.DataAtom == atom.H2 {
+			headings = append(headings, linkText(n))
+		}
+	})
+	want := []string{"Broken links (1)", "Missing page IDs (1)", "Page findings (1)", "Problems by referring page"}
+	if !slices.Equal(headings, want) {
+		t.Errorf("got headings %q; want %q", headings, want)
+	}
+	// Refs link to their section under the referring pages
+	for _, page := range []string{"https://example.com/a", "https://example.com/b"} {
+		if !ids[pageAnchor(page)] {
+			t.Errorf("no section for %s", page)
+		}
+	}
+	for _, want := range []string{"Missing IDs: top", "“&lt;Read more&gt;” at <code>p &gt; a</code>", "unclosed &lt;div&gt;"} {
+		if !bytes.Contains(b, []byte(want)) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+
+	// An empty report says so
+	var buf bytes.Buffer
+	if err = c.writeReport(&buf, results{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "No broken links found.") {
+		t.Errorf("empty report:\n%s", &buf)
+	}
+}
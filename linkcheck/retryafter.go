@@ -0,0 +1,52 @@
+package linkcheck
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRetries is how many times a throttled URL is re-fetched.
+	maxRetries = 3
+	// maxRetryAfter is the longest Retry-After delay that is waited out.
+	maxRetryAfter = 5 * time.Minute
+)
+
+// retryAfter returns how long to wait before re-fetching
+// a throttled response, or 0 if it shouldn't be retried.
+func retryAfter(res *http.Response, now time.Time) time.Duration {
+	if res.StatusCode != http.StatusTooManyRequests &&
+		res.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	d := parseRetryAfter(res.Header.Get("Retry-After"), now)
+	if d > maxRetryAfter {
+		return 0
+	}
+	return d
+}
+
+// parseRetryAfter parses a Retry-After value,
+// which is either a number of seconds or an HTTP date.
+func parseRetryAfter(s string, now time.Time) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(s); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		// Retry right away means try again soon, not zero
+		return time.Duration(secs)*time.Second + time.Millisecond
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+		return time.Millisecond
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	var testcases = []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"soon", 0},
+		{"-1", 0},
+		{"0", time.Millisecond},
+		{"120", 2*time.Minute + time.Millisecond},
+		{"Tue, 01 Jun 2021 12:00:30 GMT", 30 * time.Second},
+		{"Tue, 01 Jun 2021 11:00:00 GMT", time.Millisecond},
+	}
+	for _, test := range testcases {
+		if got := parseRetryAfter(test.in, now); got != test.want {
+			t.Errorf("parseRetryAfter(%q) = %v; want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		tries int
+	)
+	site, _, pages := crawlSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tries++
+		n := tries
+		mu.Unlock()
+		if n < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<html><a href="/404">broken</a></html>`)
+	}), func(c *crawler) {
+		c.strict = true
+	})
+	if pi := pages[site+"/"]; pi.err != nil || pi.throttled != 2 {
+		t.Fatalf("expected page to succeed after 2 retries; got %+v", pi)
+	}
+	if _, ok := pages[site+"/404"]; !ok {
+		t.Errorf("links on retried page were not crawled")
+	}
+}
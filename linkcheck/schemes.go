@@ -0,0 +1,98 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ignoredSchemes are schemes that are never checked but aren't mistakes.
+// javascript: links are reported by -dead-anchors instead.
+var ignoredSchemes = map[string]bool{
+	"mailto":     true,
+	"tel":        true,
+	"sms":        true,
+	"javascript": true,
+	"data":       true,
+}
+
+// missingSchemeRe matches relative links that were meant to be absolute,
+// like http//example.com or www.example.com.
+var missingSchemeRe = regexp.MustCompile(`(?i)^(?:h?tt?ps?[:;]?//|www\.)`)
+
+// otherSchemeLinks returns the raw hrefs of links in doc that aren't checked
+// because of their scheme, such as ftp: and file:,
+// or because a typo in the scheme makes them relative.
+func otherSchemeLinks(doc *html.Node) []string {
+	var links []string
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.A && n.DataAtom != atom.Area) {
+			return
+		}
+		ref := strings.TrimSpace(href(n))
+		if ref != "" && hasOtherScheme(ref) {
+			links = append(links, ref)
+		}
+	})
+	return links
+}
+
+func hasOtherScheme(ref string) bool {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return strings.Contains(ref, ":")
+	}
+	switch scheme := strings.ToLower(u.Scheme); {
+	case scheme == "":
+		return missingSchemeRe.MatchString(ref)
+	case scheme == "http" || scheme == "https":
+		// Like http:example.com or http:/example.com
+		return u.Host == ""
+	default:
+		return !ignoredSchemes[scheme]
+	}
+}
+
+// otherSchemes maps links that weren't checked because of their scheme
+// to the pages they appear on.
+type otherSchemes map[string][]string
+
+func (cp crawledPages) toOtherSchemes() otherSchemes {
+	other := make(otherSchemes)
+	for page, pi := range cp {
+		for _, link := range pi.otherSchemes {
+			if refs := other[link]; len(refs) == 0 || refs[len(refs)-1] != page {
+				other[link] = append(refs, page)
+			}
+		}
+	}
+	for _, refs := range other {
+		sort.Strings(refs)
+	}
+	return other
+}
+
+func (other otherSchemes) links() []string {
+	links := make([]string, 0, len(other))
+	for link := range other {
+		links = append(links, link)
+	}
+	sort.Strings(links)
+	return links
+}
+
+func (other otherSchemes) String() string {
+	var buf strings.Builder
+	for _, link := range other.links() {
+		fmt.Fprintf(&buf, "%q\n", link)
+		for _, ref := range other[link] {
+			fmt.Fprintf(&buf, " - on %s\n", ref)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,76 @@
+package linkcheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestHasOtherScheme(t *testing.T) {
+	cases := []struct {
+		ref  string
+		want bool
+	}{
+		{"https://example.com/", false},
+		{"HTTP://example.com/", false},
+		{"/news/", false},
+		{"story.html", false},
+		{"#top", false},
+		{"//cdn.example.com/a.js", false},
+		{"mailto:tips@example.com", false},
+		{"tel:+17175550100", false},
+		{"javascript:void(0)", false},
+		{"ftp://files.example.com/a.zip", true},
+		{"file:///C:/Users/editor/story.docx", true},
+		{"chrome://settings", true},
+		{"htps://example.com", true},
+		{"http//example.com", true},
+		{"https//example.com", true},
+		{"http:/example.com", true},
+		{"http:example.com", true},
+		{"www.example.com/story", true},
+		{"http://exa mple.com:port", true},
+	}
+	for _, tc := range cases {
+		if got := hasOtherScheme(tc.ref); got != tc.want {
+			t.Errorf("hasOtherScheme(%q) = %t; want %t", tc.ref, got, tc.want)
+		}
+	}
+}
+
+func TestOtherSchemes(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body>
+<a href="ftp://files.example.com/a.zip">Data</a>
+<a href="/news/">News</a>
+<a href=" http//example.com ">Typo</a>
+<map><area href="file:///tmp/map.html"></map>
+<a href="ftp://files.example.com/a.zip">Data again</a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	links := otherSchemeLinks(doc)
+	want := []string{"ftp://files.example.com/a.zip", "http//example.com", "file:///tmp/map.html", "ftp://files.example.com/a.zip"}
+	if !reflect.DeepEqual(links, want) {
+		t.Fatalf("got %q; want %q", links, want)
+	}
+	cp := crawledPages{
+		"https://example.com/b": {otherSchemes: links},
+		"https://example.com/a": {otherSchemes: []string{"http//example.com"}},
+		"https://example.com/c": {},
+	}
+	other := cp.toOtherSchemes()
+	got := other.String()
+	wantText := `"file:///tmp/map.html"
+ - on https://example.com/b
+"ftp://files.example.com/a.zip"
+ - on https://example.com/b
+"http//example.com"
+ - on https://example.com/a
+ - on https://example.com/b
+`
+	if got != wantText {
+		t.Errorf("got:\n%s\nwant:\n%s", got, wantText)
+	}
+}
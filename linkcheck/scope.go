@@ -0,0 +1,90 @@
+package linkcheck
+
+import (
+	"net/url"
+	"strings"
+)
+
+// scope decides which URLs are internal, meaning they are crawled for links.
+// That is URLs under the base URL and, with -include-subdomains,
+// any URL on a subdomain of the base URL's host.
+// A list of URLs has no site: only the list itself is internal,
+// and base is only its name.
+type scope struct {
+	base       string
+	subdomains bool
+	list       bool
+}
+
+func (c *crawler) scope() scope {
+	if c.urlList != nil {
+		return scope{base: c.base, list: true}
+	}
+	return scope{base: c.base, subdomains: c.includeSubdomains}
+}
+
+// contains reports whether link is internal.
+func (s scope) contains(link string) bool {
+	if s.list {
+		return link == s.base
+	}
+	if strings.HasPrefix(link, s.base) {
+		return true
+	}
+	if !s.subdomains {
+		return false
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	base, err := url.Parse(s.base)
+	if err != nil {
+		return false
+	}
+	// The base host itself is limited to the base path
+	baseHost := strings.ToLower(base.Hostname())
+	host := strings.ToLower(u.Hostname())
+	if host == baseHost {
+		return false
+	}
+	// Treat www.example.com as example.com,
+	// so blog.example.com counts as a sibling subdomain
+	domain := strings.TrimPrefix(baseHost, "www.")
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// canonicalHost rewrites links to http/https or www/apex variants
+// of the base URL's origin, such as http://www.example.com/ for
+// a base of https://example.com/, to use the base origin instead.
+// It reports whether link was rewritten.
+func (s scope) canonicalHost(link string) (string, bool) {
+	if s.list {
+		return link, false
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return link, false
+	}
+	base, err := url.Parse(s.base)
+	if err != nil {
+		return link, false
+	}
+	if u.Scheme == base.Scheme && strings.EqualFold(u.Host, base.Host) {
+		return link, false
+	}
+	apex := func(host string) string {
+		return strings.TrimPrefix(strings.ToLower(host), "www.")
+	}
+	if apex(u.Hostname()) != apex(base.Hostname()) {
+		return link, false
+	}
+	// Other ports are other sites, except that http and https
+	// on their default ports are the same site
+	if u.Scheme == base.Scheme && u.Port() != base.Port() ||
+		u.Scheme != base.Scheme && (u.Port() != "" || base.Port() != "") {
+		return link, false
+	}
+	u.Scheme, u.Host = base.Scheme, base.Host
+	return u.String(), true
+}
@@ -0,0 +1,54 @@
+package linkcheck
+
+import "testing"
+
+func TestScope(t *testing.T) {
+	cases := []struct {
+		base       string
+		subdomains bool
+		link       string
+		want       bool
+	}{
+		{"https://example.com/", false, "https://example.com/a", true},
+		{"https://example.com/", false, "https://blog.example.com/a", false},
+		{"https://example.com/", true, "https://blog.example.com/a", true},
+		{"https://example.com/", true, "http://Shop.Example.com/", true},
+		{"https://example.com/", true, "https://notexample.com/", false},
+		{"https://example.com/", true, "mailto:a@blog.example.com", false},
+		{"https://www.example.com/", true, "https://blog.example.com/", true},
+		{"https://www.example.com/", true, "https://example.com/", true},
+		{"https://example.com/news/", false, "https://example.com/about", false},
+		{"https://example.com/news/", true, "https://example.com/about", false},
+		{"https://example.com/news/", true, "https://blog.example.com/about", true},
+	}
+	for _, tc := range cases {
+		sc := scope{base: tc.base, subdomains: tc.subdomains}
+		if got := sc.contains(tc.link); got != tc.want {
+			t.Errorf("scope{%q, %v}.contains(%q) = %v; want %v",
+				tc.base, tc.subdomains, tc.link, got, tc.want)
+		}
+	}
+}
+
+func TestCanonicalHost(t *testing.T) {
+	sc := scope{base: "https://example.com/"}
+	cases := []struct {
+		link, want string
+		ok         bool
+	}{
+		{"https://example.com/a", "https://example.com/a", false},
+		{"http://example.com/a?b#c", "https://example.com/a?b#c", true},
+		{"https://www.example.com/a", "https://example.com/a", true},
+		{"http://WWW.Example.com/", "https://example.com/", true},
+		{"https://blog.example.com/", "https://blog.example.com/", false},
+		{"https://example.com:8443/", "https://example.com:8443/", false},
+		{"http://example.com:8080/", "http://example.com:8080/", false},
+		{"mailto:www.example.com", "mailto:www.example.com", false},
+	}
+	for _, tc := range cases {
+		got, ok := sc.canonicalHost(tc.link)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("canonicalHost(%q) = %q, %v; want %q, %v", tc.link, got, ok, tc.want, tc.ok)
+		}
+	}
+}
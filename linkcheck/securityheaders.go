@@ -0,0 +1,42 @@
+package linkcheck
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// securityHeaders returns a finding listing the security headers
+// missing from a response: Strict-Transport-Security for HTTPS pages,
+// X-Content-Type-Options: nosniff, and Content-Security-Policy.
+// A report-only policy doesn't protect anything, so it doesn't count.
+func securityHeaders(res *http.Response) []finding {
+	var missing []string
+	if res.Request != nil && res.Request.URL.Scheme == "https" && !hasHSTS(res.Header.Get("Strict-Transport-Security")) {
+		missing = append(missing, "Strict-Transport-Security")
+	}
+	if !strings.EqualFold(strings.TrimSpace(res.Header.Get("X-Content-Type-Options")), "nosniff") {
+		missing = append(missing, "X-Content-Type-Options: nosniff")
+	}
+	if strings.TrimSpace(res.Header.Get("Content-Security-Policy")) == "" {
+		missing = append(missing, "Content-Security-Policy")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []finding{{categorySecurityHeader, "missing " + strings.Join(missing, ", ")}}
+}
+
+// hasHSTS reports whether a Strict-Transport-Security header
+// has a max-age that keeps it in effect.
+func hasHSTS(value string) bool {
+	for _, directive := range strings.Split(value, ";") {
+		name, val, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			continue
+		}
+		age, err := strconv.Atoi(strings.Trim(strings.TrimSpace(val), `"`))
+		return err == nil && age > 0
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package linkcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestHasHSTS(t *testing.T) {
+	cases := map[string]bool{
+		"":                                    false,
+		"max-age=31536000":                    true,
+		"max-age=31536000; includeSubDomains": true,
+		`includeSubDomains; Max-Age="600"`:    true,
+		"max-age=0":                           false,
+		"max-age=forever":                     false,
+		"preload":                             false,
+	}
+	for value, want := range cases {
+		if got := hasHSTS(value); got != want {
+			t.Errorf("hasHSTS(%q) = %t; want %t", value, got, want)
+		}
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		url     string
+		headers map[string]string
+		want    []string
+	}{
+		{"http://example.com/", map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"Content-Security-Policy": "default-src 'self'",
+			// Ignored over plain HTTP
+			"Strict-Transport-Security": "max-age=0",
+		}, nil},
+		{"http://example.com/bare", nil, []string{
+			"missing X-Content-Type-Options: nosniff, Content-Security-Policy",
+		}},
+		{"http://example.com/report-only", map[string]string{
+			"X-Content-Type-Options":              "NoSniff",
+			"Content-Security-Policy-Report-Only": "default-src 'self'",
+		}, []string{"missing Content-Security-Policy"}},
+		{"https://example.com/", map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"Content-Security-Policy": "default-src 'self'",
+		}, []string{"missing Strict-Transport-Security"}},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+		res := &http.Response{Request: req, Header: make(http.Header)}
+		for k, v := range tc.headers {
+			res.Header.Set(k, v)
+		}
+		var got []string
+		for _, f := range securityHeaders(res) {
+			if f.category != categorySecurityHeader {
+				t.Errorf("%s: got category %q", tc.url, f.category)
+			}
+			got = append(got, f.detail)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got findings %q; want %q", tc.url, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package linkcheck
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	sentry "github.com/getsentry/sentry-go"
+)
+
+// Ways of grouping Sentry events
+const (
+	sentryGroupURL        = "url"
+	sentryGroupDomain     = "domain"
+	sentryGroupErrorClass = "error-class"
+)
+
+// sentryInit sets up Sentry. If environment or release are blank,
+// they are read from $SENTRY_ENVIRONMENT and $SENTRY_RELEASE.
+func (c *crawler) sentryInit(dsn, environment, release string) {
+	sentry.Init(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: environment,
+		Release:     release,
+	})
+}
+
+// reportToSentry sends errs to Sentry
+// with metadata about the crawl attached to every event.
+func (c *crawler) reportToSentry(errs urlErrors, pages crawledPages, duration time.Duration) {
+	defer sentry.Flush(10 * time.Second)
+	sentry.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetContext("crawl", map[string]interface{}{
+			"base":     c.base,
+			"pages":    len(pages),
+			"errors":   len(errs),
+			"duration": duration.Round(time.Millisecond).String(),
+			"version":  getVersion(),
+		})
+	})
+	groups := make(map[string][]string)
+	for url, pe := range errs {
+		key := c.sentryGroupKey(url, pe)
+		groups[key] = append(groups[key], url)
+	}
+	for key, urls := range groups {
+		if len(urls) == 1 {
+			reportPageErrorToSentry(key, urls[0], errs[urls[0]])
+			continue
+		}
+		sort.Strings(urls)
+		reportGroupToSentry(key, urls, errs)
+	}
+}
+
+// sentryGroupKey returns the fingerprint used to group the error for url.
+func (c *crawler) sentryGroupKey(link string, pe *pageError) string {
+	switch c.sentryGroupBy {
+	case sentryGroupDomain:
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+			return u.Hostname()
+		}
+	case sentryGroupErrorClass:
+		return errorClass(pe)
+	}
+	return link
+}
+
+// errorClass is a coarse description of the kind of problem in pe.
+func errorClass(pe *pageError) string {
+	if se := new(requests.StatusError); errors.As(pe.err, &se) {
+		return fmt.Sprintf("HTTP %d", se.StatusCode)
+	}
+	if d := new(net.DNSError); errors.As(pe.err, &d) {
+		return "DNS error"
+	}
+	return pe.category()
+}
+
+func sentryErrType(pe *pageError) string {
+	switch pe.err {
+	case ErrMissingFragment:
+		return "missing page IDs"
+	case ErrNormalizedFragment:
+		return "normalized page IDs"
+	}
+	return "request error"
+}
+
+// sentryLevel is the Sentry level for pe.
+// Missing fragments are warnings, since the linked page still loads.
+func sentryLevel(pe *pageError) sentry.Level {
+	switch pe.level() {
+	case severityWarning:
+		return sentry.LevelWarning
+	case severityInfo:
+		return sentry.LevelInfo
+	}
+	switch pe.category() {
+	case categoryMissingFragment, categoryLocaleFragment:
+		return sentry.LevelWarning
+	}
+	return sentry.LevelError
+}
+
+// sentryReferrers is the structured context listing every page linking to pe.
+func sentryReferrers(pe *pageError) map[string]interface{} {
+	refs := append([]string{}, pe.refs...)
+	sort.Strings(refs)
+	links := make([]map[string]string, 0, len(pe.refContexts))
+	for _, ref := range refs {
+		if lc, ok := pe.refContexts[ref]; ok {
+			links = append(links, map[string]string{
+				"page":     ref,
+				"text":     lc.text,
+				"selector": lc.selector,
+			})
+		}
+	}
+	return map[string]interface{}{
+		"count": len(refs),
+		"pages": refs,
+		"links": links,
+	}
+}
+
+func reportPageErrorToSentry(fingerprint, url string, pe *pageError) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		event := sentry.NewEvent()
+		scope.SetFingerprint([]string{fingerprint})
+		scope.SetTag("URL", url)
+		scope.SetTag("category", pe.category())
+		scope.SetLevel(sentryLevel(pe))
+		if len(pe.missingFragments) > 0 {
+			frags := setToSlice(pe.missingFragments)
+			scope.SetExtra("missing page IDs", frags)
+		}
+		if len(pe.normalizedFragments) > 0 {
+			frags := setToSlice(pe.normalizedFragments)
+			scope.SetExtra("normalized page IDs", frags)
+		}
+		scope.SetTag("failure type", sentryErrType(pe))
+		scope.SetContext("referring pages", sentryReferrers(pe))
+		event.Exception = []sentry.Exception{{
+			Type:  url,
+			Value: pe.err.Error(),
+		}}
+		sentry.CaptureEvent(event)
+	})
+}
+
+// reportGroupToSentry sends a single event for several failing URLs.
+func reportGroupToSentry(key string, urls []string, errs urlErrors) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		event := sentry.NewEvent()
+		scope.SetFingerprint([]string{key})
+		scope.SetTag("group", key)
+		refs := make(map[string]bool)
+		types := make(map[string]bool)
+		problems := make(map[string]string, len(urls))
+		// use the most severe level in the group
+		level := sentry.LevelInfo
+		for _, url := range urls {
+			pe := errs[url]
+			for _, ref := range pe.refs {
+				refs[ref] = true
+			}
+			types[sentryErrType(pe)] = true
+			problems[url] = pe.err.Error()
+			switch l := sentryLevel(pe); {
+			case l == sentry.LevelError:
+				level = l
+			case l == sentry.LevelWarning && level == sentry.LevelInfo:
+				level = l
+			}
+		}
+		scope.SetLevel(level)
+		scope.SetTag("failure type", strings.Join(setToSlice(types), ", "))
+		scope.SetExtra("URLs", problems)
+		pages := setToSlice(refs)
+		scope.SetContext("referring pages", map[string]interface{}{
+			"count": len(pages),
+			"pages": pages,
+		})
+		event.Exception = []sentry.Exception{{
+			Type:  key,
+			Value: fmt.Sprintf("%d URLs failing", len(urls)),
+		}}
+		sentry.CaptureEvent(event)
+	})
+}
@@ -0,0 +1,151 @@
+package linkcheck
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	sentry "github.com/getsentry/sentry-go"
+)
+
+var errTest = errors.New("unexpected status: 404")
+
+type sentryRecorder struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (sr *sentryRecorder) Flush(time.Duration) bool       { return true }
+func (sr *sentryRecorder) Configure(sentry.ClientOptions) {}
+func (sr *sentryRecorder) SendEvent(e *sentry.Event) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.events = append(sr.events, e)
+}
+
+// recordSentry points Sentry at a recorder until the test ends.
+func recordSentry(t *testing.T) *sentryRecorder {
+	t.Helper()
+	sr := &sentryRecorder{}
+	if err := sentry.Init(sentry.ClientOptions{
+		Dsn:         "https://key@sentry.example/1",
+		Environment: "staging",
+		Release:     "linkrot@test",
+		Transport:   sr,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sentry.Init(sentry.ClientOptions{}) })
+	return sr
+}
+
+func TestReportToSentry(t *testing.T) {
+	sr := recordSentry(t)
+	c := crawler{base: "http://example.com/", sentryGroupBy: sentryGroupURL}
+	errs := urlErrors{
+		"http://example.com/404": &pageError{
+			err:  errTest,
+			refs: []string{"http://example.com/b", "http://example.com/a"},
+		},
+		"http://example.com/page": &pageError{
+			err:              ErrMissingFragment,
+			refs:             []string{"http://example.com/a"},
+			missingFragments: map[string]bool{"top": true},
+		},
+	}
+	c.reportToSentry(errs, crawledPages{}, time.Second)
+
+	levels := make(map[string]sentry.Level)
+	for _, e := range sr.events {
+		if e.Environment != "staging" || e.Release != "linkrot@test" {
+			t.Errorf("event environment=%q release=%q", e.Environment, e.Release)
+		}
+		crawl, _ := e.Contexts["crawl"].(map[string]interface{})
+		if crawl["base"] != c.base {
+			t.Errorf("missing crawl context: %v", e.Contexts)
+		}
+		levels[e.Tags["URL"]] = e.Level
+	}
+	if levels["http://example.com/404"] != sentry.LevelError ||
+		levels["http://example.com/page"] != sentry.LevelWarning {
+		t.Errorf("got levels %v", levels)
+	}
+}
+
+func TestSentryGroupKey(t *testing.T) {
+	notFound := &pageError{err: (*requests.StatusError)(&http.Response{StatusCode: http.StatusNotFound})}
+	noHost := &pageError{err: &net.DNSError{Err: "no such host", IsNotFound: true}}
+	missing := &pageError{err: ErrMissingFragment}
+	for _, test := range []struct {
+		groupBy, link string
+		pe            *pageError
+		want          string
+	}{
+		{sentryGroupURL, "https://example.com/a", notFound, "https://example.com/a"},
+		{"", "https://example.com/a", notFound, "https://example.com/a"},
+		{sentryGroupDomain, "https://example.com:8080/a", notFound, "example.com"},
+		{sentryGroupDomain, "mailto:a@example.com", notFound, "mailto:a@example.com"},
+		{sentryGroupErrorClass, "https://example.com/a", notFound, "HTTP 404"},
+		{sentryGroupErrorClass, "https://gone.example/", noHost, "DNS error"},
+		{sentryGroupErrorClass, "https://example.com/b", missing, categoryMissingFragment},
+	} {
+		c := crawler{sentryGroupBy: test.groupBy}
+		if got := c.sentryGroupKey(test.link, test.pe); got != test.want {
+			t.Errorf("group by %q: sentryGroupKey(%q) = %q; want %q", test.groupBy, test.link, got, test.want)
+		}
+	}
+}
+
+func TestReportGroupToSentry(t *testing.T) {
+	sr := recordSentry(t)
+	c := crawler{base: "http://example.com/", sentryGroupBy: sentryGroupDomain}
+	errs := urlErrors{
+		"http://gone.example/a": &pageError{err: ErrMissingFragment, refs: []string{"http://example.com/x"}},
+		"http://gone.example/b": &pageError{err: errTest, refs: []string{"http://example.com/x", "http://example.com/y"}},
+		"http://example.com/c":  &pageError{err: errTest, refs: []string{"http://example.com/"}},
+	}
+	c.reportToSentry(errs, crawledPages{}, time.Second)
+	if len(sr.events) != 2 {
+		t.Fatalf("got %d events", len(sr.events))
+	}
+	var group *sentry.Event
+	for _, e := range sr.events {
+		if e.Tags["group"] == "gone.example" {
+			group = e
+		}
+	}
+	if group == nil {
+		t.Fatalf("no event for the group: %+v", sr.events)
+	}
+	if !slices.Equal(group.Fingerprint, []string{"gone.example"}) || group.Level != sentry.LevelError ||
+		group.Tags["failure type"] != "missing page IDs, request error" ||
+		len(group.Exception) != 1 || group.Exception[0].Value != "2 URLs failing" {
+		t.Errorf("got event %+v", group)
+	}
+	if refs, _ := group.Contexts["referring pages"].(map[string]interface{}); refs["count"] != 2 {
+		t.Errorf("got referring pages %v", refs)
+	}
+	if urls, _ := group.Extra["URLs"].(map[string]string); len(urls) != 2 {
+		t.Errorf("got URLs %v", group.Extra["URLs"])
+	}
+}
+
+func TestSentryReferrers(t *testing.T) {
+	pe := &pageError{err: errTest}
+	pe.addRef("http://example.com/b", linkContext{"Read more", "p > a"})
+	pe.addRef("http://example.com/a", linkContext{})
+	got := sentryReferrers(pe)
+	pages := got["pages"].([]string)
+	if got["count"] != 2 || pages[0] != "http://example.com/a" {
+		t.Errorf("got %v", got)
+	}
+	links := got["links"].([]map[string]string)
+	if len(links) != 1 || links[0]["text"] != "Read more" {
+		t.Errorf("got links %v", links)
+	}
+}
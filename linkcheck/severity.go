@@ -0,0 +1,93 @@
+package linkcheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severity is how seriously a problem is taken.
+// Errors fail the run, warnings fail it only with -fail-on=warning,
+// and info is only reported.
+type severity string
+
+const (
+	severityError   severity = "error"
+	severityWarning severity = "warning"
+	severityInfo    severity = "info"
+)
+
+// defaultSeverities are the severities of each category of problem
+// unless they are overridden with -severity.
+var defaultSeverities = map[string]severity{
+	categoryRequestError:       severityError,
+	categoryTooManyRedirects:   severityError,
+	categoryMissingFragment:    severityError,
+	categoryLocaleFragment:     severityError,
+	categoryNormalizedFragment: severityWarning,
+	categorySkippedHost:        severityWarning,
+	categoryFlakyDNS:           severityWarning,
+	categoryParkedDomain:       severityWarning,
+	categoryHTMLLint:           severityWarning,
+	categoryTooManyLinks:       severityWarning,
+	categoryCommentedLink:      severityWarning,
+	categoryDeadAnchor:         severityWarning,
+	categoryDuplicateID:        severityWarning,
+	categorySlowResponse:       severityInfo,
+	categoryHighLatency:        severityWarning,
+	categoryBlockedAgent:       severityInfo,
+	categoryNonCanonicalHost:   severityWarning,
+	categoryCrawlerTrap:        severityWarning,
+	categoryDuplicateContent:   severityInfo,
+	categoryShortenedLink:      severityInfo,
+	categoryNoindexLinked:      severityWarning,
+	categoryOneWayHreflang:     severityWarning,
+	categoryHreflangRedirect:   severityWarning,
+	categoryAMPMismatch:        severityWarning,
+	categoryBadTel:             severityWarning,
+	categoryDevHostLink:        severityWarning,
+	categoryEmptyLinkText:      severityWarning,
+	categoryAccessibility:      severityInfo,
+	categoryMissingMetadata:    severityWarning,
+	categoryDuplicateMetadata:  severityWarning,
+	categoryMissingContent:     severityError,
+	categorySecurityHeader:     severityInfo,
+	categoryTokenizedPage:      severityInfo,
+}
+
+// severities overrides the default severities of categories.
+type severities map[string]severity
+
+// of returns the severity of category.
+func (ss severities) of(category string) severity {
+	if sev, ok := ss[category]; ok {
+		return sev
+	}
+	if sev, ok := defaultSeverities[category]; ok {
+		return sev
+	}
+	return severityError
+}
+
+// set parses an override like "missing-fragment=warning".
+func (ss severities) set(s string) error {
+	category, level, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("severity override %q is not category=level", s)
+	}
+	if _, ok := defaultSeverities[category]; !ok {
+		return fmt.Errorf("unknown category: %q", category)
+	}
+	switch sev := severity(level); sev {
+	case severityError, severityWarning, severityInfo:
+		ss[category] = sev
+		return nil
+	}
+	return fmt.Errorf("unknown severity: %q", level)
+}
+
+// setSeverities applies overrides to the errors in ue.
+func (ue urlErrors) setSeverities(ss severities) {
+	for _, pe := range ue {
+		pe.severity = ss.of(pe.category())
+	}
+}
@@ -0,0 +1,73 @@
+package linkcheck
+
+import "testing"
+
+func TestSeverities(t *testing.T) {
+	ss := make(severities)
+	for _, bad := range []string{"missing-fragment", "nope=warning", "missing-fragment=fatal"} {
+		if err := ss.set(bad); err == nil {
+			t.Errorf("set(%q) succeeded; want error", bad)
+		}
+	}
+	if err := ss.set("missing-fragment=warning"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.set("html-lint=error"); err != nil {
+		t.Fatal(err)
+	}
+	var testcases = []struct {
+		category string
+		want     severity
+	}{
+		{categoryMissingFragment, severityWarning},
+		{categoryHTMLLint, severityError},
+		{categoryRequestError, severityError},
+		{categoryNormalizedFragment, severityWarning},
+		{categorySlowResponse, severityInfo},
+		{categoryHighLatency, severityWarning},
+	}
+	for _, test := range testcases {
+		if got := ss.of(test.category); got != test.want {
+			t.Errorf("of(%q) = %q; want %q", test.category, got, test.want)
+		}
+	}
+}
+
+func TestPageErrorLevel(t *testing.T) {
+	errs := urlErrors{
+		"a": &pageError{err: ErrMissingFragment},
+		"b": &pageError{err: ErrMissingFragment, staleOnly: true},
+		"c": &pageError{err: ErrNormalizedFragment},
+		"d": &pageError{err: ErrNormalizedFragment, staleOnly: true},
+	}
+	want := map[string]severity{
+		"a": severityError,
+		"b": severityWarning,
+		"c": severityWarning,
+		"d": severityWarning,
+	}
+	for url, pe := range errs {
+		if got := pe.level(); got != want[url] {
+			t.Errorf("%s: default level = %q; want %q", url, got, want[url])
+		}
+	}
+	errs.setSeverities(severities{
+		categoryMissingFragment:    severityInfo,
+		categoryNormalizedFragment: severityError,
+	})
+	want = map[string]severity{
+		"a": severityInfo,
+		"b": severityInfo,
+		"c": severityError,
+		"d": severityWarning,
+	}
+	for url, pe := range errs {
+		if got := pe.level(); got != want[url] {
+			t.Errorf("%s: overridden level = %q; want %q", url, got, want[url])
+		}
+	}
+	s := newRunSummary(nil, results{errs: errs}, nil, false, 0)
+	if s.info != 2 || s.broken != 1 || s.warnings != 1 || s.status != "failed" {
+		t.Errorf("summary = %v", s)
+	}
+}
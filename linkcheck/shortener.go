@@ -0,0 +1,95 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// shortenerHosts are URL shorteners whose targets are checked too.
+var shortenerHosts = map[string]bool{
+	"bit.ly":      true,
+	"bitly.com":   true,
+	"buff.ly":     true,
+	"dlvr.it":     true,
+	"fb.me":       true,
+	"goo.gl":      true,
+	"is.gd":       true,
+	"lnkd.in":     true,
+	"ow.ly":       true,
+	"t.co":        true,
+	"tinyurl.com": true,
+	"trib.al":     true,
+	"youtu.be":    true,
+}
+
+// isShortener reports whether link is on a URL shortener.
+func isShortener(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return shortenerHosts[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")]
+}
+
+// firstStatus returns the status of the first response in a redirect chain.
+func firstStatus(res *http.Response) int {
+	for res.Request != nil && res.Request.Response != nil {
+		res = res.Request.Response
+	}
+	return res.StatusCode
+}
+
+// metaRefreshTarget returns the normalized target of a meta refresh in doc,
+// as used by shorteners that serve an interstitial page instead of redirecting.
+func metaRefreshTarget(pageurl *url.URL, doc *html.Node) (target string) {
+	pageurl = documentBase(pageurl, doc)
+	visitAll(doc, func(n *html.Node) {
+		if target != "" {
+			return
+		}
+		if link := linkFromMetaRefresh(pageurl, n); link != "" {
+			if norm, err := Normalize(link); err == nil {
+				target = norm
+			}
+		}
+	})
+	return target
+}
+
+// checkShortened records where a shortened link leads and how both the
+// shortener and its target responded. Targets behind an interstitial page
+// are fetched too, since the shortener itself responds 200 even when its
+// target is gone. Errors from a target the shortener redirected to
+// are reported with both statuses.
+func (c *crawler) checkShortened(ctx context.Context, fr *fetchResult) {
+	target, status := fr.redirect, fr.status
+	if fr.err != nil {
+		if target != "" {
+			fr.err = fmt.Errorf("%w %s (shortener returned %d; target returned %d): %w",
+				ErrShortenedTarget, target, fr.shortenerStatus, status, fr.err)
+		}
+		return
+	}
+	if fr.shortTarget != "" && !isShortener(fr.shortTarget) {
+		target = fr.shortTarget
+		tr := fetchResult{url: target}
+		tr.err = c.doFetch(ctx, &tr)
+		status = tr.status
+		if tr.err != nil {
+			fr.err = fmt.Errorf("%w %s (shortener returned %d): %w",
+				ErrShortenedTarget, target, fr.shortenerStatus, tr.err)
+		}
+	}
+	if target == "" {
+		return
+	}
+	fr.findings = append(fr.findings, finding{
+		categoryShortenedLink,
+		fmt.Sprintf("shortener returned %d; %s returned %d", fr.shortenerStatus, target, status),
+	})
+}
@@ -0,0 +1,67 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+)
+
+func TestShortenedLinks(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.Host + r.URL.Path {
+		case "bit.ly/ok":
+			http.Redirect(w, r, "http://live.example/page", http.StatusMovedPermanently)
+		case "bit.ly/gone":
+			http.Redirect(w, r, "http://gone.example/page", http.StatusMovedPermanently)
+		case "t.co/dead":
+			io.WriteString(w, `<head><meta http-equiv="refresh" content="0;URL=http://dead.example/page"></head>`)
+		case "live.example/page":
+			io.WriteString(w, `<p>here</p>`)
+		case "dead.example/page", "gone.example/page":
+			http.NotFound(w, r)
+		default:
+			io.WriteString(w, `<a href="http://bit.ly/ok">ok</a><a href="http://t.co/dead">dead</a><a href="http://bit.ly/gone">gone</a>`)
+		}
+	})
+	_, c, pages := crawlSite(t, h, func(c *crawler) {
+		// Send every host to the test server
+		addr := strings.TrimSuffix(strings.TrimPrefix(c.base, "http://"), "/")
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}
+		c.Client = &http.Client{Transport: tr}
+	})
+	findings := pages.toFindings()
+	fs := findings["http://bit.ly/ok"]
+	if len(fs) != 1 || fs[0].category != categoryShortenedLink ||
+		fs[0].detail != "shortener returned 301; http://live.example/page returned 200" {
+		t.Errorf("bit.ly: got findings %v", fs)
+	}
+	errs := pages.toURLErrors(c.scope(), true)
+	if len(errs) != 2 {
+		t.Fatalf("got errors %v; want t.co and bit.ly/gone", errs)
+	}
+	pe := errs["http://t.co/dead"]
+	if pe == nil || !errors.Is(pe.err, ErrShortenedTarget) ||
+		!strings.Contains(pe.err.Error(), "http://dead.example/page (shortener returned 200)") {
+		t.Errorf("t.co: got %v", pe)
+	}
+	// A redirect to a missing page names the target and both statuses
+	pe = errs["http://bit.ly/gone"]
+	if pe == nil || !errors.Is(pe.err, ErrShortenedTarget) ||
+		!strings.Contains(pe.err.Error(), "http://gone.example/page (shortener returned 301; target returned 404)") {
+		t.Errorf("bit.ly/gone: got %v", pe)
+	}
+	if !requests.HasStatusErr(pe.err, http.StatusNotFound) {
+		t.Errorf("bit.ly/gone: the target's status isn't kept: %v", pe.err)
+	}
+}
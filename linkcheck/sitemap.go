@@ -0,0 +1,105 @@
+package linkcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxSitemaps bounds how many sitemaps are read,
+// counting those listed by sitemap indexes.
+const maxSitemaps = 50
+
+// sitemap is a <urlset> or a <sitemapindex>.
+// See https://www.sitemaps.org/protocol.html
+type sitemap struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod"`
+}
+
+// parseLastmod parses a lastmod in W3C Datetime format,
+// which may be a date without a time.
+func parseLastmod(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sitemapLastmods are the lastmod times of pages in a site's sitemaps,
+// keyed by normalized URL.
+type sitemapLastmods map[string]time.Time
+
+// add records the lastmod times in a sitemap
+// and returns the sitemaps it lists, if it's an index.
+func (sl sitemapLastmods) add(body []byte) (sitemaps []string, err error) {
+	var sm sitemap
+	if err = xml.Unmarshal(body, &sm); err != nil {
+		return nil, err
+	}
+	for _, e := range sm.URLs {
+		t, ok := parseLastmod(e.Lastmod)
+		if !ok {
+			continue
+		}
+		if link, err := Normalize(strings.TrimSpace(e.Loc)); err == nil {
+			sl[link] = t
+		}
+	}
+	for _, e := range sm.Sitemaps {
+		if loc := strings.TrimSpace(e.Loc); loc != "" {
+			sitemaps = append(sitemaps, loc)
+		}
+	}
+	return sitemaps, nil
+}
+
+// loadSitemaps reads the lastmod times in /sitemap.xml on the base URL's origin
+// and in the sitemaps it lists, which must be on the same origin.
+// Sitemaps that can't be read are skipped, since many sites don't have one.
+func (c *crawler) loadSitemaps(ctx context.Context) sitemapLastmods {
+	sl := make(sitemapLastmods)
+	origin := variantOrigin(c.base)
+	if c.urlList != nil || !strings.HasPrefix(origin, "http") {
+		return sl
+	}
+	queue := []string{origin + "sitemap.xml"}
+	seen := make(map[string]bool)
+	for len(queue) > 0 && len(seen) < maxSitemaps {
+		loc := queue[0]
+		queue = queue[1:]
+		if seen[loc] || variantOrigin(loc) != origin {
+			continue
+		}
+		seen[loc] = true
+		var body bytes.Buffer
+		err := c.request(loc, c.userAgentFor(loc)).
+			CheckStatus(http.StatusOK).
+			AddValidator(c.limitBody).
+			ToBytesBuffer(&body).
+			Fetch(ctx)
+		if err != nil {
+			c.Debug("could not read sitemap", "url", loc, "error", err)
+			continue
+		}
+		sitemaps, err := sl.add(body.Bytes())
+		if err != nil {
+			c.Info("ignoring unparsable sitemap", "url", loc, "error", err)
+			continue
+		}
+		queue = append(queue, sitemaps...)
+	}
+	c.Debug("read sitemaps", "sitemaps", len(seen), "pages", len(sl))
+	return sl
+}
@@ -0,0 +1,91 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemapLastmods(t *testing.T) {
+	sl := make(sitemapLastmods)
+	sitemaps, err := sl.add([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc> https://example.com/a </loc><lastmod>2020-01-02</lastmod></url>
+<url><loc>https://example.com/b#top</loc><lastmod>2020-01-02T03:04:05-05:00</lastmod></url>
+<url><loc>https://example.com/c</loc><lastmod>2020-01-02T03:04Z</lastmod></url>
+<url><loc>https://example.com/d</loc><lastmod>last week</lastmod></url>
+<url><loc>https://example.com/e</loc></url>
+</urlset>`))
+	if err != nil || sitemaps != nil {
+		t.Fatalf("got sitemaps %q and error %v", sitemaps, err)
+	}
+	want := sitemapLastmods{
+		"https://example.com/a": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		"https://example.com/b": time.Date(2020, 1, 2, 8, 4, 5, 0, time.UTC),
+		"https://example.com/c": time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC),
+	}
+	if len(sl) != len(want) {
+		t.Fatalf("got %v; want %v", sl, want)
+	}
+	for link, wt := range want {
+		if !sl[link].Equal(wt) {
+			t.Errorf("%s: got %v; want %v", link, sl[link], wt)
+		}
+	}
+
+	sitemaps, err = sl.add([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>https://example.com/posts.xml</loc></sitemap>
+<sitemap><loc>https://example.com/pages.xml</loc><lastmod>2020-01-02</lastmod></sitemap>
+</sitemapindex>`))
+	if err != nil || !reflect.DeepEqual(sitemaps, []string{"https://example.com/posts.xml", "https://example.com/pages.xml"}) {
+		t.Errorf("got sitemaps %q and error %v", sitemaps, err)
+	}
+	if _, err = sl.add([]byte(`<html>`)); err == nil {
+		t.Error("want an error for a page that isn't a sitemap")
+	}
+}
+
+func TestSitemapStaleness(t *testing.T) {
+	ext := httptest.NewServer(http.NotFoundHandler())
+	defer ext.Close()
+	var site string
+	recent := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	pages := map[string]string{
+		"/": `<a href="/old">old</a><a href="/new">new</a>`,
+		// The sitemap is trusted over the page's own metadata
+		"/old": fmt.Sprintf(`<head><meta property="article:modified_time" content="%s"></head>
+<body><a href="%s/old">gone</a>`, recent, ext.URL),
+		"/new": fmt.Sprintf(`<a href="%s/new">gone</a>`, ext.URL),
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%[1]s/posts.xml</loc></sitemap>
+<sitemap><loc>%[2]s/elsewhere.xml</loc></sitemap></sitemapindex>`, site, ext.URL)
+		case "/posts.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/old</loc><lastmod>2015-06-01</lastmod></url>
+<url><loc>%[1]s/new</loc><lastmod>%[2]s</lastmod></url></urlset>`, site, recent)
+		default:
+			htmlSite(pages).ServeHTTP(w, r)
+		}
+	})
+	site, c, crawled := crawlSite(t, h, func(c *crawler) {
+		site = strings.TrimSuffix(c.base, "/")
+		c.staleContentAge = 365 * 24 * time.Hour
+	})
+	if got := crawled[site+"/old"].modified; got.Year() != 2015 {
+		t.Errorf("/old: got modified %v; want the sitemap's lastmod", got)
+	}
+	errs := crawled.toURLErrors(c.scope(), true)
+	c.markStaleOnly(crawled, errs)
+	if pe := errs[ext.URL+"/old"]; pe == nil || !pe.staleOnly {
+		t.Errorf("link from /old: got %v; want a stale-only error", pe)
+	}
+	if pe := errs[ext.URL+"/new"]; pe == nil || pe.staleOnly {
+		t.Errorf("link from /new: got %v; want an error", pe)
+	}
+}
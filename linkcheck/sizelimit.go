@@ -0,0 +1,49 @@
+package linkcheck
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// limitBody is a validator that fails responses larger than the size limits,
+// so an endpoint streaming a huge body can't tie up a crawler or exhaust memory.
+// Responses the transport decompressed are held to the decompressed limit.
+func (c *crawler) limitBody(res *http.Response) error {
+	max := c.maxBodySize
+	if res.Uncompressed {
+		max = c.maxDecompressedSize
+	}
+	if max <= 0 {
+		return nil
+	}
+	if res.ContentLength > max {
+		return fmt.Errorf("%w: Content-Length is %d bytes; limit is %d",
+			ErrTooLarge, res.ContentLength, max)
+	}
+	res.Body = &limitedBody{res.Body, max, max}
+	return nil
+}
+
+// limitedBody is like io.LimitReader, but returns ErrTooLarge
+// instead of io.EOF if the body goes on past the limit.
+type limitedBody struct {
+	io.ReadCloser
+	max, left int64
+}
+
+func (lb *limitedBody) Read(p []byte) (int, error) {
+	if lb.left <= 0 {
+		var b [1]byte
+		if n, err := lb.ReadCloser.Read(b[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: body is over the limit of %d bytes", ErrTooLarge, lb.max)
+	}
+	if int64(len(p)) > lb.left {
+		p = p[:lb.left]
+	}
+	n, err := lb.ReadCloser.Read(p)
+	lb.left -= int64(n)
+	return n, err
+}
@@ -0,0 +1,51 @@
+package linkcheck
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLimitBody(t *testing.T) {
+	page := `<a href="/big">big</a><a href="/unsized">unsized</a><a href="/bomb">bomb</a>`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, page)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, strings.Repeat("<p>big</p>", 1000))
+	})
+	mux.HandleFunc("/unsized", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		for i := 0; i < 100; i++ {
+			io.WriteString(w, strings.Repeat("<p>x</p>", 10))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/bomb", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, strings.Repeat("<p>bomb</p>", 100_000))
+		zw.Close()
+	})
+	site, _, pages := crawlSite(t, mux, func(c *crawler) {
+		c.strict = true
+		c.maxBodySize = int64(len(page)) + 100
+		c.maxDecompressedSize = 10_000
+	})
+	if pi, ok := pages[site+"/"]; !ok || pi.err != nil {
+		t.Fatalf("want home page to be checked; got %v", pi)
+	}
+	for _, path := range []string{"/big", "/unsized", "/bomb"} {
+		pi, ok := pages[site+path]
+		if !ok || !errors.Is(pi.err, ErrTooLarge) {
+			t.Errorf("%s: want ErrTooLarge; got %v", path, pi)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package linkcheck
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// pageModified guesses when a page's content was last changed
+// for pages without a lastmod in the sitemap,
+// preferring Open Graph article metadata over the Last-Modified header,
+// which static hosts often set to the time of the last deploy.
+// It returns the zero time if the page gives no indication.
+func pageModified(doc *html.Node, lastModified string) time.Time {
+	var modified, published time.Time
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Meta {
+			return
+		}
+		var t *time.Time
+		switch attr(n, "property") {
+		case "article:modified_time":
+			t = &modified
+		case "article:published_time":
+			t = &published
+		default:
+			return
+		}
+		if parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(attr(n, "content"))); err == nil {
+			*t = parsed
+		}
+	})
+	if !modified.IsZero() {
+		return modified
+	}
+	if !published.IsZero() {
+		return published
+	}
+	if t, err := http.ParseTime(lastModified); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// isStale reports whether a page modified at t is older than
+// the stale content threshold.
+func (c *crawler) isStale(t time.Time) bool {
+	return c.staleContentAge > 0 && !t.IsZero() &&
+		time.Since(t) > c.staleContentAge
+}
+
+// markStaleOnly relaxes errors for external URLs that are only
+// linked from stale pages, so they are reported as warnings.
+func (c *crawler) markStaleOnly(pages crawledPages, errs urlErrors) {
+	for url, pe := range errs {
+		if c.scope().contains(url) || len(pe.refs) == 0 {
+			continue
+		}
+		staleOnly := true
+		for _, ref := range pe.refs {
+			staleOnly = staleOnly && c.isStale(pages[ref].modified)
+		}
+		pe.staleOnly = staleOnly
+	}
+}
@@ -0,0 +1,235 @@
+package linkcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kinds of streamEvent
+const (
+	eventError   = "error"
+	eventFinding = "finding"
+	eventSkipped = "skipped"
+	eventDone    = "done"
+)
+
+// maxStreamEvents is how many events a findingStream keeps
+// for subscribers that fall behind or connect late.
+const maxStreamEvents = 10_000
+
+// streamEvent is a line of the /findings stream.
+// Errors and findings have the same fields as in JSON reports.
+type streamEvent struct {
+	Event   string       `json:"event"`
+	Error   *jsonError   `json:"error,omitempty"`
+	Finding *jsonFinding `json:"finding,omitempty"`
+	// Skipped is how many events a subscriber missed
+	// because they were dropped before it read them
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// findingStream keeps the errors and findings of a crawl as they come in,
+// so /findings subscribers can act on them before the report is written.
+// The last maxStreamEvents events are kept for subscribers that connect late.
+type findingStream struct {
+	*crawler
+	mu     sync.Mutex
+	events [][]byte
+	// dropped is how many events were removed from the front of events
+	dropped int
+	done    bool
+	// changed is closed and replaced whenever events are added
+	changed chan struct{}
+}
+
+// crawlFindings are the categories of findings that compare pages,
+// so they're only found once the crawl is over.
+var crawlFindings = map[string]bool{
+	categoryDuplicateContent:  true,
+	categoryNoindexLinked:     true,
+	categoryOneWayHreflang:    true,
+	categoryHreflangRedirect:  true,
+	categoryAMPMismatch:       true,
+	categoryDuplicateMetadata: true,
+}
+
+// serveFindings streams the crawl's problems at /findings on addr
+// until stop is called. If secret isn't empty,
+// requests must carry it as a bearer token;
+// it can only be empty for loopback addresses.
+// It returns the address it listens on.
+func (c *crawler) serveFindings(addr, secret string) (listening string, stop func(), err error) {
+	if secret == "" && !isLoopback(addr) {
+		return "", nil, fmt.Errorf("-findings-secret is required to listen on %s", addr)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, err
+	}
+	c.findings = newFindingStream(c)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/findings", c.findings.handleFindings)
+	srv := &http.Server{Handler: requireBearer(secret, mux)}
+	go srv.Serve(l)
+	return l.Addr().String(), func() { srv.Close() }, nil
+}
+
+func newFindingStream(c *crawler) *findingStream {
+	return &findingStream{
+		crawler: c,
+		changed: make(chan struct{}),
+	}
+}
+
+// add appends events, dropping the oldest past maxStreamEvents,
+// and wakes up subscribers. Call with fs.mu held.
+func (fs *findingStream) add(events ...streamEvent) {
+	for _, ev := range events {
+		b, err := json.Marshal(ev)
+		if err != nil {
+			fs.Error("could not stream event", "event", ev.Event, "error", err)
+			continue
+		}
+		fs.events = append(fs.events, append(b, '\n'))
+	}
+	if over := len(fs.events) - maxStreamEvents; over > 0 {
+		fs.events = fs.events[over:]
+		fs.dropped += over
+	}
+	close(fs.changed)
+	fs.changed = make(chan struct{})
+}
+
+// since returns the events after the first n ever added, how many of those
+// were already dropped, a channel closed when there are more,
+// and whether the stream is over.
+func (fs *findingStream) since(n int) (events [][]byte, skipped int, changed <-chan struct{}, done bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if n < fs.dropped {
+		skipped = fs.dropped - n
+		n = fs.dropped
+	}
+	return fs.events[n-fs.dropped:], skipped, fs.changed, fs.done
+}
+
+// publish streams the error and findings of a result added to cp.
+// Errors list the internal pages crawled so far that link to them.
+func (fs *findingStream) publish(fr fetchResult, cp crawledPages) {
+	if fs == nil {
+		return
+	}
+	var events []streamEvent
+	if fr.err != nil {
+		pe := &pageError{err: fr.err, suggestion: fr.suggestion}
+		pe.severity = fs.severities.of(pe.category())
+		refs := cp.refsTo(fs.scope(), fr.url)
+		events = append(events, streamEvent{Event: eventError, Error: &jsonError{
+			URL:        fr.url,
+			Type:       pe.category(),
+			Severity:   pe.level(),
+			Error:      fr.err.Error(),
+			Suggestion: pe.suggestion,
+			Refs:       refs,
+		}})
+	}
+	for _, f := range fr.findings {
+		events = append(events, streamEvent{Event: eventFinding, Finding: &jsonFinding{
+			fr.url, f.category, fs.severities.of(f.category), f.detail,
+		}})
+	}
+	if len(events) > 0 {
+		fs.mu.Lock()
+		fs.add(events...)
+		fs.mu.Unlock()
+	}
+}
+
+// refsTo returns the internal pages in cp that link to url, sorted.
+// Links are compared as written, without their fragments,
+// which is quick enough to do for each error as it's found;
+// the report's refs also match links that only normalize to url.
+func (cp crawledPages) refsTo(sc scope, url string) []string {
+	var refs []string
+	for page, pi := range cp {
+		if !sc.contains(page) {
+			continue
+		}
+		for rawLink := range pi.links {
+			if link, _, _ := strings.Cut(rawLink, "#"); link == url {
+				refs = append(refs, page)
+				break
+			}
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// finish streams the problems only found once the crawl was over,
+// such as missing fragments and duplicate pages, and ends the stream.
+// Errors of pages that failed to load and findings on single pages
+// were already streamed by publish.
+func (fs *findingStream) finish(res results, cp crawledPages) {
+	if fs == nil {
+		return
+	}
+	r := res.toJSON(fs.base)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var events []streamEvent
+	for i := range r.Errors {
+		if je := &r.Errors[i]; cp[je.URL].err == nil {
+			events = append(events, streamEvent{Event: eventError, Error: je})
+		}
+	}
+	for i := range r.Findings {
+		if jf := &r.Findings[i]; crawlFindings[jf.Type] {
+			events = append(events, streamEvent{Event: eventFinding, Finding: jf})
+		}
+	}
+	fs.done = true
+	fs.add(append(events, streamEvent{Event: eventDone})...)
+}
+
+// handleFindings streams errors and page findings as newline delimited JSON
+// as they are found, starting from the oldest event kept.
+// Events dropped before a subscriber read them are counted in a skipped event.
+// It ends with a done event once the crawl is over.
+func (fs *findingStream) handleFindings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+	for n := 0; ; {
+		events, skipped, changed, done := fs.since(n)
+		if skipped > 0 {
+			b, _ := json.Marshal(streamEvent{Event: eventSkipped, Skipped: skipped})
+			if _, err := w.Write(append(b, '\n')); err != nil {
+				return
+			}
+			n += skipped
+		}
+		for _, b := range events {
+			if _, err := w.Write(b); err != nil {
+				return
+			}
+		}
+		n += len(events)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if done {
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package linkcheck
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func readStream(t *testing.T, body io.Reader) []streamEvent {
+	t.Helper()
+	var events []streamEvent
+	s := bufio.NewScanner(body)
+	for s.Scan() {
+		var ev streamEvent
+		if err := json.Unmarshal(s.Bytes(), &ev); err != nil {
+			t.Fatalf("bad event %q: %v", s.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func getFindings(url, secret string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+secret)
+	return http.DefaultClient.Do(req)
+}
+
+func TestFindingStream(t *testing.T) {
+	ts := coordinatedSite()
+	defer ts.Close()
+
+	c := testCrawler(ts.URL + "/")
+	c.strict = true
+	addr, stop, err := c.serveFindings("127.0.0.1:0", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	res, err := getFindings("http://"+addr+"/findings", "wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong secret got status %d", res.StatusCode)
+	}
+
+	res, err = getFindings("http://"+addr+"/findings", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("got content type %q", ct)
+	}
+	// The broken link is streamed before the crawl is over
+	lines := bufio.NewReader(res.Body)
+	streamed := make(chan streamEvent, 1)
+	go func() {
+		var ev streamEvent
+		line, _ := lines.ReadBytes('\n')
+		json.Unmarshal(line, &ev)
+		streamed <- ev
+	}()
+
+	pages, _ := c.crawlContext(context.Background())
+	select {
+	case ev := <-streamed:
+		if ev.Event != eventError || ev.Error.URL != ts.URL+"/missing" ||
+			ev.Error.Type != categoryRequestError || ev.Error.Severity != severityError ||
+			!slices.Equal(ev.Error.Refs, []string{ts.URL + "/"}) {
+			t.Errorf("first event: %+v", ev.Error)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("broken link wasn't streamed during the crawl")
+	}
+
+	r := results{
+		errs:       pages.toURLErrors(c.scope(), true),
+		findings:   pages.toFindings(),
+		severities: c.severities,
+	}
+	c.findings.finish(r, pages)
+	rest := readStream(t, lines)
+	if len(rest) != 2 ||
+		rest[0].Event != eventError || rest[0].Error.Type != categoryMissingFragment ||
+		rest[1].Event != eventDone {
+		t.Errorf("events after the crawl: %+v", rest)
+	}
+
+	// Late subscribers get everything
+	res, err = getFindings("http://"+addr+"/findings", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if all := readStream(t, res.Body); len(all) != 3 {
+		t.Errorf("late subscriber got %+v", all)
+	}
+}
+
+func TestFindingsSecretRequired(t *testing.T) {
+	c := &crawler{}
+	if _, _, err := c.serveFindings(":0", ""); err == nil {
+		t.Error("served findings on every interface without a secret")
+	}
+	addr, stop, err := c.serveFindings("localhost:0", "")
+	if err != nil {
+		t.Fatalf("loopback without a secret: %v", err)
+	}
+	stop()
+	if !isLoopback(addr) || isLoopback("0.0.0.0:80") || isLoopback("example.com:80") || !isLoopback("[::1]:80") {
+		t.Errorf("isLoopback is wrong")
+	}
+}
+
+func TestFindingStreamLimit(t *testing.T) {
+	fs := newFindingStream(&crawler{})
+	fs.mu.Lock()
+	for i := 0; i < maxStreamEvents+5; i++ {
+		fs.add(streamEvent{Event: eventFinding})
+	}
+	fs.mu.Unlock()
+	if len(fs.events) != maxStreamEvents {
+		t.Errorf("kept %d events", len(fs.events))
+	}
+	events, skipped, _, _ := fs.since(0)
+	if skipped != 5 || len(events) != maxStreamEvents {
+		t.Errorf("since(0) skipped %d and got %d events", skipped, len(events))
+	}
+	events, skipped, _, _ = fs.since(maxStreamEvents + 3)
+	if skipped != 0 || len(events) != 2 {
+		t.Errorf("since(n) skipped %d and got %d events", skipped, len(events))
+	}
+}
+
+func TestFindingStreamFinish(t *testing.T) {
+	fs := newFindingStream(&crawler{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	cp := crawledPages{
+		"https://example.com/gone": {err: errTest},
+		"https://example.com/page": {findings: []finding{
+			{categoryHTMLLint, "streamed when the page was fetched"},
+			{categoryDuplicateContent, "only found after the crawl"},
+		}},
+	}
+	res := results{
+		errs: urlErrors{
+			"https://example.com/gone": {err: errTest},
+			"https://example.com/page": {err: ErrMissingFragment, missingFragments: map[string]bool{"top": true}},
+		},
+		findings: cp.toFindings(),
+	}
+	fs.finish(res, cp)
+	events, _, _, done := fs.since(0)
+	var got []string
+	for _, b := range events {
+		var ev streamEvent
+		if err := json.Unmarshal(b, &ev); err != nil {
+			t.Fatal(err)
+		}
+		switch ev.Event {
+		case eventError:
+			got = append(got, ev.Error.Type)
+		case eventFinding:
+			got = append(got, ev.Finding.Type)
+		default:
+			got = append(got, ev.Event)
+		}
+	}
+	want := []string{categoryMissingFragment, categoryDuplicateContent, eventDone}
+	if !done || !slices.Equal(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
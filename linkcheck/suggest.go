@@ -0,0 +1,133 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxExternalSuggestions is how many missing URLs on each external host
+// are probed for corrections, so other sites only get a few extra requests.
+const maxExternalSuggestions = 5
+
+// trailingJunk is punctuation often pasted onto the end of a link by accident.
+var trailingJunk = []string{".", ",", ";", ":", "!", ")", "]", "}", "'", `"`, "%20", "%22", "%27", "%29"}
+
+// fixCandidates returns plausible corrections for a URL that responded 404,
+// most likely first.
+func fixCandidates(link string) []string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	add := func(u *url.URL) {
+		s := u.String()
+		if s == link {
+			return
+		}
+		for _, c := range candidates {
+			if c == s {
+				return
+			}
+		}
+		candidates = append(candidates, s)
+	}
+
+	// Trailing punctuation on the query or path
+	trimmed := *u
+	if trimmed.RawQuery != "" {
+		trimmed.RawQuery = trimJunk(trimmed.RawQuery)
+	} else {
+		trimmed.RawPath = ""
+		trimmed.Path = trimJunk(u.EscapedPath())
+		if p, err := url.PathUnescape(trimmed.Path); err == nil {
+			trimmed.Path = p
+		}
+	}
+	add(&trimmed)
+
+	// With or without a trailing slash
+	if u.Path != "" && u.Path != "/" {
+		slashed := *u
+		if strings.HasSuffix(u.Path, "/") {
+			slashed.Path = strings.TrimSuffix(u.Path, "/")
+			slashed.RawPath = strings.TrimSuffix(u.RawPath, "/")
+		} else {
+			slashed.Path = u.Path + "/"
+			if u.RawPath != "" {
+				slashed.RawPath = u.RawPath + "/"
+			}
+		}
+		add(&slashed)
+	}
+
+	// Without the query string
+	if u.RawQuery != "" {
+		bare := *u
+		bare.RawQuery = ""
+		add(&bare)
+	}
+
+	// Over https
+	if u.Scheme == "http" {
+		secure := *u
+		secure.Scheme = "https"
+		add(&secure)
+	}
+	return candidates
+}
+
+func trimJunk(s string) string {
+	for {
+		trimmed := s
+		for _, junk := range trailingJunk {
+			trimmed = strings.TrimSuffix(trimmed, junk)
+		}
+		if trimmed == s {
+			return s
+		}
+		s = trimmed
+	}
+}
+
+// suggestionBudget counts the missing external URLs probed on each host.
+type suggestionBudget struct {
+	mu     sync.Mutex
+	probed map[string]int
+}
+
+func newSuggestionBudget() *suggestionBudget {
+	return &suggestionBudget{probed: make(map[string]int)}
+}
+
+// allow reports whether another missing URL on link's host may be probed.
+func (sb *suggestionBudget) allow(link string) bool {
+	host := hostname(link)
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	if sb.probed[host] >= maxExternalSuggestions {
+		return false
+	}
+	sb.probed[host]++
+	return true
+}
+
+// suggestFix probes the candidate corrections for a missing URL
+// and returns the first that responds 200, or "" if none do.
+// Candidates are requested like crawled pages, including -max-body-size.
+func (c *crawler) suggestFix(ctx context.Context, link string) string {
+	for _, candidate := range fixCandidates(link) {
+		err := c.request(candidate, c.userAgentFor(candidate)).
+			CheckStatus(http.StatusOK).
+			AddValidator(c.limitBody).
+			Fetch(ctx)
+		if err == nil {
+			c.Debug("found a fix for missing URL", "url", link, "suggestion", candidate)
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package linkcheck
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFixCandidates(t *testing.T) {
+	for in, want := range map[string][]string{
+		"http://example.com/story).": {
+			"http://example.com/story",
+			"http://example.com/story)./",
+			"https://example.com/story).",
+		},
+		"https://example.com/a/?utm=x%22": {
+			"https://example.com/a/?utm=x",
+			"https://example.com/a?utm=x%22",
+			"https://example.com/a/",
+		},
+		"https://example.com/": nil,
+	} {
+		if got := fixCandidates(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("fixCandidates(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestSuggestFix(t *testing.T) {
+	ext := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/docs/" {
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<p>docs</p>`)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer ext.Close()
+	site, c, pages := crawlSite(t, htmlSite(map[string]string{
+		"/":      fmt.Sprintf(`<a href="/docs">docs</a><a href="/gone">gone</a><a href="/big">big</a><a href="%s/docs">external</a>`, ext.URL),
+		"/big/":  strings.Repeat(" ", 1024),
+		"/docs/": `<p>docs</p>`,
+	}), func(c *crawler) {
+		c.suggestFixes = true
+		c.maxBodySize = 512
+	})
+	errs := pages.toURLErrors(c.scope(), true)
+	if pe := errs[site+"/docs"]; pe == nil || pe.suggestion != site+"/docs/" {
+		t.Errorf("/docs: got %v; want suggestion of /docs/", pe)
+	}
+	if pe := errs[site+"/gone"]; pe == nil || pe.suggestion != "" {
+		t.Errorf("/gone: got %v; want no suggestion", pe)
+	}
+	// Corrections are subject to -max-body-size like other requests
+	if pe := errs[site+"/big"]; pe == nil || pe.suggestion != "" {
+		t.Errorf("/big: got %v; want no suggestion", pe)
+	}
+	if pe := errs[ext.URL+"/docs"]; pe == nil || pe.suggestion != ext.URL+"/docs/" {
+		t.Errorf("external: got %v; want suggestion of /docs/", pe)
+	}
+}
+
+func TestSuggestionBudget(t *testing.T) {
+	sb := newSuggestionBudget()
+	for i := 0; i < maxExternalSuggestions; i++ {
+		if !sb.allow(fmt.Sprintf("https://example.com/%d", i)) {
+			t.Fatalf("probe %d wasn't allowed", i)
+		}
+	}
+	if sb.allow("https://example.com/more") {
+		t.Error("probes past the limit were allowed")
+	}
+	if !sb.allow("https://example.org/") {
+		t.Error("other hosts share the limit")
+	}
+}
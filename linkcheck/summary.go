@@ -0,0 +1,160 @@
+package linkcheck
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// runSummary is the verdict of a run, printed as a single
+// machine-parsable line regardless of report format.
+type runSummary struct {
+	status    string
+	broken    int
+	fragments int
+	warnings  int
+	pages     int
+	throttled int
+	skipped   int
+	unchecked int
+	info      int
+	requests  int
+	bytes     int64
+	// hosts are the requests and bytes for each host, most bytes first
+	hosts     []hostUsage
+	cancelled bool
+	duration  time.Duration
+	delivery  []deliveryStatus
+}
+
+// summaryHosts is how many hosts the summary line breaks usage down for.
+const summaryHosts = 3
+
+// Values for -fail-on
+const (
+	failOnError   = "error"
+	failOnWarning = "warning"
+)
+
+func newRunSummary(pages crawledPages, res results, delivery []deliveryStatus, cancelled bool, duration time.Duration) runSummary {
+	s := runSummary{
+		cancelled: cancelled,
+		duration:  duration,
+		delivery:  delivery,
+	}
+	for _, pi := range pages {
+		if pi.unchecked {
+			s.unchecked++
+			continue
+		}
+		s.pages++
+		if pi.throttled > 0 {
+			s.throttled++
+		}
+	}
+	for _, fs := range res.findings {
+		for _, f := range fs {
+			s.count(res.severities.of(f.category), f.category)
+		}
+	}
+	for _, pe := range res.errs {
+		if pe.category() == categorySkippedHost {
+			s.skipped++
+		}
+		s.count(pe.level(), pe.category())
+	}
+	s.gate(failOnError, 0)
+	return s
+}
+
+// count tallies a problem by severity. Errors other than
+// missing fragments count as broken.
+func (s *runSummary) count(sev severity, category string) {
+	switch {
+	case sev == severityInfo:
+		s.info++
+	case sev == severityWarning:
+		s.warnings++
+	case category == categoryMissingFragment,
+		category == categoryLocaleFragment:
+		s.fragments++
+	default:
+		s.broken++
+	}
+}
+
+// gate sets the status of s and returns the error that sets the exit code.
+// Problems count against maxErrors, including warnings
+// if failOn is failOnWarning. The exit code is 3 if the run was cancelled,
+// otherwise if there are more than maxErrors problems,
+// 4 if any links are broken, 5 if only fragments are missing,
+// and 6 if there are only warnings.
+func (s *runSummary) gate(failOn string, maxErrors int) error {
+	problems := s.broken + s.fragments
+	if failOn == failOnWarning {
+		problems += s.warnings
+	}
+	var err error
+	switch {
+	case s.cancelled:
+		s.status = "cancelled"
+		return ErrCancelled
+	case problems <= maxErrors:
+		s.status = "ok"
+		return nil
+	case s.broken > 0:
+		err = ErrBadLinks
+	case s.fragments > 0:
+		err = ErrBadFragments
+	default:
+		err = ErrWarnings
+	}
+	s.status = "failed"
+	return err
+}
+
+func (s runSummary) String() string {
+	line := fmt.Sprintf("linkrot: status=%s broken=%d fragments=%d warnings=%d pages=%d duration=%s",
+		s.status, s.broken, s.fragments, s.warnings, s.pages, s.duration.Round(time.Second))
+	if s.throttled > 0 {
+		line += fmt.Sprintf(" throttled=%d", s.throttled)
+	}
+	if s.info > 0 {
+		line += fmt.Sprintf(" info=%d", s.info)
+	}
+	if s.skipped > 0 {
+		line += fmt.Sprintf(" skipped=%d", s.skipped)
+	}
+	if s.unchecked > 0 {
+		line += fmt.Sprintf(" unchecked=%d", s.unchecked)
+	}
+	if s.requests > 0 {
+		line += fmt.Sprintf(" requests=%d bytes=%d", s.requests, s.bytes)
+	}
+	if len(s.hosts) > 0 {
+		hosts := make([]string, 0, summaryHosts)
+		for _, hu := range s.hosts[:min(len(s.hosts), summaryHosts)] {
+			hosts = append(hosts, fmt.Sprintf("%s:%d:%d", hu.host, hu.requests, hu.bytes))
+		}
+		line += " top_hosts=" + strings.Join(hosts, ",")
+	}
+	if len(s.delivery) > 0 {
+		statuses := make([]string, len(s.delivery))
+		for i, ds := range s.delivery {
+			statuses[i] = ds.String()
+		}
+		line += " delivery=" + strings.Join(statuses, ",")
+	}
+	return line
+}
+
+// summaryWriter is where the summary line goes.
+// It's stdout unless a machine-readable report is already there.
+func (c *crawler) summaryWriter() io.Writer {
+	if c.output == "" && c.format != formatText {
+		return os.Stderr
+	}
+	return os.Stdout
+}
@@ -0,0 +1,59 @@
+package linkcheck
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/exitcode"
+)
+
+func TestRunSummaryGate(t *testing.T) {
+	var testcases = []struct {
+		name      string
+		s         runSummary
+		failOn    string
+		maxErrors int
+		status    string
+		code      int
+	}{
+		{"clean", runSummary{}, failOnError, 0, "ok", 0},
+		{"broken", runSummary{broken: 1, fragments: 1}, failOnError, 0, "failed", 4},
+		{"fragments", runSummary{fragments: 2}, failOnError, 0, "failed", 5},
+		{"warnings ignored", runSummary{warnings: 3}, failOnError, 0, "ok", 0},
+		{"warnings", runSummary{warnings: 3}, failOnWarning, 0, "failed", 6},
+		{"under max", runSummary{broken: 1, fragments: 1}, failOnError, 2, "ok", 0},
+		{"over max", runSummary{broken: 1, fragments: 1}, failOnError, 1, "failed", 4},
+		{"warnings count toward max", runSummary{broken: 1, warnings: 1}, failOnWarning, 1, "failed", 4},
+		{"cancelled", runSummary{cancelled: true}, failOnError, 0, "cancelled", 3},
+	}
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			s := test.s
+			err := s.gate(test.failOn, test.maxErrors)
+			if s.status != test.status {
+				t.Errorf("status = %q; want %q", s.status, test.status)
+			}
+			if code := exitcode.Get(err); code != test.code {
+				t.Errorf("exit code = %d; want %d", code, test.code)
+			}
+		})
+	}
+}
+
+func TestRunSummaryHosts(t *testing.T) {
+	s := runSummary{
+		status:   "ok",
+		requests: 10,
+		bytes:    4000,
+		hosts: []hostUsage{
+			{"example.com", 5, 3000},
+			{"cdn.example.com:8080", 2, 600},
+			{"a.example.net", 2, 300},
+			{"b.example.net", 1, 100},
+		},
+	}
+	want := " requests=10 bytes=4000 top_hosts=example.com:5:3000,cdn.example.com:8080:2:600,a.example.net:2:300"
+	if got := s.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("got %q; want it to end with %q", got, want)
+	}
+}
@@ -0,0 +1,225 @@
+package linkcheck
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// crawlSupervisor runs a crawl: it hands out URLs from the frontier
+// to a fetch pool, records the results, and queues the links they find
+// until there's nothing left to fetch. Only its run goroutine uses it.
+type crawlSupervisor struct {
+	*crawler
+	q       frontier
+	crawled crawledPages
+	pool    stringPool
+	follows *followTracker
+	limiter *aimd
+	breaker *hostBreaker
+	traps   *trapDetector
+	// lastmods are the sitemap's lastmod times for -stale-content-age
+	lastmods sitemapLastmods
+
+	// open is how many fetches we're waiting on
+	open int
+	// errored is how many fetches failed, for the progress line
+	errored int
+	// retryCh gets throttled URLs once they've waited out their Retry-After
+	retryCh        chan string
+	pendingRetries int
+	// retryReady are throttled URLs ready to fetch again, ahead of the queue
+	retryReady []string
+	retries    map[string]int
+}
+
+func (c *crawler) newSupervisor(q frontier) *crawlSupervisor {
+	s := &crawlSupervisor{
+		crawler: c,
+		q:       q,
+		crawled: newCrawledPages(),
+		pool:    make(stringPool),
+		retryCh: make(chan string),
+		retries: make(map[string]int),
+	}
+	if c.respectNofollow == nofollowCheck {
+		s.follows = newFollowTracker(c.base)
+	}
+	if c.adaptive {
+		s.limiter = newAIMD(c.scope(), c.workers)
+	}
+	if c.hostFailureLimit > 0 {
+		s.breaker = newHostBreaker(c.scope(), c.hostFailureLimit)
+	}
+	if c.maxPagesPerPattern > 0 {
+		s.traps = newTrapDetector(c.maxPagesPerPattern)
+	}
+	return s
+}
+
+// record adds a result to the database
+// and streams its problems to -findings-addr subscribers.
+func (s *crawlSupervisor) record(fr fetchResult) {
+	// The sitemap says when a page's content changed more reliably than the page
+	if t, ok := s.lastmods[fr.url]; ok {
+		fr.modified = t
+	}
+	s.crawled.add(fr, s.pool)
+	s.findings.publish(fr, s.crawled)
+}
+
+// queueFrom queues the links of pageurl and of any pages it releases
+// from the follow tracker, if it's a page whose links should be crawled.
+func (s *crawlSupervisor) queueFrom(pageurl string) {
+	// Only queue links on pages under root
+	if !s.scope().contains(pageurl) || (s.singlePage && pageurl != s.base) {
+		return
+	}
+	for _, page := range s.follows.pagesToQueue(s.crawled, pageurl) {
+		stale := s.isStale(s.crawled[page].modified)
+		s.crawled.addLinksToQueue(page, s.q, func(link string) bool {
+			return stale && !s.scope().contains(link)
+		})
+	}
+}
+
+// seedList records the -url-list as the links of the root page.
+func (s *crawlSupervisor) seedList(urls []string) {
+	s.q.pophead()
+	fr := fetchResult{url: s.base}
+	s.addLinks(&fr, s.base, urls)
+	s.record(fr)
+	s.queueFrom(s.base)
+}
+
+// next returns the URL to fetch next, or false if there isn't one yet.
+// URLs that shouldn't be fetched are recorded as skipped along the way.
+func (s *crawlSupervisor) next() (string, bool) {
+	for {
+		var url string
+		switch {
+		case len(s.retryReady) > 0:
+			url = s.retryReady[0]
+		case !s.q.empty():
+			url = s.q.head()
+		default:
+			return "", false
+		}
+		switch {
+		case s.internalOnly && !s.scope().contains(url):
+			s.pop()
+			s.record(fetchResult{url: url, unchecked: true})
+		// Retries were already counted
+		case s.traps != nil && len(s.retryReady) == 0 &&
+			s.scope().contains(url) && !s.traps.allow(url):
+			s.pop()
+			s.record(fetchResult{url: url, unchecked: true, findings: s.traps.skip(url)})
+		case s.breaker != nil && !s.breaker.allow(url):
+			s.pop()
+			s.record(fetchResult{url: url, throttled: s.retries[url], err: ErrHostUnhealthy})
+		default:
+			return url, true
+		}
+	}
+}
+
+// pop removes the URL returned by next.
+func (s *crawlSupervisor) pop() {
+	if len(s.retryReady) > 0 {
+		s.retryReady = s.retryReady[1:]
+	} else {
+		s.q.pophead()
+	}
+}
+
+// handOut pops url once a worker has taken it.
+func (s *crawlSupervisor) handOut(url string) {
+	s.open++
+	// Retries were already counted
+	if len(s.retryReady) > 0 {
+		s.pop()
+		return
+	}
+	s.pop()
+	if s.traps != nil && s.scope().contains(url) && s.traps.count(url) {
+		s.Warn("too many pages match a URL pattern; skipping the rest",
+			"pattern", urlPattern(url), "limit", s.maxPagesPerPattern)
+	}
+}
+
+// handle records a fetch result and queues its links,
+// or schedules a retry if the URL was throttled.
+func (s *crawlSupervisor) handle(ctx context.Context, result fetchResult) {
+	s.open--
+	if s.limiter != nil && s.limiter.observe(result) {
+		s.Info("adjusted concurrent crawlers", "crawlers", s.limiter.limit)
+	}
+	if s.breaker != nil && s.breaker.observe(result) {
+		s.Warn("host keeps failing; skipping the rest of its URLs",
+			"host", hostname(result.url), "failures", s.hostFailureLimit)
+	}
+	if result.retryAfter > 0 && s.retries[result.url] < maxRetries {
+		s.retries[result.url]++
+		s.pendingRetries++
+		s.Info("throttled; retrying",
+			"url", result.url, "status", result.status, "retry_after", result.retryAfter)
+		url := result.url
+		time.AfterFunc(result.retryAfter, func() {
+			select {
+			case s.retryCh <- url:
+			case <-ctx.Done():
+			}
+		})
+		return
+	}
+	result.throttled = s.retries[result.url]
+	if result.err != nil {
+		s.errored++
+	}
+	s.record(result)
+	s.queueFrom(result.url)
+}
+
+// run hands out URLs to fp and handles its results until there's
+// nothing left to fetch or the crawl is cancelled.
+func (s *crawlSupervisor) run(fp *fetchPool) (cancelled bool) {
+	ctx := fp.ctx
+	var ticks <-chan time.Time
+	var prog *progress
+	if s.progress {
+		prog = newProgress(os.Stderr)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+		defer prog.clear()
+	}
+	for {
+		url, ok := s.next()
+		if !ok && s.open == 0 && s.pendingRetries == 0 {
+			return false
+		}
+		jobs := fp.jobs
+		// A nil channel never receives, so nothing is handed out
+		if !ok || (s.limiter != nil && s.open >= s.limiter.limit) {
+			jobs = nil
+		}
+		select {
+		case jobs <- url:
+			s.handOut(url)
+
+		case <-ticks:
+			queued := s.q.len() + len(s.retryReady) + s.pendingRetries + s.open
+			prog.update(len(s.crawled), queued, s.errored)
+
+		case url := <-s.retryCh:
+			s.pendingRetries--
+			s.retryReady = append(s.retryReady, url)
+
+		case result := <-fp.results:
+			s.handle(ctx, result)
+
+		case <-ctx.Done():
+			return true
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Digits in a phone number: E.164 numbers have at most 15,
+// and international ones need at least a country code and a few more.
+// Local numbers can be as short as an emergency number.
+const (
+	maxTelDigits              = 15
+	minInternationalTelDigits = 7
+	minLocalTelDigits         = 3
+)
+
+// telSeparators are the visual separators allowed in tel: numbers (RFC 3966).
+const telSeparators = " -.()"
+
+// telDialChars are dialable characters other than digits:
+// the keypad's * and # (RFC 3966) and the , pause phones support,
+// as in tel:+1-555-0100,,123 for an extension.
+const telDialChars = "*#,"
+
+// badTelLinks returns findings for tel: links in doc with numbers
+// that phones can't dial, such as ones with letters or too few digits.
+func badTelLinks(doc *html.Node) []finding {
+	var findings []finding
+	visitAll(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.A && n.DataAtom != atom.Area) {
+			return
+		}
+		href := strings.TrimSpace(attr(n, "href"))
+		number, ok := cutPrefixFold(href, "tel:")
+		if !ok {
+			return
+		}
+		if problem := telProblem(number); problem != "" {
+			findings = append(findings, finding{categoryBadTel, "tel: link " + problem + ": " + describeLink(n).String()})
+		}
+	})
+	return findings
+}
+
+// telProblem describes what's wrong with the number in a tel: link, if anything.
+func telProblem(number string) string {
+	if unescaped, err := url.PathUnescape(number); err == nil {
+		number = unescaped
+	}
+	// Parameters like ;ext=123 follow the number
+	number, _, _ = strings.Cut(number, ";")
+	international := strings.HasPrefix(number, "+")
+	digits := 0
+	for _, r := range strings.TrimPrefix(number, "+") {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case strings.ContainsRune(telSeparators, r),
+			strings.ContainsRune(telDialChars, r):
+		case r == '+':
+			return "has + after the start"
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			return "has letters"
+		default:
+			return fmt.Sprintf("has unexpected %q", r)
+		}
+	}
+	switch {
+	case digits == 0:
+		return "has no number"
+	case digits > maxTelDigits:
+		return "has too many digits"
+	case international && digits < minInternationalTelDigits,
+		!international && digits < minLocalTelDigits:
+		return "has too few digits"
+	}
+	return ""
+}
+
+func cutPrefixFold(s, prefix string) (after string, found bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
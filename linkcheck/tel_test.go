@@ -0,0 +1,45 @@
+package linkcheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestBadTelLinks(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body><footer id="contact">
+<a href="tel:+1-717-555-0100">Newsroom</a>
+<a href="TEL:(717)%20555-0100;ext=12">Desk</a>
+<a href="tel:911">Emergency</a>
+<a href="tel:1-800-FLOWERS">Flowers</a>
+<a href="tel:">Call</a>
+<a href="tel:+44 20">London</a>
+<a href="tel:717+555">Typo</a>
+<a href="tel:717_555_0100">Underscores</a>
+<a href="mailto:tips@example.com">Tips</a>
+<a href="tel:+1-555-0100,,123">Extension</a>
+<a href="tel:*67%23717-555-0100">Blocked caller ID</a>
+</footer></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range badTelLinks(doc) {
+		if f.category != categoryBadTel {
+			t.Errorf("unexpected category %q", f.category)
+		}
+		got = append(got, f.detail)
+	}
+	want := []string{
+		`tel: link has letters: "Flowers" at #contact > a:nth-child(4)`,
+		`tel: link has no number: "Call" at #contact > a:nth-child(5)`,
+		`tel: link has too few digits: "London" at #contact > a:nth-child(6)`,
+		`tel: link has + after the start: "Typo" at #contact > a:nth-child(7)`,
+		`tel: link has unexpected '_': "Underscores" at #contact > a:nth-child(8)`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
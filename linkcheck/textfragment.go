@@ -0,0 +1,143 @@
+package linkcheck
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// fragmentDirectiveDelimiter separates a fragment's ID from its directives,
+// as in #section:~:text=quoted%20words. Directives aren't IDs.
+const fragmentDirectiveDelimiter = ":~:"
+
+// textDirective is a scroll-to-text fragment,
+// text=[prefix-,]start[,end][,-suffix].
+type textDirective struct {
+	prefix, start, end, suffix string
+}
+
+// parseTextDirectives returns the text directives in link's fragment.
+// Malformed directives are ignored.
+func parseTextDirectives(link string) []textDirective {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil
+	}
+	// Commas and dashes are only syntax when they aren't percent-encoded
+	_, raw, ok := strings.Cut(u.EscapedFragment(), fragmentDirectiveDelimiter)
+	if !ok {
+		return nil
+	}
+	var tds []textDirective
+	for _, directive := range strings.Split(raw, "&") {
+		value, ok := strings.CutPrefix(directive, "text=")
+		if !ok {
+			continue
+		}
+		if td, ok := parseTextDirective(value); ok {
+			tds = append(tds, td)
+		}
+	}
+	return tds
+}
+
+func parseTextDirective(value string) (td textDirective, ok bool) {
+	parts := strings.Split(value, ",")
+	if p, ok := strings.CutSuffix(parts[0], "-"); ok && len(parts) > 1 {
+		td.prefix, parts = p, parts[1:]
+	}
+	if s, ok := strings.CutPrefix(parts[len(parts)-1], "-"); ok && len(parts) > 1 {
+		td.suffix, parts = s, parts[:len(parts)-1]
+	}
+	switch len(parts) {
+	case 1:
+		td.start = parts[0]
+	case 2:
+		td.start, td.end = parts[0], parts[1]
+	default:
+		return td, false
+	}
+	for _, s := range []*string{&td.prefix, &td.start, &td.end, &td.suffix} {
+		unescaped, err := url.PathUnescape(*s)
+		if err != nil {
+			return td, false
+		}
+		*s = normalizeText(unescaped)
+	}
+	return td, td.start != ""
+}
+
+// normalizeText folds case and whitespace the way browsers do
+// when searching for text fragments, near enough.
+func normalizeText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// pageText returns the normalized visible text of doc.
+func pageText(doc *html.Node) string {
+	var buf strings.Builder
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			buf.WriteString(n.Data)
+			buf.WriteByte(' ')
+			return
+		case n.Type == html.ElementNode:
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Head:
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+	return normalizeText(buf.String())
+}
+
+// foundIn reports whether text has a match for td.
+func (td textDirective) foundIn(text string) bool {
+	for i := 0; i < len(text); {
+		j := strings.Index(text[i:], td.start)
+		if j < 0 {
+			return false
+		}
+		if td.matchesAt(text, i+j) {
+			return true
+		}
+		i += j + 1
+	}
+	return false
+}
+
+func (td textDirective) matchesAt(text string, pos int) bool {
+	before := strings.TrimSuffix(text[:pos], " ")
+	if td.prefix != "" && !strings.HasSuffix(before, td.prefix) {
+		return false
+	}
+	end := pos + len(td.start)
+	if td.end != "" {
+		k := strings.Index(text[end:], td.end)
+		if k < 0 {
+			return false
+		}
+		end += k + len(td.end)
+	}
+	after := strings.TrimPrefix(text[end:], " ")
+	return td.suffix == "" || strings.HasPrefix(after, td.suffix)
+}
+
+// textFragmentFound reports whether every text directive
+// in link's fragment has a match in text.
+func textFragmentFound(link, text string) bool {
+	for _, td := range parseTextDirectives(link) {
+		if !td.foundIn(text) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseTextDirectives(t *testing.T) {
+	cases := []struct {
+		link string
+		want []textDirective
+	}{
+		{"https://example.com/#intro", nil},
+		{"https://example.com/#:~:text=Hello%20World", []textDirective{{start: "hello world"}}},
+		{"https://example.com/#intro:~:text=the-,quick,lazy,-dog", []textDirective{{"the", "quick", "lazy", "dog"}}},
+		{"https://example.com/#:~:text=a%2Cb&unknown=1&text=c,d", []textDirective{{start: "a,b"}, {start: "c", end: "d"}}},
+		{"https://example.com/#:~:text=a,b,c", nil},
+		{"https://example.com/#:~:text=", nil},
+	}
+	for _, tc := range cases {
+		if got := parseTextDirectives(tc.link); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseTextDirectives(%q) = %+v; want %+v", tc.link, got, tc.want)
+		}
+	}
+}
+
+func TestTextFragmentFound(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head><title>Ignored title</title></head><body>
+<p>The quick   brown fox jumps over the lazy dog.</p><script>var hidden = "secret";</script>
+<p>A quick recap follows.</p>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := pageText(doc)
+	cases := []struct {
+		frag string
+		want bool
+	}{
+		{"text=QUICK%20BROWN", true},
+		{"text=quick,dog", true},
+		{"text=the-,quick", true},
+		{"text=a-,quick,-recap", true},
+		{"text=the-,quick,-recap", false},
+		{"text=lazy,fox", false},
+		{"text=secret", false},
+		{"text=ignored", false},
+		{"text=quick&text=missing", false},
+	}
+	for _, tc := range cases {
+		link := "https://example.com/#:~:" + tc.frag
+		if got := textFragmentFound(link, text); got != tc.want {
+			t.Errorf("textFragmentFound(%q) = %t; want %t", tc.frag, got, tc.want)
+		}
+	}
+}
+
+func TestTextFragments(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			io.WriteString(w, `<a href="/b#:~:text=budget%20vote">ok</a><a href="/b#:~:text=tax%20cut">gone</a>
+<a href="/b#intro:~:text=budget">id</a>`)
+			return
+		}
+		io.WriteString(w, `<body><p id="intro">The budget vote is Tuesday.</p>`)
+	}))
+	defer ts.Close()
+
+	for _, check := range []bool{false, true} {
+		c := testCrawler(ts.URL + "/")
+		c.checkTextFragments = check
+		pages, _ := c.crawl()
+		errs := pages.toURLErrors(c.scope(), true)
+		if !check {
+			if len(errs) != 0 {
+				t.Errorf("text fragments treated as IDs: %v", errs)
+			}
+			continue
+		}
+		pe := errs[ts.URL+"/b"]
+		if len(errs) != 1 || pe == nil || pe.err != ErrMissingFragment ||
+			!reflect.DeepEqual(pe.missingFragments, map[string]bool{":~:text=tax cut": true}) {
+			t.Errorf("want only the tax cut text missing; got %v", errs)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package linkcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fetchTimings breaks down where the time went while fetching a URL,
+// so slowness can be pinned on DNS, the network, or the remote host.
+// Phases are summed across redirects.
+type fetchTimings struct {
+	dns, connect, tls, ttfb, body, total time.Duration
+}
+
+func (ft fetchTimings) String() string {
+	r := func(d time.Duration) time.Duration { return d.Round(time.Microsecond) }
+	return fmt.Sprintf("dns=%s connect=%s tls=%s ttfb=%s body=%s total=%s",
+		r(ft.dns), r(ft.connect), r(ft.tls), r(ft.ttfb), r(ft.body), r(ft.total))
+}
+
+// LogValue logs the phases as a group.
+func (ft fetchTimings) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Duration("dns", ft.dns),
+		slog.Duration("connect", ft.connect),
+		slog.Duration("tls", ft.tls),
+		slog.Duration("ttfb", ft.ttfb),
+		slog.Duration("body", ft.body),
+		slog.Duration("total", ft.total),
+	)
+}
+
+// timingTrace collects fetchTimings from httptrace hooks.
+type timingTrace struct {
+	mu                                 sync.Mutex
+	start, dnsStart, connStart         time.Time
+	tlsStart, reqStart, firstByte, end time.Time
+	t                                  fetchTimings
+	now                                func() time.Time
+}
+
+// withTimingTrace returns a context whose requests are timed by tt.
+func withTimingTrace(ctx context.Context) (_ context.Context, tt *timingTrace) {
+	return traceTimings(ctx, time.Now)
+}
+
+// traceTimings is withTimingTrace with a clock.
+func traceTimings(ctx context.Context, now func() time.Time) (_ context.Context, tt *timingTrace) {
+	tt = &timingTrace{start: now(), now: now}
+	// since records the time since *from and clears it,
+	// so that overlapping dials are only counted once.
+	since := func(from *time.Time) time.Duration {
+		if from.IsZero() {
+			return 0
+		}
+		d := now().Sub(*from)
+		*from = time.Time{}
+		return d
+	}
+	mark := func(f func()) {
+		tt.mu.Lock()
+		defer tt.mu.Unlock()
+		f()
+	}
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			mark(func() { tt.reqStart = now() })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mark(func() { tt.dnsStart = now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mark(func() { tt.t.dns += since(&tt.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			mark(func() {
+				if tt.connStart.IsZero() {
+					tt.connStart = now()
+				}
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			mark(func() { tt.t.connect += since(&tt.connStart) })
+		},
+		TLSHandshakeStart: func() {
+			mark(func() { tt.tlsStart = now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mark(func() { tt.t.tls += since(&tt.tlsStart) })
+		},
+		GotFirstResponseByte: func() {
+			mark(func() {
+				tt.firstByte = now()
+				if !tt.reqStart.IsZero() {
+					tt.t.ttfb += tt.firstByte.Sub(tt.reqStart)
+				}
+			})
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace), tt
+}
+
+// finish stops the clock and returns the timings.
+// Body time is counted from the first byte of the final response.
+func (tt *timingTrace) finish() fetchTimings {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	now := tt.now()
+	if !tt.firstByte.IsZero() {
+		tt.t.body = now.Sub(tt.firstByte)
+	}
+	tt.t.total = now.Sub(tt.start)
+	return tt.t
+}
+
+// pageTimings maps URLs to how long they took to fetch.
+type pageTimings map[string]fetchTimings
+
+func (cp crawledPages) toTimings() pageTimings {
+	pt := make(pageTimings, len(cp))
+	for page, pi := range cp {
+		pt[page] = pi.timings
+	}
+	return pt
+}
+
+func (pt pageTimings) pages() []string {
+	pages := make([]string, 0, len(pt))
+	for page := range pt {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+	return pages
+}
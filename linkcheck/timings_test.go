@@ -0,0 +1,57 @@
+package linkcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+func TestTimingTrace(t *testing.T) {
+	var clock time.Time
+	at := func(ms int) { clock = time.Time{}.Add(time.Duration(ms) * time.Millisecond) }
+	at(1)
+	ctx, tt := traceTimings(context.Background(), func() time.Time { return clock })
+	trace := httptrace.ContextClientTrace(ctx)
+
+	// A new connection, dialing two addresses at once
+	at(1)
+	trace.GetConn("example.com:443")
+	trace.DNSStart(httptrace.DNSStartInfo{})
+	at(11)
+	trace.DNSDone(httptrace.DNSDoneInfo{})
+	trace.ConnectStart("tcp", "192.0.2.1:443")
+	at(13)
+	trace.ConnectStart("tcp", "[2001:db8::1]:443")
+	at(31)
+	trace.ConnectDone("tcp", "192.0.2.1:443", nil)
+	at(32)
+	trace.ConnectDone("tcp", "[2001:db8::1]:443", nil)
+	trace.TLSHandshakeStart()
+	at(61)
+	trace.TLSHandshakeDone(tls.ConnectionState{}, nil)
+	at(101)
+	trace.GotFirstResponseByte()
+
+	// A redirect over the same connection
+	at(151)
+	trace.GetConn("example.com:443")
+	at(171)
+	trace.GotFirstResponseByte()
+	at(201)
+
+	got := tt.finish()
+	ms := time.Millisecond
+	want := fetchTimings{
+		dns:     10 * ms,
+		connect: 20 * ms,
+		tls:     29 * ms,
+		ttfb:    120 * ms,
+		body:    30 * ms,
+		total:   200 * ms,
+	}
+	if got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// queryToken is a query parameter added to every request under the base URL,
+// e.g. for CMS preview environments that gate all pages behind ?token=...
+type queryToken struct {
+	name, value string
+}
+
+func parseQueryToken(s string) (queryToken, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return queryToken{}, fmt.Errorf("bad token %q: want name=value", s)
+	}
+	return queryToken{name, value}, nil
+}
+
+// stripToken removes the query token from link,
+// so that preview pages which echo it in their links are not reported twice.
+func (c *crawler) stripToken(link string) string {
+	if c.token.name == "" || !strings.Contains(link, c.token.name+"=") {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return c.stripRequestParams(u)
+}
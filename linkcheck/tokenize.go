@@ -0,0 +1,153 @@
+package linkcheck
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// defaultStreamThreshold is the page size over which
+// IDs and links are extracted without building a DOM.
+const defaultStreamThreshold = 4 << 20
+
+// tokenizedPage is what tokenizeIDsAndLinks finds on a page.
+type tokenizedPage struct {
+	ids, links []string
+	// forms are the actions of the page's forms
+	forms []formAction
+	// nofollow is as returned by nofollowLinks
+	nofollow map[string]bool
+	// robots are the directives in robots meta tags
+	robots []string
+	// lang and locales are as returned by pageLocales
+	lang    string
+	locales localeVariants
+	// amp is as returned by pageAMPLinks
+	amp ampLinks
+	// text is as returned by pageText, if opts.text is set
+	text string
+}
+
+// tokenizeIDsAndLinks is like getIDsAndLinks, but reads body with a tokenizer
+// instead of building a DOM, which takes many times a page's size in memory.
+// Elements are seen one at a time without their surroundings, so links
+// have no context, and a <base href> only applies to the links after it.
+// Form actions, nofollow links, robots meta tags, locales, AMP links,
+// and the page's text are found along the way.
+func tokenizeIDsAndLinks(pageurl *url.URL, body []byte, opts parseOptions) (tp tokenizedPage) {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	picture := &html.Node{Type: html.ElementNode, Data: "picture", DataAtom: atom.Picture}
+	var (
+		hasBase, hasLang       bool
+		svgDepth, pictureDepth int
+		// hiddenDepth counts open elements whose text pageText skips
+		hiddenDepth int
+		text        strings.Builder
+		nf          nofollowFinder
+	)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			tp.nofollow = nf.result()
+			if opts.text {
+				tp.text = normalizeText(text.String())
+			}
+			return tp
+		case html.TextToken:
+			if opts.text && hiddenDepth == 0 {
+				text.Write(z.Text())
+				text.WriteByte(' ')
+			}
+			continue
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Svg:
+				svgDepth = max(svgDepth-1, 0)
+			case atom.Picture:
+				pictureDepth = max(pictureDepth-1, 0)
+			case atom.Title, atom.Noscript, atom.Template:
+				hiddenDepth = max(hiddenDepth-1, 0)
+			}
+			continue
+		case html.StartTagToken, html.SelfClosingTagToken:
+		default:
+			continue
+		}
+		n := elementFromToken(z.Token(), svgDepth > 0)
+		if tt == html.StartTagToken {
+			switch n.DataAtom {
+			case atom.Svg:
+				svgDepth++
+			case atom.Picture:
+				pictureDepth++
+			case atom.Title, atom.Noscript, atom.Template:
+				hiddenDepth++
+			case atom.Script, atom.Style:
+				// Their contents are a single raw text token
+				if z.Next() == html.TextToken {
+					n.AppendChild(&html.Node{Type: html.TextNode, Data: string(z.Text())})
+				}
+			}
+		}
+		if n.DataAtom == atom.Source && pictureDepth > 0 {
+			n.Parent = picture
+		}
+		if !hasBase && n.DataAtom == atom.Base && hasAttr(n, "href") {
+			hasBase = true
+			if u, err := url.Parse(href(n)); err == nil {
+				pageurl = pageurl.ResolveReference(u)
+			}
+		}
+		if !hasLang && n.DataAtom == atom.Html {
+			hasLang = true
+			tp.lang = strings.ToLower(strings.TrimSpace(attr(n, "lang")))
+		}
+		if isRobotsMeta(n) {
+			tp.robots = append(tp.robots, robotsDirectives(attr(n, "content"))...)
+		}
+		tp.locales = tp.locales.addFrom(pageurl, n)
+		tp.amp.visit(pageurl, n)
+		if opts.ids {
+			tp.ids = append(tp.ids, idsFromNode(n)...)
+			tp.ids = append(tp.ids, targetNamesFromNode(n)...)
+		}
+		if !opts.links {
+			continue
+		}
+		if opts.jsonLD {
+			for _, jl := range linksFromJSONLD(pageurl, n) {
+				tp.links = append(tp.links, jl.url)
+			}
+		}
+		tp.links = append(tp.links, linksFromNode(pageurl, n, opts)...)
+		if fa, ok := actionOf(pageurl, n); ok {
+			tp.forms = append(tp.forms, fa)
+		}
+		nf.visit(pageurl, n)
+	}
+}
+
+// elementFromToken returns a detached element for a start tag,
+// namespaced the way the parser would for elements inside <svg>.
+func elementFromToken(t html.Token, inSVG bool) *html.Node {
+	n := &html.Node{
+		Type:     html.ElementNode,
+		Data:     t.Data,
+		DataAtom: t.DataAtom,
+		Attr:     t.Attr,
+	}
+	if inSVG || t.DataAtom == atom.Svg {
+		n.Namespace = "svg"
+		for i, a := range n.Attr {
+			if a.Key == "xlink:href" {
+				n.Attr[i] = html.Attribute{Namespace: "xlink", Key: "href", Val: a.Val}
+			}
+		}
+	}
+	return n
+}
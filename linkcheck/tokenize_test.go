@@ -0,0 +1,117 @@
+package linkcheck
+
+import (
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestTokenizeIDsAndLinks(t *testing.T) {
+	const page = `<!doctype html>
+<html lang=" EN-us " amp><head>
+<title>Post title</title>
+<base href="/blog/">
+<meta name="robots" content="max-snippet:50, NoIndex">
+<link rel="canonical" href="post">
+<link rel="amphtml" href="post/amp">
+<link rel="alternate" hreflang="es" href="/es/post">
+<link rel="alternate" hreflang="x-default" href="/">
+<link rel="stylesheet" href="/site.css">
+<meta http-equiv="refresh" content="30; url=/refreshed">
+<style>.hero { background: url(hero.jpg) }</style>
+<script type="application/ld+json">{"@type": "Article", "image": "/cover.jpg", "url": "<a href=nope>"}</script>
+<script>document.write("<a href='/scripted'>")</script>
+</head><body>
+<h1 id="top">Post</h1>
+<a name="old"></a><a id="same" name="same" href="#top">top</a>
+<a href="https://example.com/">out</a>
+<map><area href="/area" alt="area"></map>
+<svg><use xlink:href="/icons.svg#menu"></use><a href="/in-svg">svg link</a></svg>
+<picture><source srcset="/wide.webp 2x, /narrow.webp 1x"><img src="/fallback.jpg" alt=""></picture>
+<source srcset="/not-in-picture.webp">
+<div style="background-image: url('/bg.png')"></div>
+<iframe src="/embed"></iframe>
+<object data="/doc.pdf"></object>
+<form name="search"><input name="q"></form>
+<form action="subscribe" method="post"></form>
+<noscript><p>Enable JavaScript</p></noscript><template><p>Template</p></template>
+<p>Caf&eacute;   <b>open</b></p>
+<a href="/sponsored" rel="sponsored nofollow">ad</a>
+</body></html>`
+	pageurl, _ := url.Parse("https://example.com/blog/post")
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := parseOptions{ids: true, links: true, iframes: true, assets: true, jsonLD: true, text: true}
+	wantIDs, wantLinks, _ := getIDsAndLinks(pageurl, doc, opts)
+	tp := tokenizeIDsAndLinks(pageurl, []byte(page), opts)
+	ids, links := tp.ids, tp.links
+	slices.Sort(wantIDs)
+	slices.Sort(ids)
+	slices.Sort(wantLinks)
+	slices.Sort(links)
+	if !slices.Equal(ids, wantIDs) {
+		t.Errorf("got IDs %q; want %q", ids, wantIDs)
+	}
+	if !slices.Equal(links, wantLinks) {
+		t.Errorf("got links %q; want %q", links, wantLinks)
+	}
+	if !slices.Contains(links, "https://example.com/icons.svg#menu") ||
+		!slices.Contains(links, "https://example.com/blog/hero.jpg") ||
+		slices.Contains(links, "https://example.com/not-in-picture.webp") {
+		t.Errorf("got links %q", links)
+	}
+	wantForms := formActions(pageurl, doc)
+	for i := range wantForms {
+		wantForms[i].context = linkContext{}
+	}
+	if !reflect.DeepEqual(tp.forms, wantForms) {
+		t.Errorf("got forms %+v; want %+v", tp.forms, wantForms)
+	}
+	wantNofollow := nofollowLinks(pageurl, doc)
+	if !reflect.DeepEqual(tp.nofollow, wantNofollow) || len(tp.nofollow) != 1 {
+		t.Errorf("got nofollow %v; want %v", tp.nofollow, wantNofollow)
+	}
+	if want := metaRobots(doc); !slices.Equal(tp.robots, want) {
+		t.Errorf("got robots %q; want %q", tp.robots, want)
+	}
+	wantLang, wantLocales := pageLocales(pageurl, doc)
+	if tp.lang != wantLang || !reflect.DeepEqual(tp.locales, wantLocales) || len(tp.locales) != 1 {
+		t.Errorf("got locales %q %v; want %q %v", tp.lang, tp.locales, wantLang, wantLocales)
+	}
+	if want := pageAMPLinks(pageurl, doc); tp.amp != want || !tp.amp.isAMP {
+		t.Errorf("got AMP links %+v; want %+v", tp.amp, want)
+	}
+	if want := pageText(doc); tp.text != want || strings.Contains(want, "Template") {
+		t.Errorf("got text %q; want %q", tp.text, want)
+	}
+}
+
+func TestStreamThreshold(t *testing.T) {
+	site, _, pages := crawlSite(t, htmlSite(map[string]string{
+		"/": `<body><p id="dup">` + strings.Repeat("big ", 100) +
+			`<p id="dup"><a href="/small#here">small</a><a href="/missing">missing</a>`,
+		"/small": `<body><p id="here"><a href="/#dup">back</a>`,
+	}), func(c *crawler) {
+		c.streamThreshold = 200
+	})
+	home := pages[site+"/"]
+	if !home.ids.has("dup") || len(home.links) != 2 {
+		t.Errorf("got IDs %v and links %v", home.ids, home.links)
+	}
+	// Audits that need the DOM are skipped for the large page
+	if len(home.findings) != 1 || home.findings[0].category != categoryTokenizedPage {
+		t.Errorf("got findings %v", home.findings)
+	}
+	if _, ok := pages[site+"/missing"]; !ok {
+		t.Errorf("links on the large page weren't checked")
+	}
+	if small := pages[site+"/small"]; !small.ids.has("here") || len(small.links) != 1 {
+		t.Errorf("got IDs %v and links %v", small.ids, small.links)
+	}
+}
@@ -0,0 +1,85 @@
+package linkcheck
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// transportOptions configure how the crawler connects to hosts.
+type transportOptions struct {
+	// proxy and internalProxy are -proxy settings, see parseProxy
+	proxy, internalProxy string
+	// tlsCert and tlsKey are PEM files for a client certificate
+	// presented only to the base URL's host
+	tlsCert, tlsKey string
+	// dns is a -dns setting, see newResolver
+	dns string
+}
+
+// newTransport returns the transport for crawling base
+// and, with a -dns setting, the resolver it uses.
+// The transport is http.DefaultTransport unless options are set.
+func newTransport(base *url.URL, opts transportOptions) (http.RoundTripper, *net.Resolver, error) {
+	if opts == (transportOptions{}) {
+		return http.DefaultTransport, nil, nil
+	}
+	external, err := parseProxy(opts.proxy)
+	if err != nil {
+		return nil, nil, err
+	}
+	internal := external
+	if opts.internalProxy != "" {
+		if internal, err = parseProxy(opts.internalProxy); err != nil {
+			return nil, nil, err
+		}
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = proxyByHost(base.Host, internal, external)
+	var r *net.Resolver
+	if opts.dns != "" {
+		// DNS-over-HTTPS requests go through the proxy like any others
+		doh := &http.Client{Transport: tr.Clone(), Timeout: 30 * time.Second}
+		if r, err = newResolver(opts.dns, doh); err != nil {
+			return nil, nil, err
+		}
+		// Same as http.DefaultTransport, but with our resolver
+		tr.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Resolver:  r,
+		}).DialContext
+	}
+	if opts.tlsCert == "" && opts.tlsKey == "" {
+		return tr, r, nil
+	}
+	if opts.tlsCert == "" || opts.tlsKey == "" {
+		return nil, nil, fmt.Errorf("client certificates need both -tls-cert and -tls-key")
+	}
+	cert, err := tls.LoadX509KeyPair(opts.tlsCert, opts.tlsKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading client certificate: %w", err)
+	}
+	internalTr := tr.Clone()
+	internalTr.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	return hostRouter{base.Host, internalTr, tr}, r, nil
+}
+
+// hostRouter sends requests for host through internal
+// and all other requests through external,
+// so credentials for the site aren't offered to third parties.
+type hostRouter struct {
+	host               string
+	internal, external http.RoundTripper
+}
+
+func (hr hostRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.EqualFold(req.URL.Host, hr.host) {
+		return hr.internal.RoundTrip(req)
+	}
+	return hr.external.RoundTrip(req)
+}
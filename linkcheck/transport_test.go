@@ -0,0 +1,96 @@
+package linkcheck
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1
+// that works for clients and servers.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "linkrot"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func newMTLSServer() *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	return ts
+}
+
+func TestClientCertificate(t *testing.T) {
+	site := newMTLSServer()
+	defer site.Close()
+	other := newMTLSServer()
+	defer other.Close()
+
+	certFile, keyFile := writeTestCert(t)
+	base, _ := url.Parse(site.URL + "/")
+	rt, _, err := newTransport(base, transportOptions{tlsCert: certFile, tlsKey: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Trust the test servers
+	roots := x509.NewCertPool()
+	roots.AddCert(site.Certificate())
+	roots.AddCert(other.Certificate())
+	hr := rt.(hostRouter)
+	hr.internal.(*http.Transport).TLSClientConfig.RootCAs = roots
+	hr.external.(*http.Transport).TLSClientConfig = &tls.Config{RootCAs: roots}
+	cl := &http.Client{Transport: hr}
+
+	res, err := cl.Get(site.URL)
+	if err != nil {
+		t.Fatalf("base host: %v", err)
+	}
+	res.Body.Close()
+	// Other hosts never see the certificate
+	if res, err = cl.Get(other.URL); err == nil {
+		res.Body.Close()
+		t.Error("other host accepted a request without a client certificate")
+	}
+
+	if _, _, err = newTransport(base, transportOptions{tlsCert: certFile}); err == nil {
+		t.Error("expected error for a certificate without a key")
+	}
+}
@@ -0,0 +1,90 @@
+package linkcheck
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// trapDetector caps how many internal pages matching the same URL pattern
+// are crawled, so calendars, faceted search, and session IDs in URLs
+// can't generate pages forever.
+type trapDetector struct {
+	limit int
+	// counts are pages crawled by pattern
+	counts map[string]int
+	// reported are patterns whose first skipped URL was reported
+	reported map[string]bool
+}
+
+func newTrapDetector(limit int) *trapDetector {
+	return &trapDetector{
+		limit:    limit,
+		counts:   make(map[string]int),
+		reported: make(map[string]bool),
+	}
+}
+
+// allow reports whether link's pattern is still under the limit.
+func (td *trapDetector) allow(link string) bool {
+	return td.counts[urlPattern(link)] < td.limit
+}
+
+// count records that link is being crawled
+// and reports whether that brought its pattern to the limit.
+func (td *trapDetector) count(link string) bool {
+	pattern := urlPattern(link)
+	td.counts[pattern]++
+	return td.counts[pattern] == td.limit
+}
+
+// skip returns a finding for the first URL skipped for each pattern.
+func (td *trapDetector) skip(link string) []finding {
+	pattern := urlPattern(link)
+	if td.reported[pattern] {
+		return nil
+	}
+	td.reported[pattern] = true
+	return []finding{{
+		categoryCrawlerTrap,
+		"over " + strconv.Itoa(td.limit) + " pages match " + pattern + "; skipping the rest",
+	}}
+}
+
+var (
+	digitsRe = regexp.MustCompile(`[0-9]+`)
+	// tokenRe matches long segments that look like session IDs or hashes
+	tokenRe = regexp.MustCompile(`^[0-9A-Za-z_-]{20,}$`)
+)
+
+// urlPattern generalizes link so that generated URLs share a pattern:
+// numbers become N, long ID-like path segments become X,
+// and query parameter values are dropped.
+// For example, https://example.com/events/2024/05?day=3&view=list
+// becomes example.com/events/N/N?day&view.
+func urlPattern(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, seg := range segments {
+		if tokenRe.MatchString(seg) && digitsRe.MatchString(seg) {
+			segments[i] = "X"
+			continue
+		}
+		segments[i] = digitsRe.ReplaceAllString(seg, "N")
+	}
+	pattern := strings.ToLower(u.Host) + strings.Join(segments, "/")
+	if q := u.Query(); len(q) > 0 {
+		keys := make([]string, 0, len(q))
+		for key := range q {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pattern += "?" + strings.Join(keys, "&")
+	}
+	return pattern
+}
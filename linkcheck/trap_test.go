@@ -0,0 +1,53 @@
+package linkcheck
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestURLPattern(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://example.com/":                                "example.com/",
+		"https://example.com/events/2024/05?view=list&day=3":  "example.com/events/N/N?day&view",
+		"https://example.com/page-12":                         "example.com/page-N",
+		"https://example.com/s/a1b2c3d4e5f6a7b8c9d0e1f2/news": "example.com/s/X/news",
+		"https://example.com/news/some-long-story-headline":   "example.com/news/some-long-story-headline",
+	} {
+		if got := urlPattern(in); got != want {
+			t.Errorf("urlPattern(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestCrawlerTrap(t *testing.T) {
+	var hits int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="/calendar/1">calendar</a>`)
+	})
+	// Every day links to the next, forever
+	mux.HandleFunc("/calendar/", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		day, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/calendar/"))
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<a href="/calendar/%d">next</a>`, day+1)
+	})
+	site, c, pages := crawlSite(t, mux, func(c *crawler) {
+		c.maxPagesPerPattern = 5
+	})
+	if hits != 5 {
+		t.Errorf("crawled %d calendar pages; want 5", hits)
+	}
+	findings := pages.toFindings()
+	if fs := findings[site+"/calendar/6"]; len(fs) != 1 || fs[0].category != categoryCrawlerTrap {
+		t.Errorf("got findings %v; want crawler-trap for /calendar/6", findings)
+	}
+	if errs := pages.toURLErrors(c.scope(), true); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
@@ -0,0 +1,46 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// userAgentRule overrides the user agent sent to a host and its subdomains,
+// for sites that block agents they don't recognize.
+type userAgentRule struct {
+	host, userAgent string
+}
+
+// parseUserAgentRule parses rules like "example.com=curl/8.0".
+func parseUserAgentRule(s string) (userAgentRule, error) {
+	host, agent, ok := strings.Cut(s, "=")
+	if !ok || host == "" || agent == "" {
+		return userAgentRule{}, fmt.Errorf("bad user agent rule %q: want host=agent", s)
+	}
+	return userAgentRule{strings.ToLower(host), agent}, nil
+}
+
+func (ur userAgentRule) matches(host string) bool {
+	host = strings.ToLower(host)
+	return host == ur.host || strings.HasSuffix(host, "."+ur.host)
+}
+
+// userAgentFor returns the user agent to send when requesting pageurl.
+// The rule for the most specific host wins.
+func (c *crawler) userAgentFor(pageurl string) string {
+	if len(c.userAgents) == 0 {
+		return c.userAgent
+	}
+	u, err := url.Parse(pageurl)
+	if err != nil {
+		return c.userAgent
+	}
+	agent, best := c.userAgent, ""
+	for _, ur := range c.userAgents {
+		if ur.matches(u.Hostname()) && len(ur.host) > len(best) {
+			agent, best = ur.userAgent, ur.host
+		}
+	}
+	return agent
+}
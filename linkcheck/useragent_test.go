@@ -0,0 +1,82 @@
+package linkcheck
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+)
+
+func TestUserAgentFor(t *testing.T) {
+	var rules []userAgentRule
+	for _, s := range []string{"example.com=curl/8.0", "news.example.com=linkrot/1.0"} {
+		ur, err := parseUserAgentRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, ur)
+	}
+	c := crawler{userAgent: chromeUserAgent, userAgents: rules}
+	cases := map[string]string{
+		"https://example.com/":              "curl/8.0",
+		"https://WWW.Example.com/page":      "curl/8.0",
+		"https://news.example.com/story":    "linkrot/1.0",
+		"https://a.news.example.com/story":  "linkrot/1.0",
+		"https://notexample.com/":           chromeUserAgent,
+		"https://www.spotlightpa.org/news/": chromeUserAgent,
+	}
+	for pageurl, want := range cases {
+		if got := c.userAgentFor(pageurl); got != want {
+			t.Errorf("userAgentFor(%q) = %q; want %q", pageurl, got, want)
+		}
+	}
+	for _, s := range []string{"example.com", "=curl", "example.com="} {
+		if _, err := parseUserAgentRule(s); err == nil {
+			t.Errorf("parseUserAgentRule(%q): want error", s)
+		}
+	}
+}
+
+func TestRetryUserAgent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="/picky">picky</a><a href="/private">private</a><a href="/broken">broken</a>`)
+	})
+	mux.HandleFunc("/picky", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.UserAgent(), "Chrome") {
+			http.Error(w, "no bots", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<p>hello</p>`)
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "private", http.StatusForbidden)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.UserAgent(), "Chrome") {
+			http.Error(w, "no bots", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "oops", http.StatusInternalServerError)
+	})
+	site, _, pages := crawlSite(t, mux, func(c *crawler) {
+		c.retryUserAgent = "curl/8.0"
+		c.strict = true
+	})
+	if pi := pages[site+"/picky"]; pi.err != nil ||
+		len(pi.findings) != 1 || pi.findings[0].category != categoryBlockedAgent ||
+		!strings.Contains(pi.findings[0].detail, "curl/8.0") {
+		t.Errorf("/picky: got err %v and findings %v; want blocked-user-agent", pi.err, pi.findings)
+	}
+	if pi := pages[site+"/private"]; pi.err == nil || len(pi.findings) != 0 {
+		t.Errorf("/private: got err %v and findings %v; want 403 error", pi.err, pi.findings)
+	}
+	// A retry that fails another way doesn't count as fixing it
+	if pi := pages[site+"/broken"]; !requests.HasStatusErr(pi.err, http.StatusForbidden) || len(pi.findings) != 0 {
+		t.Errorf("/broken: got err %v and findings %v; want 403 error", pi.err, pi.findings)
+	}
+}
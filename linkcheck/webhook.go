@@ -0,0 +1,26 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// postWebhook sends the JSON report to the webhook URL.
+func (c *crawler) postWebhook(ctx context.Context, res results) error {
+	err := requests.
+		URL(c.webhookURL).
+		UserAgent(c.userAgent).
+		BodyJSON(res.toJSON(c.base)).
+		Client(c.deliveryClient).
+		Fetch(ctx)
+	// Client errors won't be fixed by retrying
+	if se := new(requests.StatusError); errors.As(err, &se) &&
+		se.StatusCode >= 400 && se.StatusCode < 500 &&
+		se.StatusCode != http.StatusTooManyRequests {
+		return permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package linkcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// failingTransport fails every request, to show a client isn't used.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("used the crawl's client")
+}
+
+func TestPostWebhook(t *testing.T) {
+	status := http.StatusOK
+	var got jsonReport
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s; want POST", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding report: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	c := crawler{
+		base:           "https://example.com/",
+		webhookURL:     ts.URL,
+		Client:         &http.Client{Transport: failingTransport{}},
+		deliveryClient: ts.Client(),
+		userAgent:      chromeUserAgent,
+	}
+	res := results{errs: urlErrors{"https://example.com/gone": {err: errTest}}}
+	if err := c.postWebhook(context.Background(), res); err != nil {
+		t.Fatal(err)
+	}
+	if got.Base != c.base || len(got.Errors) != 1 || got.Errors[0].URL != "https://example.com/gone" {
+		t.Errorf("posted %+v", got)
+	}
+
+	for _, test := range []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusBadGateway, false},
+	} {
+		status = test.status
+		err := c.postWebhook(context.Background(), res)
+		if err == nil {
+			t.Fatalf("%d: expected an error", test.status)
+		}
+		if perm := errors.As(err, new(permanentError)); perm != test.permanent {
+			t.Errorf("%d: permanent = %v; want %v", test.status, perm, test.permanent)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package linkcheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/carlmjohnson/flagext"
+	"github.com/carlmjohnson/requests"
+)
+
+const (
+	// workerRetries is how many times in a row a worker tries
+	// to reach its coordinator before giving up
+	workerRetries    = 5
+	workerRetryDelay = 2 * time.Second
+)
+
+// workerCLI runs the linkrot worker subcommand, which fetches URLs
+// for a crawl run with -coordinate by another linkrot process.
+func workerCLI(args []string) error {
+	fl := flag.NewFlagSet("linkrot worker", flag.ContinueOnError)
+	fl.Usage = func() {
+		const usage = `Usage of linkrot worker %s:
+
+linkrot worker [options] <coordinator URL>
+
+    linkrot worker gets its options from a linkrot process started with
+    -coordinate, then fetches the URLs it hands out and reports what it
+    finds on each page until the crawl is over. Files named in the
+    coordinator's options must exist on the worker's machine too.
+    Options that may hold credentials, such as -token, -cookie, and
+    -proxy, aren't passed on; set them on the worker with LINKROT_
+    environment variables instead.
+
+    Options may also be specified as env vars prefixed with "LINKROT_".
+
+Options:
+
+`
+		fmt.Fprintf(os.Stderr, usage, getVersion())
+		fl.PrintDefaults()
+	}
+	crawlers := fl.Int("crawlers", 0, "number of concurrent crawlers (default the coordinator's -crawlers)")
+	secret := fl.String("coordinator-secret", "", "the coordinator's shared `secret`")
+	caFile := fl.String("coordinator-ca", "", "trust the PEM certificates in `file` for an https coordinator,\nsuch as a self-signed -coordinator-cert")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	if err := flagext.ParseEnv(fl, "linkrot"); err != nil {
+		return err
+	}
+	if fl.NArg() != 1 {
+		fl.Usage()
+		return fmt.Errorf("worker needs exactly one coordinator URL; got %d", fl.NArg())
+	}
+	if *crawlers < 0 {
+		return fmt.Errorf("bad crawler count: %d", *crawlers)
+	}
+	if *secret == "" {
+		return fmt.Errorf("worker needs the coordinator's -coordinator-secret")
+	}
+	coordinatorURL := fl.Arg(0)
+	cl, err := coordinatorClient(coordinatorURL, *caFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	var cfg workerConfig
+	if err := requests.
+		URL(coordinatorURL).
+		Client(cl).
+		Path("config").
+		Bearer(*secret).
+		ToJSON(&cfg).
+		Fetch(ctx); err != nil {
+		return fmt.Errorf("getting options from coordinator: %w", err)
+	}
+	c, cleanup, err := parseCrawler(cfg.Args)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if *crawlers > 0 {
+		c.workers = *crawlers
+	}
+	return c.work(ctx, cl, coordinatorURL, *secret)
+}
+
+// coordinatorClient returns the client for reaching the coordinator at rawURL,
+// trusting the certificates in caFile if it's set.
+// The secret is sent with every request, so a coordinator that isn't
+// on the loopback interface must be reached over HTTPS.
+func coordinatorClient(rawURL, caFile string) (*http.Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("bad coordinator URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !isLoopback(net.JoinHostPort(u.Hostname(), "80")) {
+			return nil, fmt.Errorf("coordinator URL %s must use https unless it's on this machine", rawURL)
+		}
+	default:
+		return nil, fmt.Errorf("bad coordinator URL: %q", rawURL)
+	}
+	if caFile == "" {
+		return http.DefaultClient, nil
+	}
+	b, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates in %s", caFile)
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Transport: tr}, nil
+}
+
+// work fetches URLs leased from the coordinator at coordinatorURL with cl,
+// authenticating with secret, with c.workers goroutines until the crawl is over.
+func (c *crawler) work(ctx context.Context, cl *http.Client, coordinatorURL, secret string) error {
+	c.Info("starting worker", "coordinator", coordinatorURL, "crawlers", c.workers)
+	c.contents = newContentIndex()
+	if c.checkForms {
+		c.postEndpoints = newPostEndpoints()
+	}
+	if c.suggestFixes {
+		c.suggestions = newSuggestionBudget()
+	}
+	workCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	errs := make(chan error, c.workers)
+	for i := 0; i < c.workers; i++ {
+		go func() {
+			err := c.workLeases(workCtx, cl, coordinatorURL, secret)
+			if err != nil {
+				stop()
+			}
+			errs <- err
+		}()
+	}
+	var err error
+	for i := 0; i < c.workers; i++ {
+		if err1 := <-errs; err == nil {
+			err = err1
+		}
+	}
+	if ctx.Err() != nil {
+		return ErrCancelled
+	}
+	return err
+}
+
+func (c *crawler) workLeases(ctx context.Context, cl *http.Client, coordinatorURL, secret string) error {
+	failures := 0
+	for ctx.Err() == nil {
+		var buf bytes.Buffer
+		err := requests.
+			URL(coordinatorURL).
+			Client(cl).
+			Path("lease").
+			Bearer(secret).
+			CheckStatus(http.StatusOK, http.StatusNoContent).
+			ToBytesBuffer(&buf).
+			Fetch(ctx)
+		switch {
+		case requests.HasStatusErr(err, http.StatusGone):
+			return nil
+		case err != nil && ctx.Err() == nil:
+			if failures++; failures > workerRetries {
+				return fmt.Errorf("reaching coordinator: %w", err)
+			}
+			c.Warn("could not reach coordinator; retrying", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(workerRetryDelay):
+			}
+			continue
+		case err != nil:
+			return nil
+		}
+		failures = 0
+		// Nothing to do yet
+		if buf.Len() == 0 {
+			continue
+		}
+		var wl workerLease
+		if err = json.Unmarshal(buf.Bytes(), &wl); err != nil {
+			return fmt.Errorf("reading lease: %w", err)
+		}
+		if wl.Post {
+			c.postEndpoints.add(wl.URL)
+		}
+		fr := c.fetch(ctx, wl.URL)
+		if ctx.Err() != nil {
+			return nil
+		}
+		err = requests.
+			URL(coordinatorURL).
+			Client(cl).
+			Path("result").
+			Param("lease", wl.ID).
+			Bearer(secret).
+			BodyJSON(newWorkerResult(&fr)).
+			Post().
+			CheckStatus(http.StatusNoContent).
+			Fetch(ctx)
+		if requests.HasStatusErr(err, http.StatusConflict) {
+			c.Warn("lease expired before reporting", "url", wl.URL)
+		} else if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("reporting result: %w", err)
+		}
+	}
+	return nil
+}
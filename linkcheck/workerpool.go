@@ -0,0 +1,61 @@
+package linkcheck
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// fetchPool is a fixed set of workers fetching URLs for a crawlSupervisor,
+// which hands out URLs on jobs, collects results,
+// and closes the pool when the crawl is done or cancelled.
+type fetchPool struct {
+	jobs    chan string
+	results chan fetchResult
+	// ctx is cancelled along with the crawl
+	ctx context.Context
+	g   *errgroup.Group
+}
+
+// startFetchPool starts n workers. Results are buffered one per worker,
+// so workers don't wait on the supervisor between fetches.
+// Once ctx is cancelled, workers drop their results instead of
+// blocking on a supervisor that has stopped reading them.
+func (c *crawler) startFetchPool(ctx context.Context, n int) *fetchPool {
+	g, ctx := errgroup.WithContext(ctx)
+	fp := &fetchPool{
+		jobs:    make(chan string),
+		results: make(chan fetchResult, n),
+		ctx:     ctx,
+		g:       g,
+	}
+	for i := 0; i < n; i++ {
+		g.Go(func() error {
+			for url := range fp.jobs {
+				fr := c.fetch(ctx, url)
+				select {
+				case fp.results <- fr:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+	}
+	return fp
+}
+
+// do runs f as one of the pool's fetches, so close waits for it.
+func (fp *fetchPool) do(f func()) {
+	fp.g.Go(func() error {
+		f()
+		return nil
+	})
+}
+
+// close stops handing out URLs and waits for the workers to finish
+// their fetches, so none are left running after the crawl.
+func (fp *fetchPool) close() error {
+	close(fp.jobs)
+	return fp.g.Wait()
+}
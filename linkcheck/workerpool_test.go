@@ -0,0 +1,66 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type inFlightTransport struct {
+	n atomic.Int32
+}
+
+func (t *inFlightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n.Add(1)
+	defer t.n.Add(-1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCrawlCancelWaitsForFetches(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			io.WriteString(w, `<body><a href="/slow1">1</a><a href="/slow2">2</a><a href="/slow3">3</a>`)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	tr := &inFlightTransport{}
+	c := testCrawler(ts.URL + "/")
+	c.workers = 2
+	c.Client = &http.Client{Transport: tr}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	pages, cancelled := c.crawlContext(ctx)
+	if !cancelled {
+		t.Fatal("crawl wasn't cancelled")
+	}
+	if n := tr.n.Load(); n != 0 {
+		t.Errorf("%d fetches still running after the crawl", n)
+	}
+	if _, ok := pages[ts.URL+"/"]; !ok {
+		t.Errorf("lost the pages crawled before cancelling: %v", pages)
+	}
+}
+
+func TestServePprof(t *testing.T) {
+	addr, stop, err := servePprof("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	res, err := http.Get("http://" + addr + "/debug/pprof/cmdline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got status %d", res.StatusCode)
+	}
+}